@@ -0,0 +1,397 @@
+// Package client is a Go SDK for the URL shortener HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/analytics"
+	"github.com/connorpalermo/url-shortener/internal/endpoint"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+)
+
+type (
+	ShortenRequest  = endpoint.ShortenRequest
+	ShortenResponse = endpoint.ShortenResponse
+	HealthCheck     = endpoint.HealthCheck
+	Readiness       = endpoint.Readiness
+	LinkVariants    = endpoint.LinkVariants
+	LinkList        = endpoint.LinkList
+	Link            = urlshortener.Link
+	MetadataUpdate  = urlshortener.MetadataUpdate
+	LinkFilter      = urlshortener.LinkFilter
+	LinkStatus      = urlshortener.LinkStatus
+	Destination     = urlshortener.Destination
+	Rule            = urlshortener.Rule
+	Device          = urlshortener.Device
+	QueryPolicy     = urlshortener.QueryPolicy
+	Variant         = endpoint.Variant
+	AnalyticsQuery  = analytics.Query
+	AnalyticsReport = analytics.Report
+	Interval        = analytics.Interval
+
+	Client struct {
+		baseURL    *url.URL
+		httpClient *http.Client
+		timeout    time.Duration
+		maxRetries int
+		backoff    time.Duration
+		adminToken string
+	}
+
+	Option func(*Client)
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 2
+	DefaultBackoff    = 100 * time.Millisecond
+	maxErrorBodyBytes = 4 << 10
+
+	IntervalHour = analytics.Hour
+	IntervalDay  = analytics.Day
+	IntervalWeek = analytics.Week
+
+	QueryDrop     = urlshortener.QueryDrop
+	QueryAppend   = urlshortener.QueryAppend
+	QueryOverride = urlshortener.QueryOverride
+
+	DeviceIOS     = urlshortener.DeviceIOS
+	DeviceAndroid = urlshortener.DeviceAndroid
+	DeviceMobile  = urlshortener.DeviceMobile
+	DeviceDesktop = urlshortener.DeviceDesktop
+
+	StatusActive    = urlshortener.StatusActive
+	StatusScheduled = urlshortener.StatusScheduled
+	StatusExpired   = urlshortener.StatusExpired
+	StatusDisabled  = urlshortener.StatusDisabled
+	StatusBlocked   = urlshortener.StatusBlocked
+	StatusExhausted = urlshortener.StatusExhausted
+)
+
+// New returns a client for the service deployed at baseURL, e.g. https://<api-id>.execute-api.us-east-1.amazonaws.com/prod.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base URL %q must be absolute", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		timeout:    DefaultTimeout,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	hc := &http.Client{}
+	if c.httpClient != nil {
+		copied := *c.httpClient
+		hc = &copied
+	}
+	// Resolve needs to see the redirect rather than follow it.
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	c.httpClient = hc
+
+	return c, nil
+}
+
+// WithHTTPClient sets the underlying HTTP client. Its redirect policy is overridden.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout bounds each attempt of a call. Zero disables the per-attempt timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithAdminToken sends the deployment's admin token with every call. UpdateLink, ListLinks and
+// Analytics need it, and Link only returns the URLs of confidential links with it.
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// WithRetries sets how many times a failed call is retried and the initial backoff, which doubles per retry.
+// Only GET and HEAD calls are retried after they reached the service; other calls are only retried
+// when the connection could not be made, so a write is never sent twice.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// Shorten returns the short code for originalURL using the default link options.
+func (c *Client) Shorten(ctx context.Context, originalURL string) (*ShortenResponse, error) {
+	return c.ShortenWith(ctx, &ShortenRequest{OriginalURL: originalURL})
+}
+
+// ShortenWith returns the short code for a request carrying per-link options such as the redirect type.
+func (c *Client) ShortenWith(ctx context.Context, req *ShortenRequest) (*ShortenResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ShortenResponse
+	err = c.do(ctx, http.MethodPost, endpoint.ShortenURLEndpoint, body, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Resolve returns the destination shortURL redirects to without following the redirect.
+func (c *Client) Resolve(ctx context.Context, shortURL string) (string, error) {
+	if shortURL == "" {
+		return "", errors.New("short URL is required")
+	}
+
+	var location string
+	err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(shortURL), nil, func(r *http.Response) error {
+		if r.StatusCode < 300 || r.StatusCode >= 400 {
+			return newError(r)
+		}
+		location = r.Header.Get("Location")
+		if location == "" {
+			return fmt.Errorf("redirect for %q has no Location header", shortURL)
+		}
+		return nil
+	})
+	return location, err
+}
+
+// Variants returns the weighted destinations of shortURL with their click counts.
+func (c *Client) Variants(ctx context.Context, shortURL string) (*LinkVariants, error) {
+	var resp LinkVariants
+	path := linkPath(shortURL) + "/variants"
+	err := c.do(ctx, http.MethodGet, path, nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Link returns the stored record of shortURL, including its metadata and status.
+func (c *Client) Link(ctx context.Context, shortURL string) (*Link, error) {
+	var resp Link
+	err := c.do(ctx, http.MethodGet, linkPath(shortURL), nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateLink changes the title, tags or notes of shortURL. Nil fields of update are left as they are.
+func (c *Client) UpdateLink(ctx context.Context, shortURL string, update *MetadataUpdate) (*Link, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp Link
+	err = c.do(ctx, http.MethodPatch, linkPath(shortURL), body, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListLinks returns one page of the links matching filter. Pass the NextCursor of the result as
+// filter.Cursor to read the next page; it is empty on the last one. It needs the admin token.
+func (c *Client) ListLinks(ctx context.Context, filter LinkFilter) (*LinkList, error) {
+	path := endpoint.LinksEndpoint
+	if query := endpoint.LinkFilterQuery(filter); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp LinkList
+	err := c.do(ctx, http.MethodGet, path, nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Analytics returns the clicks of shortURL over time. Zero fields of q are left to the service's
+// defaults: daily buckets over the last 30 days. It needs the admin token.
+func (c *Client) Analytics(ctx context.Context, shortURL string, q AnalyticsQuery) (*AnalyticsReport, error) {
+	path := "/" + url.PathEscape(shortURL) + "/analytics"
+	if query := endpoint.AnalyticsQueryValues(q); len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp AnalyticsReport
+	err := c.do(ctx, http.MethodGet, path, nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health returns the liveness details of the service.
+func (c *Client) Health(ctx context.Context) (*HealthCheck, error) {
+	var resp HealthCheck
+	err := c.do(ctx, http.MethodGet, endpoint.HealthCheckEndpoint, nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK {
+			return newError(r)
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Ready returns the readiness report. A degraded service is reported with both the report and an ErrUnavailable error.
+func (c *Client) Ready(ctx context.Context) (*Readiness, error) {
+	var resp Readiness
+	var statusErr error
+	err := c.do(ctx, http.MethodGet, endpoint.ReadinessEndpoint, nil, func(r *http.Response) error {
+		if r.StatusCode != http.StatusOK && r.StatusCode != http.StatusServiceUnavailable {
+			return newError(r)
+		}
+		if r.StatusCode == http.StatusServiceUnavailable {
+			statusErr = &Error{StatusCode: r.StatusCode, Message: endpoint.StatusDegraded}
+		}
+		return json.NewDecoder(r.Body).Decode(&resp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, statusErr
+}
+
+// linkPath returns the path of a link below /links, escaping the short URL.
+func linkPath(shortURL string) string {
+	return endpoint.LinksEndpoint + "/" + url.PathEscape(shortURL)
+}
+
+// do sends the request, retrying retryable errors with exponential backoff.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, handle func(*http.Response) error) error {
+	target := c.baseURL.String() + path
+	backoff := c.backoff
+
+	var err error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		err = c.attempt(ctx, method, target, body, handle)
+		if err == nil || !retryable(ctx, method, err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) attempt(ctx context.Context, method, target string, body []byte, handle func(*http.Response) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return handle(resp)
+}
+
+// retryable reports whether a failed attempt can be sent again. Calls that are not idempotent are
+// only retried when the connection failed, which proves the service never saw them.
+func retryable(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
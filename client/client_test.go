@@ -0,0 +1,411 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/analytics"
+	"github.com/connorpalermo/url-shortener/internal/endpoint"
+	"github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/router"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockUrlShortenerProvider struct {
+	mock.Mock
+}
+
+func (m *MockUrlShortenerProvider) GetOriginalURL(ctx context.Context, shortened string) (string, error) {
+	args := m.Called(ctx, shortened)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) ShortenURL(ctx context.Context, originalUrl string, opts urlshortener.LinkOptions) (string, error) {
+	args := m.Called(ctx, originalUrl, opts)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) GetLink(ctx context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) RecordVariantClick(ctx context.Context, shortened string, variant int) error {
+	args := m.Called(ctx, shortened, variant)
+	return args.Error(0)
+}
+
+func (m *MockUrlShortenerProvider) ConsumeClick(ctx context.Context, shortened string) error {
+	args := m.Called(ctx, shortened)
+	return args.Error(0)
+}
+
+func (m *MockUrlShortenerProvider) UpdateMetadata(ctx context.Context, shortened string, update urlshortener.MetadataUpdate) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened, update)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) ListLinks(ctx context.Context, filter urlshortener.LinkFilter) (*urlshortener.LinkPage, error) {
+	args := m.Called(ctx, filter)
+	page, _ := args.Get(0).(*urlshortener.LinkPage)
+	return page, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) ResolveLink(ctx context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func newTestServer(t *testing.T, provider *MockUrlShortenerProvider) *httptest.Server {
+	logger := zaptest.NewLogger(t)
+	srv := httptest.NewServer(router.New(logger, &endpoint.Handler{
+		Logger:               logger,
+		UrlShortenerProvider: provider,
+		AdminToken:           "admin",
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Shorten(t *testing.T) {
+	tests := map[string]struct {
+		shortenURL    string
+		shortenError  error
+		expectedCode  string
+		expectedError error
+	}{
+		"Happy Path": {
+			shortenURL:   "b",
+			expectedCode: "b",
+		},
+		"Sad Path server error": {
+			shortenError:  errors.New("error"),
+			expectedError: ErrServer,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := new(MockUrlShortenerProvider)
+			provider.On("ShortenURL", mock.Anything, "http://www.example.com", urlshortener.LinkOptions{}).Return(tc.shortenURL, tc.shortenError)
+			srv := newTestServer(t, provider)
+
+			c, err := New(srv.URL, WithRetries(1, time.Millisecond))
+			assert.NoError(t, err)
+
+			resp, err := c.Shorten(context.Background(), "http://www.example.com")
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				// a write that reached the service is never sent twice
+				provider.AssertNumberOfCalls(t, "ShortenURL", 1)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCode, resp.ShortenURL)
+		})
+	}
+}
+
+func Test_ShortenWith(t *testing.T) {
+	provider := new(MockUrlShortenerProvider)
+	provider.On("ShortenURL", mock.Anything, "http://www.example.com", urlshortener.LinkOptions{
+		RedirectType: http.StatusPermanentRedirect,
+		QueryPolicy:  urlshortener.QueryAppend,
+		Destinations: []urlshortener.Destination{{URL: "http://a.example.com", Weight: 1}, {URL: "http://b.example.com", Weight: 3}},
+		Rules:        []urlshortener.Rule{{Device: urlshortener.DeviceIOS, URL: "http://apps.example.com"}},
+	}).Return("b", nil)
+	srv := newTestServer(t, provider)
+
+	c, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	// the request is built from the client's own names only
+	resp, err := c.ShortenWith(context.Background(), &ShortenRequest{
+		OriginalURL:  "http://www.example.com",
+		RedirectType: http.StatusPermanentRedirect,
+		QueryPolicy:  QueryAppend,
+		Destinations: []Destination{{URL: "http://a.example.com", Weight: 1}, {URL: "http://b.example.com", Weight: 3}},
+		Rules:        []Rule{{Device: DeviceIOS, URL: "http://apps.example.com"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", resp.ShortenURL)
+}
+
+func Test_Shorten_BadRequest(t *testing.T) {
+	provider := new(MockUrlShortenerProvider)
+	srv := newTestServer(t, provider)
+
+	c, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	_, err = c.Shorten(context.Background(), "")
+
+	assert.ErrorIs(t, err, ErrBadRequest)
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, endpoint.InvalidBodyError, apiErr.Message)
+}
+
+func Test_Resolve(t *testing.T) {
+	provider := new(MockUrlShortenerProvider)
+	provider.On("ResolveLink", mock.Anything, "b").Return(&urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com"}, nil)
+	provider.On("ResolveLink", mock.Anything, "c").Return(nil, errors.New("error"))
+	provider.On("ResolveLink", mock.Anything, "d").Return(nil, urlshortener.ErrLinkNotFound)
+	srv := newTestServer(t, provider)
+
+	c, err := New(srv.URL+"/", WithRetries(0, 0))
+	assert.NoError(t, err)
+
+	location, err := c.Resolve(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://www.example.com", location)
+
+	_, err = c.Resolve(context.Background(), "c")
+	assert.ErrorIs(t, err, ErrServer)
+
+	_, err = c.Resolve(context.Background(), "d")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_Variants(t *testing.T) {
+	provider := new(MockUrlShortenerProvider)
+	provider.On("GetLink", mock.Anything, "b").Return(&urlshortener.Link{
+		ShortURL:      "b",
+		VariantClicks: map[string]int64{"0": 7},
+		LinkOptions: urlshortener.LinkOptions{
+			Destinations: []urlshortener.Destination{{URL: "http://a.example.com", Weight: 1}},
+		},
+	}, nil)
+	srv := newTestServer(t, provider)
+
+	c, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	variants, err := c.Variants(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.Len(t, variants.Variants, 1)
+	assert.EqualValues(t, 7, variants.Variants[0].Clicks)
+}
+
+func Test_Links(t *testing.T) {
+	title := "Launch"
+	provider := new(MockUrlShortenerProvider)
+	provider.On("GetLink", mock.Anything, "b").Return(&urlshortener.Link{
+		ShortURL:    "b",
+		LinkOptions: urlshortener.LinkOptions{Metadata: urlshortener.Metadata{Tags: []string{"q3"}}},
+	}, nil)
+	provider.On("UpdateMetadata", mock.Anything, "b", urlshortener.MetadataUpdate{Title: &title}).Return(&urlshortener.Link{
+		ShortURL:    "b",
+		LinkOptions: urlshortener.LinkOptions{Metadata: urlshortener.Metadata{Title: title, Tags: []string{"q3"}}},
+	}, nil)
+	provider.On("ListLinks", mock.Anything, urlshortener.LinkFilter{Tag: "q3 launch", Limit: 1, Cursor: "abc"}).Return(&urlshortener.LinkPage{
+		Links:      []*urlshortener.Link{{ShortURL: "b"}},
+		NextCursor: "def",
+	}, nil)
+	srv := newTestServer(t, provider)
+
+	c, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	link, err := c.Link(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"q3"}, link.Tags)
+
+	_, err = c.UpdateLink(context.Background(), "b", &MetadataUpdate{Title: &title})
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	admin, err := New(srv.URL, WithAdminToken("admin"))
+	assert.NoError(t, err)
+	link, err = admin.UpdateLink(context.Background(), "b", &MetadataUpdate{Title: &title})
+	assert.NoError(t, err)
+	assert.Equal(t, title, link.Title)
+
+	_, err = c.ListLinks(context.Background(), LinkFilter{})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	links, err := admin.ListLinks(context.Background(), LinkFilter{Tag: "q3 launch", Limit: 1, Cursor: "abc"})
+	assert.NoError(t, err)
+	assert.Len(t, links.Links, 1)
+	assert.Equal(t, "def", links.NextCursor)
+	provider.AssertExpectations(t)
+}
+
+func Test_Analytics(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	query := AnalyticsQuery{From: from, Interval: IntervalWeek}
+	provider := new(MockUrlShortenerProvider)
+	provider.On("GetLink", mock.Anything, "b").Return(&urlshortener.Link{ShortURL: "b"}, nil)
+	provider.On("GetLink", mock.Anything, "c").Return(nil, urlshortener.ErrLinkNotFound)
+	logger := zaptest.NewLogger(t)
+	srv := httptest.NewServer(router.New(logger, &endpoint.Handler{
+		Logger:               logger,
+		UrlShortenerProvider: provider,
+		Analytics: &analytics.Reporter{DB: aggregateDB{
+			"2025-03-02": {"clicks": 2, "device#mobile": 2},
+		}},
+		AdminToken: "admin",
+	}))
+	defer srv.Close()
+
+	anonymous, err := New(srv.URL)
+	assert.NoError(t, err)
+	_, err = anonymous.Analytics(context.Background(), "b", query)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	c, err := New(srv.URL, WithAdminToken("admin"))
+	assert.NoError(t, err)
+
+	report, err := c.Analytics(context.Background(), "b", query)
+	assert.NoError(t, err)
+	assert.Equal(t, analytics.Week, report.Interval)
+	assert.EqualValues(t, 2, report.Clicks)
+	assert.Equal(t, map[string]int64{"mobile": 2}, report.Devices)
+
+	_, err = c.Analytics(context.Background(), "c", query)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.Analytics(context.Background(), "b", AnalyticsQuery{Interval: "month"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	provider.AssertExpectations(t)
+}
+
+// aggregateDB serves the day buckets of every link's clicks.
+type aggregateDB map[string]map[string]int64
+
+func (db aggregateDB) QueryAggregates(_ context.Context, _, from, to string) ([]persistence.AggregateItem, error) {
+	var items []persistence.AggregateItem
+	for bucket, counters := range db {
+		if bucket >= from && bucket <= to {
+			items = append(items, persistence.AggregateItem{Bucket: bucket, Counters: counters})
+		}
+	}
+	return items, nil
+}
+
+func Test_Health(t *testing.T) {
+	srv := newTestServer(t, new(MockUrlShortenerProvider))
+
+	c, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	health, err := c.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, endpoint.ApiVersion, health.Version)
+
+	ready, err := c.Ready(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, endpoint.StatusOK, ready.Status)
+}
+
+func Test_RetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"shortened_url":"b"}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithRetries(2, time.Millisecond))
+	assert.NoError(t, err)
+
+	_, err = c.Link(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func Test_Retries_Writes(t *testing.T) {
+	tests := map[string]struct {
+		status        int
+		dialFailures  int32
+		expectedCalls int32
+		checkError    bool
+	}{
+		"Happy Path retried after a failed connection": {
+			dialFailures:  1,
+			expectedCalls: 1,
+		},
+		"Sad Path server error is not retried": {
+			status:        http.StatusServiceUnavailable,
+			expectedCalls: 1,
+			checkError:    true,
+		},
+		"Sad Path rate limit is not retried": {
+			status:        http.StatusTooManyRequests,
+			expectedCalls: 1,
+			checkError:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if tc.status != 0 {
+					http.Error(w, http.StatusText(tc.status), tc.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"shortened_url":"b"}`))
+			}))
+			defer srv.Close()
+
+			var dials atomic.Int32
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			dial := transport.DialContext
+			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if dials.Add(1) <= tc.dialFailures {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+				}
+				return dial(ctx, network, addr)
+			}
+
+			c, err := New(srv.URL, WithHTTPClient(&http.Client{Transport: transport}), WithRetries(2, time.Millisecond))
+			assert.NoError(t, err)
+
+			resp, err := c.Shorten(context.Background(), "http://www.example.com")
+			assert.Equal(t, tc.checkError, err != nil)
+			if !tc.checkError {
+				assert.Equal(t, "b", resp.ShortenURL)
+			}
+			assert.Equal(t, tc.expectedCalls, calls.Load())
+		})
+	}
+}
+
+func Test_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithTimeout(10*time.Millisecond), WithRetries(0, 0))
+	assert.NoError(t, err)
+
+	_, err = c.Health(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_New_InvalidBaseURL(t *testing.T) {
+	_, err := New("not-a-url")
+	assert.Error(t, err)
+}
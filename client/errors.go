@@ -0,0 +1,63 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrNotFound     = errors.New("not found")
+	ErrGone         = errors.New("gone")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrUnexpected   = errors.New("unexpected response")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error is returned when the service answers with a status the call does not expect.
+// It matches one of the Err* sentinels with errors.Is.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("url shortener returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("url shortener returned %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusGone:
+		return ErrGone
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusServiceUnavailable:
+		return ErrUnavailable
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return ErrUnexpected
+	}
+}
+
+func newError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(b)),
+	}
+}
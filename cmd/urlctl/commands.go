@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/linkcheck"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
+)
+
+const (
+	OutputJSON  = "json"
+	OutputTable = "table"
+	usage       = `usage: urlctl [-o json|table] [-v] <command> [args]
+
+commands:
+  shorten [-redirect 301|302|307|308] [-query drop|append|override] [-prefix]
+          [-dest <url>=<weight>]... [-sticky] [-rule '<key>=<value>,... <url>']...
+          [-password <password>] [-max-clicks <n>]
+          [-not-before <RFC3339>] [-not-after <RFC3339>] [-prelaunch <url>]
+          [-title <title>] [-tag <tag>]... [-notes <notes>] [-owner <owner>]
+          [-interstitial] <url>
+                      shorten a URL, reusing the existing code if it was seen before
+  resolve <code>      print the destination a code redirects to
+  inspect <code>      print the stored record for a code
+  disable <code>      stop a code from redirecting
+  enable <code>       let a disabled code redirect again
+  delete <code>       remove a code for good, it answers 404 from then on
+  update [-title <title>] [-tags <tag>,...] [-notes <notes>] <code>
+                      change the metadata of a code, an empty value clears it
+  list [-owner <owner>] [-tag <tag>] [-domain <host>] [-after <RFC3339>]
+       [-before <RFC3339>] [-limit <n>] [-cursor <cursor>] [-asc]
+                      list a page of codes, newest first
+  recheck [-all] [<code>...]
+                      look codes up in the reputation source again, -all walks every code
+  check-links [-concurrency <n>] [-host-delay <duration>] [-timeout <duration>]
+                      probe the destination of every live code and list the broken ones
+  webhooks add [-event <type>]... [-secret <secret>] <url>
+                      subscribe a URL to link events, all of them without -event
+  webhooks list       list the webhook subscriptions
+  webhooks remove <id>
+                      stop sending events to a subscription
+  webhooks deliveries [-limit <n>] <id>
+                      print the latest delivery attempts of a subscription
+  counter             print the current url-counter value
+`
+)
+
+var errUsage = errors.New("invalid usage")
+
+type (
+	linkService interface {
+		ShortenURL(ctx context.Context, url string, opts urlshortener.LinkOptions) (string, error)
+		GetOriginalURL(ctx context.Context, shortened string) (string, error)
+		GetLink(ctx context.Context, shortened string) (*urlshortener.Link, error)
+		SetDisabled(ctx context.Context, shortened string, disabled bool) error
+		DeleteLink(ctx context.Context, shortened string) error
+		Counter(ctx context.Context) (int64, error)
+		UpdateMetadata(ctx context.Context, shortened string, update urlshortener.MetadataUpdate) (*urlshortener.Link, error)
+		ListLinks(ctx context.Context, filter urlshortener.LinkFilter) (*urlshortener.LinkPage, error)
+		RecheckLink(ctx context.Context, shortened string) (*urlshortener.Link, error)
+		CheckLinks(ctx context.Context, checker urlshortener.LinkChecker) (*urlshortener.LinkCheckReport, error)
+		SaveSubscription(ctx context.Context, sub webhook.Subscription) error
+		Subscriptions(ctx context.Context) ([]webhook.Subscription, error)
+		DeleteSubscription(ctx context.Context, id string) error
+		Deliveries(ctx context.Context, webhookID string, limit int) ([]webhook.Delivery, error)
+	}
+
+	options struct {
+		output  string
+		verbose bool
+	}
+
+	// result is rendered either as its JSON value or as key/value rows.
+	result struct {
+		value any
+		rows  [][2]string
+	}
+)
+
+func parseFlags(args []string, stderr io.Writer) (options, []string, error) {
+	var opts options
+	fs := flag.NewFlagSet("urlctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	fs.StringVar(&opts.output, "o", OutputTable, "output format, json or table")
+	fs.BoolVar(&opts.verbose, "v", false, "write service logs to stderr")
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	if opts.output != OutputJSON && opts.output != OutputTable {
+		fmt.Fprintf(stderr, "unknown output format %q\n", opts.output)
+		return opts, nil, errUsage
+	}
+	return opts, fs.Args(), nil
+}
+
+func run(ctx context.Context, svc linkService, opts options, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, usage)
+		return errUsage
+	}
+
+	res, err := execute(ctx, svc, args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	return render(out, opts.output, res)
+}
+
+func execute(ctx context.Context, svc linkService, command string, args []string) (*result, error) {
+	switch command {
+	case "shorten":
+		var opts urlshortener.LinkOptions
+		var query string
+		fs := flag.NewFlagSet("shorten", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.IntVar(&opts.RedirectType, "redirect", 0, "redirect status code")
+		fs.StringVar(&query, "query", "", "query string policy")
+		fs.BoolVar(&opts.Prefix, "prefix", false, "forward the path after the short code")
+		fs.Func("dest", "weighted destination as <url>=<weight>, repeatable", func(v string) error {
+			d, err := parseDestination(v)
+			opts.Destinations = append(opts.Destinations, d)
+			return err
+		})
+		fs.BoolVar(&opts.Sticky, "sticky", false, "keep visitors on the destination they were first sent to")
+		fs.StringVar(&opts.Password, "password", "", "ask visitors for this password before redirecting")
+		fs.Int64Var(&opts.MaxClicks, "max-clicks", 0, "stop redirecting after this many clicks")
+		fs.Func("not-before", "RFC3339 time the link starts redirecting", func(v string) error {
+			return parseTime(v, &opts.NotBefore)
+		})
+		fs.Func("not-after", "RFC3339 time the link stops redirecting", func(v string) error {
+			return parseTime(v, &opts.NotAfter)
+		})
+		fs.StringVar(&opts.PrelaunchURL, "prelaunch", "", "destination before -not-before")
+		fs.StringVar(&opts.Title, "title", "", "title shown when listing links")
+		fs.Func("tag", "tag to filter links by, repeatable", func(v string) error {
+			opts.Tags = append(opts.Tags, v)
+			return nil
+		})
+		fs.StringVar(&opts.Notes, "notes", "", "free-form notes")
+		fs.StringVar(&opts.Owner, "owner", "", "team or person to list the link under")
+		fs.BoolVar(&opts.Interstitial, "interstitial", false, "show a warning page before redirecting")
+		fs.Func("rule", "routing rule as '<key>=<value>,... <url>', repeatable", func(v string) error {
+			rule, err := parseRule(v)
+			opts.Rules = append(opts.Rules, rule)
+			return err
+		})
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return nil, fmt.Errorf("%w: shorten [-redirect code] [-query policy] <url>", errUsage)
+		}
+		opts.QueryPolicy = urlshortener.QueryPolicy(query)
+		original := fs.Arg(0)
+		shortened, err := svc.ShortenURL(ctx, original, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &result{
+			value: map[string]string{"short_url": shortened, "original_url": original},
+			rows:  [][2]string{{"short_url", shortened}, {"original_url", original}},
+		}, nil
+
+	case "resolve":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: resolve <code>", errUsage)
+		}
+		original, err := svc.GetOriginalURL(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &result{
+			value: map[string]string{"short_url": args[0], "original_url": original},
+			rows:  [][2]string{{"short_url", args[0]}, {"original_url", original}},
+		}, nil
+
+	case "inspect":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: inspect <code>", errUsage)
+		}
+		link, err := svc.GetLink(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return linkResult(link), nil
+
+	case "disable", "enable":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s <code>", errUsage, command)
+		}
+		if err := svc.SetDisabled(ctx, args[0], command == "disable"); err != nil {
+			return nil, err
+		}
+		link, err := svc.GetLink(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return linkResult(link), nil
+
+	case "delete":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: delete <code>", errUsage)
+		}
+		if err := svc.DeleteLink(ctx, args[0]); err != nil {
+			return nil, err
+		}
+		return &result{
+			value: map[string]string{"short_url": args[0], "status": "deleted"},
+			rows:  [][2]string{{"short_url", args[0]}, {"status", "deleted"}},
+		}, nil
+
+	case "update":
+		var update urlshortener.MetadataUpdate
+		fs := flag.NewFlagSet("update", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Func("title", "new title", func(v string) error {
+			update.Title = &v
+			return nil
+		})
+		fs.Func("tags", "comma separated tags replacing the current ones", func(v string) error {
+			tags := strings.Split(v, ",")
+			update.Tags = &tags
+			return nil
+		})
+		fs.Func("notes", "new notes", func(v string) error {
+			update.Notes = &v
+			return nil
+		})
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return nil, fmt.Errorf("%w: update [-title title] [-tags tag,...] [-notes notes] <code>", errUsage)
+		}
+		link, err := svc.UpdateMetadata(ctx, fs.Arg(0), update)
+		if err != nil {
+			return nil, err
+		}
+		return linkResult(link), nil
+
+	case "list":
+		var filter urlshortener.LinkFilter
+		fs := flag.NewFlagSet("list", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.StringVar(&filter.Owner, "owner", "", "only list codes of this owner")
+		fs.StringVar(&filter.Tag, "tag", "", "only list codes with this tag")
+		fs.StringVar(&filter.Domain, "domain", "", "only list codes redirecting to this host")
+		fs.Func("after", "RFC3339 time codes were created at or after", func(v string) error {
+			return parseTime(v, &filter.CreatedAfter)
+		})
+		fs.Func("before", "RFC3339 time codes were created before", func(v string) error {
+			return parseTime(v, &filter.CreatedBefore)
+		})
+		fs.IntVar(&filter.Limit, "limit", 0, "codes to read per page")
+		fs.StringVar(&filter.Cursor, "cursor", "", "next_cursor of the previous page")
+		fs.BoolVar(&filter.Ascending, "asc", false, "list the oldest codes first")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+			return nil, fmt.Errorf("%w: list [-owner owner] [-tag tag] [-domain host] [-after time] [-before time] [-limit n] [-cursor cursor] [-asc]", errUsage)
+		}
+		page, err := svc.ListLinks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{value: page}
+		now := time.Now()
+		for _, link := range page.Links {
+			res.rows = append(res.rows, [2]string{
+				link.ShortURL,
+				fmt.Sprintf("%s\t%s\t%s", link.StatusAt(now), link.OriginalURL, link.Title),
+			})
+		}
+		if page.NextCursor != "" {
+			res.rows = append(res.rows, [2]string{"next_cursor", page.NextCursor})
+		}
+		return res, nil
+
+	case "recheck":
+		var all bool
+		fs := flag.NewFlagSet("recheck", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.BoolVar(&all, "all", false, "recheck every code")
+		if err := fs.Parse(args); err != nil || all == (fs.NArg() > 0) {
+			return nil, fmt.Errorf("%w: recheck -all | recheck <code>...", errUsage)
+		}
+		codes := fs.Args()
+		if all {
+			var err error
+			if codes, err = listCodes(ctx, svc); err != nil {
+				return nil, err
+			}
+		}
+		links := make([]*urlshortener.Link, 0, len(codes))
+		res := &result{}
+		for _, code := range codes {
+			link, err := svc.RecheckLink(ctx, code)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", code, err)
+			}
+			links = append(links, link)
+			res.rows = append(res.rows, [2]string{link.ShortURL, formatFlag(link)})
+		}
+		res.value = links
+		return res, nil
+
+	case "check-links":
+		var timeout time.Duration
+		checker := &linkcheck.Checker{}
+		fs := flag.NewFlagSet("check-links", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.IntVar(&checker.Concurrency, "concurrency", linkcheck.DefaultConcurrency, "hosts checked at once")
+		fs.DurationVar(&checker.HostDelay, "host-delay", linkcheck.DefaultHostDelay, "pause between requests to the same host")
+		fs.DurationVar(&timeout, "timeout", linkcheck.DefaultTimeout, "timeout of a single request")
+		if err := fs.Parse(args); err != nil || fs.NArg() > 0 || checker.Concurrency <= 0 || checker.HostDelay <= 0 || timeout <= 0 {
+			return nil, fmt.Errorf("%w: check-links [-concurrency <n>] [-host-delay <duration>] [-timeout <duration>]", errUsage)
+		}
+		checker.Client = linkcheck.NewClient(timeout)
+		report, err := svc.CheckLinks(ctx, checker)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{value: report}
+		for _, link := range report.Broken {
+			res.rows = append(res.rows, [2]string{link.ShortURL, formatHealth(link)})
+		}
+		for _, link := range report.Flagged {
+			res.rows = append(res.rows, [2]string{link.ShortURL, formatFlag(link)})
+		}
+		res.rows = append(res.rows,
+			[2]string{"checked", strconv.Itoa(report.Checked)},
+			[2]string{"broken", strconv.Itoa(len(report.Broken))})
+		if len(report.Flagged) > 0 {
+			res.rows = append(res.rows, [2]string{"flagged", strconv.Itoa(len(report.Flagged))})
+		}
+		if report.Failed > 0 {
+			res.rows = append(res.rows, [2]string{"failed", strconv.Itoa(report.Failed)})
+		}
+		if report.More {
+			res.rows = append(res.rows, [2]string{"more", "run check-links again to continue"})
+		}
+		return res, nil
+
+	case "webhooks":
+		return webhooks(ctx, svc, args)
+
+	case "counter":
+		counter, err := svc.Counter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &result{
+			value: map[string]int64{"counter_value": counter},
+			rows:  [][2]string{{"counter_value", strconv.FormatInt(counter, 10)}},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+func webhooks(ctx context.Context, svc linkService, args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: webhooks add|list|remove|deliveries", errUsage)
+	}
+	switch command, args := args[0], args[1:]; command {
+	case "add":
+		var events []webhook.EventType
+		var secret string
+		fs := flag.NewFlagSet("webhooks add", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Func("event", "event type to send, repeatable", func(v string) error {
+			events = append(events, webhook.EventType(v))
+			return nil
+		})
+		fs.StringVar(&secret, "secret", "", "secret to sign deliveries with, generated when empty")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return nil, fmt.Errorf("%w: webhooks add [-event type]... [-secret secret] <url>", errUsage)
+		}
+		sub, err := webhook.NewSubscription(fs.Arg(0), events, secret)
+		if err != nil {
+			return nil, err
+		}
+		if err = svc.SaveSubscription(ctx, sub); err != nil {
+			return nil, err
+		}
+		// the secret is not shown again
+		return &result{
+			value: sub,
+			rows:  [][2]string{{"id", sub.ID}, {"url", sub.URL}, {"events", formatEvents(sub.Events)}, {"secret", sub.Secret}},
+		}, nil
+
+	case "list":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%w: webhooks list", errUsage)
+		}
+		subs, err := svc.Subscriptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{}
+		for i := range subs {
+			subs[i].Secret = ""
+			res.rows = append(res.rows, [2]string{subs[i].ID, fmt.Sprintf("%s\t%s", subs[i].URL, formatEvents(subs[i].Events))})
+		}
+		if subs == nil {
+			subs = []webhook.Subscription{}
+		}
+		res.value = subs
+		return res, nil
+
+	case "remove":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: webhooks remove <id>", errUsage)
+		}
+		if err := svc.DeleteSubscription(ctx, args[0]); err != nil {
+			return nil, err
+		}
+		return &result{
+			value: map[string]string{"id": args[0], "status": "removed"},
+			rows:  [][2]string{{"id", args[0]}, {"status", "removed"}},
+		}, nil
+
+	case "deliveries":
+		var limit int
+		fs := flag.NewFlagSet("webhooks deliveries", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.IntVar(&limit, "limit", 20, "attempts to print")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 || limit <= 0 {
+			return nil, fmt.Errorf("%w: webhooks deliveries [-limit n] <id>", errUsage)
+		}
+		deliveries, err := svc.Deliveries(ctx, fs.Arg(0), limit)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{}
+		for _, d := range deliveries {
+			res.rows = append(res.rows, [2]string{d.CreatedAt.Format(time.RFC3339), formatDelivery(d)})
+		}
+		if deliveries == nil {
+			deliveries = []webhook.Delivery{}
+		}
+		res.value = deliveries
+		return res, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown webhooks command %q", errUsage, command)
+	}
+}
+
+func linkResult(link *urlshortener.Link) *result {
+	res := &result{
+		value: link,
+		rows: [][2]string{
+			{"short_url", link.ShortURL},
+			{"id", strconv.FormatInt(link.ID, 10)},
+			{"original_url", link.OriginalURL},
+			{"status", string(link.StatusAt(time.Now()))},
+			{"disabled", strconv.FormatBool(link.Disabled)},
+			{"redirect_type", strconv.Itoa(link.RedirectStatus())},
+			{"query_policy", string(queryPolicy(link.QueryPolicy))},
+			{"prefix", strconv.FormatBool(link.Prefix)},
+			{"password_protected", strconv.FormatBool(link.Protected())},
+		},
+	}
+	if link.Flag != nil {
+		res.rows = append(res.rows, [2]string{"flag", formatFlag(link)})
+	}
+	if link.Health != nil {
+		res.rows = append(res.rows, [2]string{"health", formatHealth(link)})
+	}
+	if link.Interstitial {
+		res.rows = append(res.rows, [2]string{"interstitial", "true"})
+	}
+	if link.Owner != "" {
+		res.rows = append(res.rows, [2]string{"owner", link.Owner})
+	}
+	if link.CreatedAt != nil {
+		res.rows = append(res.rows, [2]string{"created_at", link.CreatedAt.Format(time.RFC3339)})
+	}
+	if link.MaxClicks > 0 {
+		res.rows = append(res.rows,
+			[2]string{"max_clicks", strconv.FormatInt(link.MaxClicks, 10)},
+			[2]string{"clicks_remaining", strconv.FormatInt(link.ClicksRemaining, 10)})
+	}
+	if link.Title != "" {
+		res.rows = append(res.rows, [2]string{"title", link.Title})
+	}
+	if len(link.Tags) > 0 {
+		res.rows = append(res.rows, [2]string{"tags", strings.Join(link.Tags, ",")})
+	}
+	if link.Notes != "" {
+		res.rows = append(res.rows, [2]string{"notes", link.Notes})
+	}
+	if link.NotBefore != nil {
+		res.rows = append(res.rows, [2]string{"not_before", link.NotBefore.Format(time.RFC3339)})
+	}
+	if link.NotAfter != nil {
+		res.rows = append(res.rows, [2]string{"not_after", link.NotAfter.Format(time.RFC3339)})
+	}
+	if link.PrelaunchURL != "" {
+		res.rows = append(res.rows, [2]string{"prelaunch_url", link.PrelaunchURL})
+	}
+	for i, d := range link.Destinations {
+		res.rows = append(res.rows, [2]string{
+			fmt.Sprintf("variant_%d", i),
+			fmt.Sprintf("%s weight=%d clicks=%d", d.URL, d.Weight, link.VariantClicks[strconv.Itoa(i)]),
+		})
+	}
+	if len(link.Destinations) > 0 {
+		res.rows = append(res.rows, [2]string{"sticky", strconv.FormatBool(link.Sticky)})
+	}
+	for i, rule := range link.Rules {
+		res.rows = append(res.rows, [2]string{fmt.Sprintf("rule_%d", i), formatRule(rule)})
+	}
+	return res
+}
+
+// listCodes pages through every listed code.
+func listCodes(ctx context.Context, svc linkService) ([]string, error) {
+	var codes []string
+	filter := urlshortener.LinkFilter{Limit: urlshortener.MaxPageSize}
+	for {
+		page, err := svc.ListLinks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range page.Links {
+			codes = append(codes, link.ShortURL)
+		}
+		if page.NextCursor == "" {
+			return codes, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func formatFlag(link *urlshortener.Link) string {
+	if link.Flag == nil {
+		return "clean"
+	}
+	flag := fmt.Sprintf("%s %s", link.Flag.Verdict, link.Flag.URL)
+	if link.Flag.Threat != "" {
+		flag += " threat=" + link.Flag.Threat
+	}
+	return flag
+}
+
+func formatHealth(link *urlshortener.Link) string {
+	state := "ok"
+	if link.Health.Broken {
+		state = "broken"
+	}
+	health := fmt.Sprintf("%s %s", state, link.Health.URL)
+	if link.Health.StatusCode != 0 {
+		health += " status=" + strconv.Itoa(link.Health.StatusCode)
+	}
+	if link.Health.Error != "" {
+		health += " error=" + link.Health.Error
+	}
+	return health + " checked_at=" + link.Health.CheckedAt.Format(time.RFC3339)
+}
+
+func formatEvents(events []webhook.EventType) string {
+	if len(events) == 0 {
+		return "all"
+	}
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
+
+func formatDelivery(d webhook.Delivery) string {
+	state := "failed"
+	if d.Succeeded {
+		state = "ok"
+	}
+	delivery := fmt.Sprintf("%s\t%s attempt=%d", state, d.EventType, d.Attempt)
+	if d.StatusCode != 0 {
+		delivery += " status=" + strconv.Itoa(d.StatusCode)
+	}
+	if d.Error != "" {
+		delivery += " error=" + d.Error
+	}
+	return delivery
+}
+
+func parseDestination(v string) (urlshortener.Destination, error) {
+	i := strings.LastIndexByte(v, '=')
+	if i < 0 {
+		return urlshortener.Destination{}, fmt.Errorf("destination %q must be <url>=<weight>", v)
+	}
+	weight, err := strconv.Atoi(v[i+1:])
+	if err != nil {
+		return urlshortener.Destination{}, fmt.Errorf("destination %q has an invalid weight", v)
+	}
+	return urlshortener.Destination{URL: v[:i], Weight: weight}, nil
+}
+
+// parseRule reads conditions such as device=ios,country=US followed by a space and the URL.
+func parseRule(v string) (urlshortener.Rule, error) {
+	fields := strings.Fields(v)
+	if len(fields) != 2 {
+		return urlshortener.Rule{}, fmt.Errorf("rule %q must be '<key>=<value>,... <url>'", v)
+	}
+	rule := urlshortener.Rule{URL: fields[1]}
+	for _, cond := range strings.Split(fields[0], ",") {
+		key, value, ok := strings.Cut(cond, "=")
+		if !ok {
+			return urlshortener.Rule{}, fmt.Errorf("rule condition %q must be <key>=<value>", cond)
+		}
+		switch key {
+		case "device":
+			rule.Device = urlshortener.Device(value)
+		case "language":
+			rule.Language = value
+		case "country":
+			rule.Country = value
+		default:
+			rule.Header, rule.Value = key, value
+		}
+	}
+	return rule, nil
+}
+
+func formatRule(rule urlshortener.Rule) string {
+	var conds []string
+	if rule.Device != "" {
+		conds = append(conds, "device="+string(rule.Device))
+	}
+	if rule.Language != "" {
+		conds = append(conds, "language="+rule.Language)
+	}
+	if rule.Country != "" {
+		conds = append(conds, "country="+rule.Country)
+	}
+	if rule.Header != "" {
+		conds = append(conds, rule.Header+"="+rule.Value)
+	}
+	return strings.Join(conds, ",") + " " + rule.URL
+}
+
+func parseTime(v string, dst **time.Time) error {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return fmt.Errorf("time %q must be RFC3339, e.g. 2025-03-01T09:00:00Z", v)
+	}
+	*dst = &t
+	return nil
+}
+
+func queryPolicy(p urlshortener.QueryPolicy) urlshortener.QueryPolicy {
+	if p == "" {
+		return urlshortener.QueryDrop
+	}
+	return p
+}
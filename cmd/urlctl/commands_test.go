@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/linkcheck"
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLinkService struct {
+	mock.Mock
+}
+
+func (m *MockLinkService) ShortenURL(_ context.Context, url string, opts urlshortener.LinkOptions) (string, error) {
+	args := m.Called(url, opts)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLinkService) GetOriginalURL(_ context.Context, shortened string) (string, error) {
+	args := m.Called(shortened)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLinkService) GetLink(_ context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockLinkService) SetDisabled(_ context.Context, shortened string, disabled bool) error {
+	args := m.Called(shortened, disabled)
+	return args.Error(0)
+}
+
+func (m *MockLinkService) DeleteLink(_ context.Context, shortened string) error {
+	args := m.Called(shortened)
+	return args.Error(0)
+}
+
+func (m *MockLinkService) Counter(_ context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLinkService) UpdateMetadata(_ context.Context, shortened string, update urlshortener.MetadataUpdate) (*urlshortener.Link, error) {
+	args := m.Called(shortened, update)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockLinkService) ListLinks(_ context.Context, filter urlshortener.LinkFilter) (*urlshortener.LinkPage, error) {
+	args := m.Called(filter)
+	page, _ := args.Get(0).(*urlshortener.LinkPage)
+	return page, args.Error(1)
+}
+
+func (m *MockLinkService) RecheckLink(_ context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockLinkService) CheckLinks(_ context.Context, checker urlshortener.LinkChecker) (*urlshortener.LinkCheckReport, error) {
+	args := m.Called(checker)
+	report, _ := args.Get(0).(*urlshortener.LinkCheckReport)
+	return report, args.Error(1)
+}
+
+func (m *MockLinkService) SaveSubscription(_ context.Context, sub webhook.Subscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockLinkService) Subscriptions(_ context.Context) ([]webhook.Subscription, error) {
+	args := m.Called()
+	subs, _ := args.Get(0).([]webhook.Subscription)
+	return subs, args.Error(1)
+}
+
+func (m *MockLinkService) DeleteSubscription(_ context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockLinkService) Deliveries(_ context.Context, webhookID string, limit int) ([]webhook.Delivery, error) {
+	args := m.Called(webhookID, limit)
+	deliveries, _ := args.Get(0).([]webhook.Delivery)
+	return deliveries, args.Error(1)
+}
+
+func Test_Run(t *testing.T) {
+	link := &urlshortener.Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", Disabled: true}
+
+	tests := map[string]struct {
+		args        []string
+		setup       func(m *MockLinkService)
+		expectedOut string
+		expectError error
+	}{
+		"shorten table": {
+			args: []string{"shorten", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{}).Return("b", nil)
+			},
+			expectedOut: "short_url     b\noriginal_url  http://www.example.com\n",
+		},
+		"shorten with redirect type": {
+			args: []string{"-o", "json", "shorten", "-redirect", "301", "-query", "append", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com",
+					urlshortener.LinkOptions{RedirectType: 301, QueryPolicy: urlshortener.QueryAppend}).Return("c", nil)
+			},
+			expectedOut: `{"original_url":"http://www.example.com","short_url":"c"}`,
+		},
+		"shorten with destinations": {
+			args: []string{"-o", "json", "shorten", "-dest", "http://a.com/?x=1=1", "-dest", "http://b.com=3", "-sticky", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{
+					Destinations: []urlshortener.Destination{{URL: "http://a.com/?x=1", Weight: 1}, {URL: "http://b.com", Weight: 3}},
+					Sticky:       true,
+				}).Return("d", nil)
+			},
+			expectedOut: `{"original_url":"http://www.example.com","short_url":"d"}`,
+		},
+		"shorten with invalid destination": {
+			args:        []string{"shorten", "-dest", "http://a.com", "http://www.example.com"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"shorten with rules": {
+			args: []string{"-o", "json", "shorten", "-rule", "device=ios https://apps.apple.com/app",
+				"-rule", "language=fr,X-Partner=acme https://example.fr", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{
+					Rules: []urlshortener.Rule{
+						{Device: urlshortener.DeviceIOS, URL: "https://apps.apple.com/app"},
+						{Language: "fr", Header: "X-Partner", Value: "acme", URL: "https://example.fr"},
+					},
+				}).Return("e", nil)
+			},
+			expectedOut: `{"original_url":"http://www.example.com","short_url":"e"}`,
+		},
+		"shorten with password": {
+			args: []string{"shorten", "-password", "hunter2", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{Password: "hunter2"}).Return("f", nil)
+			},
+			expectedOut: "short_url     f\noriginal_url  http://www.example.com\n",
+		},
+		"shorten one-time link": {
+			args: []string{"shorten", "-max-clicks", "1", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{MaxClicks: 1}).Return("g", nil)
+			},
+			expectedOut: "short_url     g\noriginal_url  http://www.example.com\n",
+		},
+		"inspect click budget": {
+			args: []string{"inspect", "g"},
+			setup: func(m *MockLinkService) {
+				m.On("GetLink", "g").Return(&urlshortener.Link{
+					ShortURL: "g", ID: 5, OriginalURL: "http://www.example.com", ClicksRemaining: 2,
+					LinkOptions: urlshortener.LinkOptions{MaxClicks: 3},
+				}, nil)
+			},
+			expectedOut: "short_url           g\nid                  5\noriginal_url        http://www.example.com\nstatus              active\ndisabled            false\nredirect_type       302\nquery_policy        drop\nprefix              false\npassword_protected  false\nmax_clicks          3\nclicks_remaining    2\n",
+		},
+		"shorten scheduled link": {
+			args: []string{"shorten", "-not-before", "2025-03-01T09:00:00Z", "-prelaunch", "http://www.example.com/soon", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				launch := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{
+					NotBefore:    &launch,
+					PrelaunchURL: "http://www.example.com/soon",
+				}).Return("h", nil)
+			},
+			expectedOut: "short_url     h\noriginal_url  http://www.example.com\n",
+		},
+		"shorten with invalid time": {
+			args:        []string{"shorten", "-not-after", "tomorrow", "http://www.example.com"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"shorten with metadata": {
+			args: []string{"shorten", "-title", "Launch", "-tag", "q3", "-tag", "blog", "-notes", "for the post", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{
+					Metadata: urlshortener.Metadata{Title: "Launch", Tags: []string{"q3", "blog"}, Notes: "for the post"},
+				}).Return("i", nil)
+			},
+			expectedOut: "short_url     i\noriginal_url  http://www.example.com\n",
+		},
+		"update": {
+			args: []string{"update", "-tags", "q3,blog", "-notes", "", "b"},
+			setup: func(m *MockLinkService) {
+				tags, notes := []string{"q3", "blog"}, ""
+				m.On("UpdateMetadata", "b", urlshortener.MetadataUpdate{Tags: &tags, Notes: &notes}).Return(&urlshortener.Link{
+					ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com",
+					LinkOptions: urlshortener.LinkOptions{Metadata: urlshortener.Metadata{Title: "Launch", Tags: []string{"blog", "q3"}}},
+				}, nil)
+			},
+			expectedOut: "short_url           b\nid                  1\noriginal_url        http://www.example.com\nstatus              active\ndisabled            false\nredirect_type       302\nquery_policy        drop\nprefix              false\npassword_protected  false\ntitle               Launch\ntags                blog,q3\n",
+		},
+		"update missing code": {
+			args:        []string{"update", "-title", "Launch"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"list by tag": {
+			args: []string{"list", "-tag", "q3"},
+			setup: func(m *MockLinkService) {
+				m.On("ListLinks", urlshortener.LinkFilter{Tag: "q3"}).Return(&urlshortener.LinkPage{Links: []*urlshortener.Link{
+					{ShortURL: "b", OriginalURL: "http://www.example.com", LinkOptions: urlshortener.LinkOptions{Metadata: urlshortener.Metadata{Title: "Launch"}}},
+					{ShortURL: "c", OriginalURL: "http://c.example.com", Disabled: true},
+				}}, nil)
+			},
+			expectedOut: "b  active    http://www.example.com  Launch\nc  disabled  http://c.example.com    \n",
+		},
+		"list page": {
+			args: []string{"list", "-owner", "growth", "-after", "2025-01-01T00:00:00Z", "-limit", "1", "-cursor", "abc", "-asc"},
+			setup: func(m *MockLinkService) {
+				after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				m.On("ListLinks", urlshortener.LinkFilter{Owner: "growth", CreatedAfter: &after, Limit: 1, Cursor: "abc", Ascending: true}).Return(&urlshortener.LinkPage{
+					Links:      []*urlshortener.Link{{ShortURL: "b", OriginalURL: "http://www.example.com"}},
+					NextCursor: "def",
+				}, nil)
+			},
+			expectedOut: "b            active  http://www.example.com  \nnext_cursor  def\n",
+		},
+		"shorten with interstitial": {
+			args: []string{"shorten", "-interstitial", "-owner", "growth", "http://www.example.com"},
+			setup: func(m *MockLinkService) {
+				m.On("ShortenURL", "http://www.example.com", urlshortener.LinkOptions{Interstitial: true, Owner: "growth"}).Return("b", nil)
+			},
+			expectedOut: "short_url     b\noriginal_url  http://www.example.com\n",
+		},
+		"recheck codes": {
+			args: []string{"recheck", "b", "c"},
+			setup: func(m *MockLinkService) {
+				m.On("RecheckLink", "b").Return(&urlshortener.Link{ShortURL: "b"}, nil)
+				m.On("RecheckLink", "c").Return(&urlshortener.Link{ShortURL: "c", Flag: &urlshortener.Flag{
+					Match: reputation.Match{URL: "http://c.example.com", Verdict: reputation.Malicious, Threat: "MALWARE"},
+				}}, nil)
+			},
+			expectedOut: "b  clean\nc  malicious http://c.example.com threat=MALWARE\n",
+		},
+		"recheck all": {
+			args: []string{"recheck", "-all"},
+			setup: func(m *MockLinkService) {
+				m.On("ListLinks", urlshortener.LinkFilter{Limit: urlshortener.MaxPageSize}).Return(&urlshortener.LinkPage{
+					Links:      []*urlshortener.Link{{ShortURL: "b"}},
+					NextCursor: "next",
+				}, nil)
+				m.On("ListLinks", urlshortener.LinkFilter{Limit: urlshortener.MaxPageSize, Cursor: "next"}).Return(&urlshortener.LinkPage{
+					Links: []*urlshortener.Link{{ShortURL: "c"}},
+				}, nil)
+				m.On("RecheckLink", "b").Return(&urlshortener.Link{ShortURL: "b"}, nil)
+				m.On("RecheckLink", "c").Return(&urlshortener.Link{ShortURL: "c"}, nil)
+			},
+			expectedOut: "b  clean\nc  clean\n",
+		},
+		"recheck error": {
+			args: []string{"recheck", "b"},
+			setup: func(m *MockLinkService) {
+				m.On("RecheckLink", "b").Return(nil, urlshortener.ErrReputationUnavailable)
+			},
+			expectError: urlshortener.ErrReputationUnavailable,
+		},
+		"recheck codes and all": {
+			args:        []string{"recheck", "-all", "b"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"check-links": {
+			args: []string{"check-links", "-concurrency", "2", "-host-delay", "5s"},
+			setup: func(m *MockLinkService) {
+				m.On("CheckLinks", mock.MatchedBy(func(c *linkcheck.Checker) bool {
+					return c.Concurrency == 2 && c.HostDelay == 5*time.Second && c.Client != nil
+				})).Return(&urlshortener.LinkCheckReport{
+					Checked: 3,
+					Broken: []*urlshortener.Link{{ShortURL: "c", Health: &urlshortener.LinkHealth{
+						Broken:     true,
+						URL:        "http://c.example.com",
+						StatusCode: 404,
+						CheckedAt:  time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+					}}},
+				}, nil)
+			},
+			expectedOut: "c        broken http://c.example.com status=404 checked_at=2025-03-01T09:00:00Z\nchecked  3\nbroken   1\n",
+		},
+		"check-links invalid concurrency": {
+			args:        []string{"check-links", "-concurrency", "0"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"shorten with invalid rule": {
+			args:        []string{"shorten", "-rule", "https://example.fr", "http://www.example.com"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"resolve json": {
+			args: []string{"-o", "json", "resolve", "b"},
+			setup: func(m *MockLinkService) {
+				m.On("GetOriginalURL", "b").Return("http://www.example.com", nil)
+			},
+			expectedOut: `{"original_url":"http://www.example.com","short_url":"b"}`,
+		},
+		"inspect json": {
+			args: []string{"-o", "json", "inspect", "b"},
+			setup: func(m *MockLinkService) {
+				m.On("GetLink", "b").Return(link, nil)
+			},
+			expectedOut: `{"short_url":"b","id":1,"original_url":"http://www.example.com","disabled":true}`,
+		},
+		"disable": {
+			args: []string{"disable", "b"},
+			setup: func(m *MockLinkService) {
+				m.On("SetDisabled", "b", true).Return(nil)
+				m.On("GetLink", "b").Return(link, nil)
+			},
+			expectedOut: "short_url           b\nid                  1\noriginal_url        http://www.example.com\nstatus              disabled\ndisabled            true\nredirect_type       302\nquery_policy        drop\nprefix              false\npassword_protected  false\n",
+		},
+		"webhooks add unknown event": {
+			args:        []string{"webhooks", "add", "-event", "link.viewed", "https://hooks.example.com/links"},
+			setup:       func(m *MockLinkService) {},
+			expectError: webhook.ErrInvalidSubscription,
+		},
+		"webhooks list": {
+			args: []string{"-o", "json", "webhooks", "list"},
+			setup: func(m *MockLinkService) {
+				m.On("Subscriptions").Return([]webhook.Subscription{{
+					ID: "a1", URL: "https://hooks.example.com/links", Secret: "s3cret", CreatedAt: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+				}}, nil)
+			},
+			expectedOut: `[{"id":"a1","url":"https://hooks.example.com/links","created_at":"2025-03-01T09:00:00Z"}]`,
+		},
+		"webhooks list table": {
+			args: []string{"webhooks", "list"},
+			setup: func(m *MockLinkService) {
+				m.On("Subscriptions").Return([]webhook.Subscription{
+					{ID: "a1", URL: "https://hooks.example.com/links", Secret: "s3cret"},
+					{ID: "b2", URL: "https://b.example.com", Events: []webhook.EventType{webhook.LinkDeleted}},
+				}, nil)
+			},
+			expectedOut: "a1  https://hooks.example.com/links  all\nb2  https://b.example.com            link.deleted\n",
+		},
+		"webhooks remove not found": {
+			args: []string{"webhooks", "remove", "a1"},
+			setup: func(m *MockLinkService) {
+				m.On("DeleteSubscription", "a1").Return(webhook.ErrSubscriptionNotFound)
+			},
+			expectError: webhook.ErrSubscriptionNotFound,
+		},
+		"webhooks deliveries": {
+			args: []string{"webhooks", "deliveries", "-limit", "2", "a1"},
+			setup: func(m *MockLinkService) {
+				m.On("Deliveries", "a1", 2).Return([]webhook.Delivery{
+					{EventType: webhook.LinkCreated, Attempt: 2, Succeeded: true, StatusCode: 204, CreatedAt: time.Date(2025, 3, 1, 9, 0, 1, 0, time.UTC)},
+					{EventType: webhook.LinkCreated, Attempt: 1, StatusCode: 503, CreatedAt: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)},
+				}, nil)
+			},
+			expectedOut: "2025-03-01T09:00:01Z  ok      link.created attempt=2 status=204\n2025-03-01T09:00:00Z  failed  link.created attempt=1 status=503\n",
+		},
+		"webhooks unknown command": {
+			args:        []string{"webhooks", "pause", "a1"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"counter": {
+			args: []string{"counter"},
+			setup: func(m *MockLinkService) {
+				m.On("Counter").Return(int64(42), nil)
+			},
+			expectedOut: "counter_value  42\n",
+		},
+		"inspect not found": {
+			args: []string{"inspect", "zz"},
+			setup: func(m *MockLinkService) {
+				m.On("GetLink", "zz").Return(nil, urlshortener.ErrLinkNotFound)
+			},
+			expectError: urlshortener.ErrLinkNotFound,
+		},
+		"missing argument": {
+			args:        []string{"resolve"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"delete": {
+			args: []string{"delete", "b"},
+			setup: func(m *MockLinkService) {
+				m.On("DeleteLink", "b").Return(nil)
+			},
+			expectedOut: "short_url  b\nstatus     deleted\n",
+		},
+		"delete not found": {
+			args: []string{"delete", "zz"},
+			setup: func(m *MockLinkService) {
+				m.On("DeleteLink", "zz").Return(urlshortener.ErrLinkNotFound)
+			},
+			expectError: urlshortener.ErrLinkNotFound,
+		},
+		"delete without code": {
+			args:        []string{"delete"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+		"unknown command": {
+			args:        []string{"purge", "b"},
+			setup:       func(m *MockLinkService) {},
+			expectError: errUsage,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockLinkService)
+			tc.setup(m)
+
+			opts, args, err := parseFlags(tc.args, io.Discard)
+			assert.NoError(t, err)
+
+			var out bytes.Buffer
+			err = run(context.Background(), m, opts, args, &out)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			if opts.output == OutputJSON {
+				assert.True(t, json.Valid(out.Bytes()))
+				assert.JSONEq(t, tc.expectedOut, out.String())
+			} else {
+				assert.Equal(t, tc.expectedOut, out.String())
+			}
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ParseFlags_InvalidOutput(t *testing.T) {
+	_, _, err := parseFlags([]string{"-o", "yaml", "counter"}, io.Discard)
+	assert.True(t, errors.Is(err, errUsage))
+}
+
+func Test_Run_WebhooksAdd(t *testing.T) {
+	m := new(MockLinkService)
+	var saved webhook.Subscription
+	m.On("SaveSubscription", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(webhook.Subscription)
+	}).Return(nil)
+
+	var out bytes.Buffer
+	err := run(context.Background(), m, options{output: OutputTable},
+		[]string{"webhooks", "add", "-event", "link.created", "-event", "link.clicked", "https://hooks.example.com/links"}, &out)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, saved.ID)
+	assert.Equal(t, "https://hooks.example.com/links", saved.URL)
+	assert.Equal(t, []webhook.EventType{webhook.LinkClicked, webhook.LinkCreated}, saved.Events)
+	assert.NotEmpty(t, saved.Secret)
+	assert.Contains(t, out.String(), "events  link.clicked,link.created\n")
+	assert.Contains(t, out.String(), "secret  "+saved.Secret+"\n")
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+// Command urlctl shortens, resolves and administers links directly against the url-mapping table,
+// using the same DynamoDB configuration as the Lambda.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
+	"go.uber.org/zap"
+)
+
+// flushTimeout bounds how long urlctl waits for webhook deliveries before exiting.
+const flushTimeout = 30 * time.Second
+
+// service is the linkService urlctl runs against.
+type service struct {
+	*urlshortener.UrlShortener
+	*webhook.Store
+}
+
+func main() {
+	opts, args, err := parseFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	logger := zap.NewNop()
+	if opts.verbose {
+		logger, err = zap.NewProduction()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	u, err := urlshortener.New(logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize db client: %v\n", err)
+		os.Exit(1)
+	}
+	store := &webhook.Store{DB: u.DBClient.(*persistence.UrlDB)}
+	dispatcher := &webhook.Dispatcher{Store: store, Logger: logger}
+	u.Events = dispatcher
+
+	err = run(context.Background(), &service{UrlShortener: u, Store: store}, opts, args, os.Stdout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	if err := dispatcher.Flush(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "urlctl: webhook deliveries still pending: %v\n", err)
+	}
+	cancel()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "urlctl: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,8 +1,34 @@
 package logkey
 
 const (
-	Error        = "error"
-	ShortenedURL = "shortenedURL"
-	OriginalURL  = "originalURL"
-	ID           = "id"
+	Error           = "error"
+	ShortenedURL    = "shortenedURL"
+	OriginalURL     = "originalURL"
+	ID              = "id"
+	RequestID       = "requestID"
+	Method          = "method"
+	Path            = "path"
+	Status          = "status"
+	Bytes           = "bytes"
+	Duration        = "duration"
+	RemoteAddr      = "remoteAddr"
+	UserAgent       = "userAgent"
+	Dependency      = "dependency"
+	Disabled        = "disabled"
+	Variant         = "variant"
+	ClicksRemaining = "clicksRemaining"
+	WebhookID       = "webhookID"
+	EventID         = "eventID"
+	EventType       = "eventType"
+	Attempt         = "attempt"
+	Clicks          = "clicks"
+	Dropped         = "dropped"
+	Verdict         = "verdict"
+	Threat          = "threat"
+	StatusCode      = "statusCode"
+	Checked         = "checked"
+	Broken          = "broken"
+	Flagged         = "flagged"
+	Failed          = "failed"
+	Bot             = "bot"
 )
@@ -4,10 +4,26 @@
 FUNCTION_NAME="urlShortenerLambda"
 ROLE_NAME="urlShortenerRole"
 TABLE_NAME="url-mapping"
+CLICK_TABLE_NAME="url-clicks"
+AGGREGATE_TABLE_NAME="url-aggregates"
 S3_BUCKET="url-shortener-source"
 ZIP_FILE="function.zip"
 API_NAME="urlShortenerAPI"
 REGION="us-east-1"
+# Comma separated domains links may not redirect to, and custom domains the API is served on
+DENIED_DOMAINS=""
+SHORT_DOMAINS=""
+# Where clicks are sent: dynamodb, stdout, sqs:<queue URL> or kinesis:<stream name>, empty to not
+# collect them. Only the dynamodb sink feeds the analytics rollups.
+CLICK_SINK="dynamodb"
+CLICK_IP_SALT=$(openssl rand -hex 32)
+# Bearer token needed to change links through the API
+ADMIN_TOKEN=$(openssl rand -hex 32)
+# Crawler list shipped in the function package, clicks from matching user agents are tagged as bots
+CRAWLER_LIST_FILE="/var/task/crawlers.txt"
+# How often the function checks every link for broken destinations
+LINK_CHECK_RULE="urlShortenerLinkCheck"
+LINK_CHECK_SCHEDULE="rate(1 day)"
 
 # Package Lambda function
 echo "Packaging Lambda function..."
@@ -30,11 +46,47 @@ aws s3 cp $ZIP_FILE s3://$S3_BUCKET/
 echo "Creating DynamoDB table..."
 aws dynamodb create-table \
     --table-name $TABLE_NAME \
-    --attribute-definitions AttributeName=short_url,AttributeType=S \
+    --attribute-definitions \
+        AttributeName=short_url,AttributeType=S \
+        AttributeName=entity,AttributeType=S \
+        AttributeName=owner,AttributeType=S \
+        AttributeName=created_at,AttributeType=S \
     --key-schema AttributeName=short_url,KeyType=HASH \
+    --global-secondary-indexes \
+        "IndexName=links-by-created,KeySchema=[{AttributeName=entity,KeyType=HASH},{AttributeName=created_at,KeyType=RANGE}],Projection={ProjectionType=ALL}" \
+        "IndexName=links-by-owner,KeySchema=[{AttributeName=owner,KeyType=HASH},{AttributeName=created_at,KeyType=RANGE}],Projection={ProjectionType=ALL}" \
     --billing-mode PAY_PER_REQUEST \
+    --stream-specification StreamEnabled=true,StreamViewType=NEW_IMAGE \
     --region $REGION
 
+# Create the click and analytics tables. Clicks stream into the rollups in the aggregate table.
+echo "Creating analytics tables..."
+aws dynamodb create-table \
+    --table-name $CLICK_TABLE_NAME \
+    --attribute-definitions AttributeName=click_id,AttributeType=S \
+    --key-schema AttributeName=click_id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --stream-specification StreamEnabled=true,StreamViewType=NEW_IMAGE \
+    --region $REGION
+
+aws dynamodb create-table \
+    --table-name $AGGREGATE_TABLE_NAME \
+    --attribute-definitions \
+        AttributeName=metric,AttributeType=S \
+        AttributeName=bucket,AttributeType=S \
+    --key-schema AttributeName=metric,KeyType=HASH AttributeName=bucket,KeyType=RANGE \
+    --billing-mode PAY_PER_REQUEST \
+    --region $REGION
+
+# Expire the webhook delivery log, raw clicks and the record of processed stream events
+for TABLE in $TABLE_NAME $CLICK_TABLE_NAME $AGGREGATE_TABLE_NAME; do
+  aws dynamodb wait table-exists --table-name $TABLE --region $REGION
+  aws dynamodb update-time-to-live \
+      --table-name $TABLE \
+      --time-to-live-specification Enabled=true,AttributeName=expires_at \
+      --region $REGION
+done
+
 # Create IAM Role for Lambda
 echo "Creating IAM Role..."
 ROLE_POLICY_DOCUMENT='{
@@ -180,6 +232,88 @@ GET_INTEGRATION_ID=$(aws apigateway put-integration \
 
 echo "Created Lambda Integration with ID: $GET_INTEGRATION_ID"
 
+FUNCTION_ARN=$(aws lambda get-function --function-name $FUNCTION_NAME --query "Configuration.FunctionArn" --output text)
+
+# create_resource PARENT_ID PATH_PART prints the ID of a new child resource
+create_resource() {
+  aws apigateway create-resource \
+      --rest-api-id $API_ID \
+      --parent-id $1 \
+      --path-part "$2" \
+      --region $REGION \
+      --query "id" --output text
+}
+
+# add_lambda_method RESOURCE_ID HTTP_METHOD routes the method to the function through a proxy
+# integration, which is always invoked with POST
+add_lambda_method() {
+  aws apigateway put-method \
+      --rest-api-id $API_ID \
+      --resource-id $1 \
+      --http-method $2 \
+      --authorization-type NONE \
+      --region $REGION
+  aws apigateway put-method-response \
+      --rest-api-id $API_ID \
+      --resource-id $1 \
+      --http-method $2 \
+      --status-code 200 \
+      --region $REGION
+  aws apigateway put-integration \
+      --rest-api-id $API_ID \
+      --resource-id $1 \
+      --http-method $2 \
+      --integration-http-method POST \
+      --type AWS_PROXY \
+      --uri arn:aws:apigateway:$REGION:lambda:path/2015-03-31/functions/$FUNCTION_ARN/invocations \
+      --region $REGION
+}
+
+# Forward paths below prefix links, GET /{shortUrl}/{proxy+}
+echo "Creating GET /{shortUrl}/{proxy+} route..."
+PROXY_RESOURCE_ID=$(create_resource $GET_RESOURCE_ID "{proxy+}")
+add_lambda_method $PROXY_RESOURCE_ID GET
+
+# Health probes, GET /health, /health/live and /health/ready
+echo "Creating GET /health routes..."
+HEALTH_RESOURCE_ID=$(create_resource $ROOT_RESOURCE_ID "health")
+add_lambda_method $HEALTH_RESOURCE_ID GET
+add_lambda_method $(create_resource $HEALTH_RESOURCE_ID "live") GET
+add_lambda_method $(create_resource $HEALTH_RESOURCE_ID "ready") GET
+
+# API description, GET /openapi.json
+echo "Creating GET /openapi.json route..."
+add_lambda_method $(create_resource $ROOT_RESOURCE_ID "openapi.json") GET
+
+# Variant split of a link, GET /links/{shortUrl}/variants
+echo "Creating GET /links/{shortUrl}/variants route..."
+LINKS_RESOURCE_ID=$(create_resource $ROOT_RESOURCE_ID "links")
+LINK_RESOURCE_ID=$(create_resource $LINKS_RESOURCE_ID "{shortUrl}")
+add_lambda_method $(create_resource $LINK_RESOURCE_ID "variants") GET
+
+# Password form submissions, POST /{shortUrl} and /{shortUrl}/{proxy+}
+echo "Creating POST /{shortUrl} routes..."
+add_lambda_method $GET_RESOURCE_ID POST
+add_lambda_method $PROXY_RESOURCE_ID POST
+
+# Link details and metadata updates, GET and PATCH /links/{shortUrl}
+echo "Creating GET and PATCH /links/{shortUrl} routes..."
+add_lambda_method $LINK_RESOURCE_ID GET
+add_lambda_method $LINK_RESOURCE_ID PATCH
+
+# Link listing, GET /links
+echo "Creating GET /links route..."
+add_lambda_method $LINKS_RESOURCE_ID GET
+
+# Click analytics of a link, GET /{shortUrl}/analytics
+echo "Creating GET /{shortUrl}/analytics route..."
+add_lambda_method $(create_resource $GET_RESOURCE_ID "analytics") GET
+
+# Link checkers and unfurlers, HEAD /{shortUrl} and /{shortUrl}/{proxy+}
+echo "Creating HEAD /{shortUrl} routes..."
+add_lambda_method $GET_RESOURCE_ID HEAD
+add_lambda_method $PROXY_RESOURCE_ID HEAD
+
 # Add permission for API Gateway to invoke Lambda
 echo "Granting API Gateway permission to invoke Lambda..."
 API_GATEWAY_ARN="arn:aws:execute-api:$REGION:$(aws sts get-caller-identity --query "Account" --output text):$API_ID/*/*/*"
@@ -205,8 +339,58 @@ aws apigateway create-deployment \
 echo "Deploying API Gateway..."
 aws apigateway create-deployment --rest-api-id $API_ID --region $REGION --stage-name prod
 
+# Reject links back to the API itself
+echo "Configuring destination policy..."
+SHORT_DOMAINS="$API_ID.execute-api.$REGION.amazonaws.com${SHORT_DOMAINS:+,$SHORT_DOMAINS}"
+aws lambda update-function-configuration \
+    --function-name $FUNCTION_NAME \
+    --environment "{\"Variables\":{\"DENIED_DOMAINS\":\"$DENIED_DOMAINS\",\"SHORT_DOMAINS\":\"$SHORT_DOMAINS\",\"CLICK_SINK\":\"$CLICK_SINK\",\"CLICK_IP_SALT\":\"$CLICK_IP_SALT\",\"ADMIN_TOKEN\":\"$ADMIN_TOKEN\",\"CRAWLER_LIST_FILE\":\"$CRAWLER_LIST_FILE\"}}" \
+    --timeout 900 \
+    --region $REGION
+
+# Schedule the link rot check, the same function handles the scheduled event
+echo "Scheduling link check..."
+FUNCTION_ARN=$(aws lambda get-function --function-name $FUNCTION_NAME --query "Configuration.FunctionArn" --output text)
+RULE_ARN=$(aws events put-rule \
+    --name $LINK_CHECK_RULE \
+    --schedule-expression "$LINK_CHECK_SCHEDULE" \
+    --region $REGION \
+    --query "RuleArn" --output text)
+
+aws lambda add-permission \
+  --function-name $FUNCTION_NAME \
+  --statement-id events-link-check-permission \
+  --action lambda:InvokeFunction \
+  --principal events.amazonaws.com \
+  --source-arn $RULE_ARN \
+  --region $REGION
+
+aws events put-targets \
+    --rule $LINK_CHECK_RULE \
+    --targets "Id=link-check,Arn=$FUNCTION_ARN" \
+    --region $REGION
+
+# Feed the link and click table streams to the function, which rolls them up into the aggregate table
+echo "Subscribing to table streams..."
+for TABLE in $TABLE_NAME $CLICK_TABLE_NAME; do
+  STREAM_ARN=$(aws dynamodb describe-table \
+      --table-name $TABLE \
+      --region $REGION \
+      --query "Table.LatestStreamArn" --output text)
+  aws lambda create-event-source-mapping \
+      --function-name $FUNCTION_NAME \
+      --event-source-arn $STREAM_ARN \
+      --starting-position LATEST \
+      --batch-size 100 \
+      --function-response-types ReportBatchItemFailures \
+      --maximum-retry-attempts 10 \
+      --bisect-batch-on-function-error \
+      --region $REGION
+done
+
 # Output API URL
 API_URL="https://$API_ID.execute-api.$REGION.amazonaws.com/prod"
 echo "API Gateway is deployed. You can use the following URL for access: $API_URL"
+echo "Send \"Authorization: Bearer $ADMIN_TOKEN\" to change links through the API."
 
 echo "Done! API Gateway deployed, Lambda, DynamoDB, and S3 setup complete."
@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"go.uber.org/zap"
+)
+
+type (
+	// AggregateDB is implemented by persistence.UrlDB.
+	AggregateDB interface {
+		ApplyAggregates(ctx context.Context, eventID string, updates []urlDB.AggregateUpdate) (bool, error)
+	}
+
+	// Aggregator consumes DynamoDB stream records of the link and click tables. Every record is
+	// counted in one transaction together with its event ID, so records the stream delivers again
+	// are not counted twice.
+	Aggregator struct {
+		DB     AggregateDB
+		Logger *zap.Logger
+		// LinkTable and ClickTable name the tables the records come from, persistence.URLTable and
+		// persistence.ClickTable when empty.
+		LinkTable  string
+		ClickTable string
+	}
+)
+
+const (
+	// EventSource is the eventSource of DynamoDB stream records.
+	EventSource = "aws:dynamodb"
+	insertEvent = "INSERT"
+)
+
+// IsStreamEvent reports whether event holds DynamoDB stream records rather than being some other
+// payload decoded into a DynamoDBEvent.
+func IsStreamEvent(event events.DynamoDBEvent) bool {
+	return len(event.Records) > 0 && event.Records[0].EventSource == EventSource
+}
+
+// Handle counts the records of a stream batch in order. It stops at the first record it cannot
+// store and reports it as the batch item failure, so the stream retries from there; records it
+// cannot read and records DynamoDB rejects as invalid are logged and skipped since retrying would
+// not help.
+func (a *Aggregator) Handle(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	var response events.DynamoDBEventResponse
+	for _, record := range event.Records {
+		logger := a.Logger.With(zap.String(logkey.EventID, record.EventID))
+		updates, err := a.updates(record)
+		if err != nil {
+			logger.Warn("skipping unreadable stream record", zap.Error(err))
+			continue
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		applied, err := a.DB.ApplyAggregates(ctx, record.EventID, updates)
+		if errors.Is(err, urlDB.ErrAggregateRejected) {
+			logger.Warn("skipping stream record rejected by DynamoDB", zap.Error(err))
+			continue
+		}
+		if err != nil {
+			logger.Error("failed to aggregate stream record", zap.Error(err))
+			response.BatchItemFailures = []events.DynamoDBBatchItemFailure{{ItemIdentifier: record.Change.SequenceNumber}}
+			return response, nil
+		}
+		if !applied {
+			logger.Info("stream record already aggregated")
+		}
+	}
+	return response, nil
+}
+
+// updates returns the counters a record adds to. Only inserts count: links and clicks are never
+// uncounted, and clicks expiring from the click table must not change the totals.
+func (a *Aggregator) updates(record events.DynamoDBEventRecord) ([]urlDB.AggregateUpdate, error) {
+	if record.EventName != insertEvent {
+		return nil, nil
+	}
+	image := record.Change.NewImage
+
+	switch tableOf(record.EventSourceArn) {
+	case orDefault(a.LinkTable, urlDB.URLTable):
+		// webhooks, deliveries and the url-counter live in the link table as well
+		if stringAttr(image, urlDB.Entity) != urlDB.EntityLink {
+			return nil, nil
+		}
+		created := record.Change.ApproximateCreationDateTime.UTC()
+		if v := stringAttr(image, urlDB.CreatedAt); v != "" {
+			t, err := time.Parse(urlDB.CreatedAtLayout, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", urlDB.CreatedAt, err)
+			}
+			created = t
+		}
+		return []urlDB.AggregateUpdate{
+			{Metric: LinksCreatedMetric, Bucket: Day.Bucket(created), Counters: map[string]int64{LinksCounter: 1}},
+		}, nil
+
+	case orDefault(a.ClickTable, urlDB.ClickTable):
+		shortURL := stringAttr(image, urlDB.ShortURL)
+		if shortURL == "" {
+			return nil, fmt.Errorf("click without %s", urlDB.ShortURL)
+		}
+		clickedAt, err := time.Parse(time.RFC3339Nano, stringAttr(image, "clicked_at"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid clicked_at: %w", err)
+		}
+		referrer, bot := stringAttr(image, "referrer"), stringAttr(image, "bot") != ""
+		counters := ClickCounters(referrer, stringAttr(image, "user_agent"), stringAttr(image, "country"), bot)
+		limit := referrerLimit(referrer, bot)
+		return []urlDB.AggregateUpdate{
+			{Metric: ClicksMetric(shortURL, Hour), Bucket: Hour.Bucket(clickedAt), Counters: counters, Limit: limit},
+			{Metric: ClicksMetric(shortURL, Day), Bucket: Day.Bucket(clickedAt), Counters: counters, Limit: limit},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("record from unknown table %q", tableOf(record.EventSourceArn))
+	}
+}
+
+// referrerLimit caps the referrer counters of a bucket, since referrers are sent by clients and
+// could otherwise add attributes until the item is too large to update. Referrers beyond the cap
+// are counted as other.
+func referrerLimit(referrer string, bot bool) *urlDB.CounterLimit {
+	prefix := ReferrerPrefix
+	if bot {
+		prefix = BotPrefix + ReferrerPrefix
+	}
+	return &urlDB.CounterLimit{Counter: prefix + ReferrerDomain(referrer), Fallback: prefix + Other}
+}
+
+// tableOf returns the table name of a stream ARN such as
+// arn:aws:dynamodb:us-east-1:123456789012:table/url-clicks/stream/2025-03-01T00:00:00.000.
+func tableOf(streamARN string) string {
+	_, resource, _ := strings.Cut(streamARN, ":table/")
+	table, _, _ := strings.Cut(resource, "/")
+	return table
+}
+
+func stringAttr(image map[string]events.DynamoDBAttributeValue, name string) string {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeString {
+		return ""
+	}
+	return v.String()
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
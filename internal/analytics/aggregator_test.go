@@ -0,0 +1,226 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeAggregateDB keeps the counters in memory and, like the aggregate table, refuses to count an
+// event ID twice and folds limited counters over maxLimited into their fallback.
+type fakeAggregateDB struct {
+	processed map[string]bool
+	// totals holds the counters of every metric|bucket item
+	totals map[string]map[string]int64
+	// limited holds the limited counters of every metric|bucket item
+	limited    map[string]map[string]bool
+	maxLimited int
+	failOn     string
+	failErr    error
+}
+
+func newFakeAggregateDB() *fakeAggregateDB {
+	return &fakeAggregateDB{
+		processed:  map[string]bool{},
+		totals:     map[string]map[string]int64{},
+		limited:    map[string]map[string]bool{},
+		maxLimited: urlDB.MaxLimitedCounters,
+	}
+}
+
+func (f *fakeAggregateDB) ApplyAggregates(_ context.Context, eventID string, updates []urlDB.AggregateUpdate) (bool, error) {
+	if eventID == f.failOn {
+		return false, f.failErr
+	}
+	if f.processed[eventID] {
+		return false, nil
+	}
+	f.processed[eventID] = true
+	for _, u := range updates {
+		key := u.Metric + "|" + u.Bucket
+		if f.totals[key] == nil {
+			f.totals[key] = map[string]int64{}
+			f.limited[key] = map[string]bool{}
+		}
+		for counter, n := range u.Counters {
+			if u.Limit != nil && counter == u.Limit.Counter && !f.limited[key][counter] {
+				if len(f.limited[key]) >= f.maxLimited {
+					counter = u.Limit.Fallback
+				} else {
+					f.limited[key][counter] = true
+				}
+			}
+			f.totals[key][counter] += n
+		}
+	}
+	return true, nil
+}
+
+func loadStreamEvent(t *testing.T, name string) events.DynamoDBEvent {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	var event events.DynamoDBEvent
+	assert.NoError(t, json.Unmarshal(b, &event))
+	return event
+}
+
+func Test_Aggregator_Handle(t *testing.T) {
+	firstClick := map[string]int64{
+		"clicks":                    1,
+		"referrer#news.example.com": 1,
+		"device#mobile":             1,
+		"browser#safari":            1,
+		"country#US":                1,
+	}
+	clicksB := map[string]int64{
+		"clicks":                    2,
+		"referrer#news.example.com": 1,
+		"referrer#direct":           1,
+		"device#mobile":             1,
+		"device#desktop":            1,
+		"browser#safari":            1,
+		"browser#chrome":            1,
+		"country#US":                1,
+		"country#unknown":           1,
+		"bot#clicks":                1,
+		"bot#referrer#direct":       1,
+		"bot#device#desktop":        1,
+		"bot#browser#other":         1,
+		"bot#country#unknown":       1,
+	}
+	clicksC := map[string]int64{
+		"clicks":          1,
+		"referrer#direct": 1,
+		"device#unknown":  1,
+		"browser#unknown": 1,
+		"country#unknown": 1,
+	}
+
+	skippedB := maps.Clone(clicksB)
+	for _, counter := range []string{"referrer#direct", "device#desktop", "browser#chrome", "country#unknown"} {
+		delete(skippedB, counter)
+	}
+	skippedB["clicks"] = 1
+	cappedB := maps.Clone(clicksB)
+	delete(cappedB, "referrer#direct")
+	delete(cappedB, "bot#referrer#direct")
+	cappedB["referrer#other"] = 1
+	cappedB["bot#referrer#other"] = 1
+
+	tests := map[string]struct {
+		fixture          string
+		maxLimited       int
+		failOn           string
+		failErr          error
+		expectedTotals   map[string]map[string]int64
+		expectedFailures []events.DynamoDBBatchItemFailure
+	}{
+		"Happy Path links": {
+			fixture: "link-stream.json",
+			expectedTotals: map[string]map[string]int64{
+				"links#day|2025-03-01": {"links": 2},
+				"links#day|2025-03-02": {"links": 1},
+			},
+		},
+		"Happy Path clicks": {
+			fixture: "click-stream.json",
+			expectedTotals: map[string]map[string]int64{
+				"clicks#b#hour|2025-03-01T09": clicksB,
+				"clicks#b#day|2025-03-01":     clicksB,
+				"clicks#c#hour|2025-03-01T10": clicksC,
+				"clicks#c#day|2025-03-01":     clicksC,
+			},
+		},
+		"Happy Path referrers over the limit count as other": {
+			fixture:    "click-stream.json",
+			maxLimited: 1,
+			expectedTotals: map[string]map[string]int64{
+				"clicks#b#hour|2025-03-01T09": cappedB,
+				"clicks#b#day|2025-03-01":     cappedB,
+				"clicks#c#hour|2025-03-01T10": clicksC,
+				"clicks#c#day|2025-03-01":     clicksC,
+			},
+		},
+		"Sad Path rejected record is skipped": {
+			fixture: "click-stream.json",
+			failOn:  "8f1b7c4e3d0a1f9b6c5d4e3f2a1b0c9d",
+			failErr: fmt.Errorf("%w: item size has exceeded the maximum allowed size", urlDB.ErrAggregateRejected),
+			expectedTotals: map[string]map[string]int64{
+				"clicks#b#hour|2025-03-01T09": skippedB,
+				"clicks#b#day|2025-03-01":     skippedB,
+				"clicks#c#hour|2025-03-01T10": clicksC,
+				"clicks#c#day|2025-03-01":     clicksC,
+			},
+		},
+		"Sad Path error": {
+			fixture: "click-stream.json",
+			failOn:  "8f1b7c4e3d0a1f9b6c5d4e3f2a1b0c9d",
+			failErr: errors.New("error"),
+			expectedTotals: map[string]map[string]int64{
+				"clicks#b#hour|2025-03-01T09": firstClick,
+				"clicks#b#day|2025-03-01":     firstClick,
+			},
+			expectedFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: "200000000000000000002"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := newFakeAggregateDB()
+			db.failOn, db.failErr = tc.failOn, tc.failErr
+			if tc.maxLimited > 0 {
+				db.maxLimited = tc.maxLimited
+			}
+			aggregator := &Aggregator{DB: db, Logger: zaptest.NewLogger(t)}
+
+			response, err := aggregator.Handle(context.Background(), loadStreamEvent(t, tc.fixture))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFailures, response.BatchItemFailures)
+			assert.Equal(t, tc.expectedTotals, db.totals)
+		})
+	}
+}
+
+func Test_Aggregator_Handle_Replay(t *testing.T) {
+	db := newFakeAggregateDB()
+	aggregator := &Aggregator{DB: db, Logger: zaptest.NewLogger(t)}
+	event := loadStreamEvent(t, "click-stream.json")
+
+	_, err := aggregator.Handle(context.Background(), event)
+	assert.NoError(t, err)
+	first := map[string]map[string]int64{}
+	for key, counters := range db.totals {
+		first[key] = maps.Clone(counters)
+	}
+
+	response, err := aggregator.Handle(context.Background(), event)
+	assert.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, first, db.totals)
+}
+
+func Test_IsStreamEvent(t *testing.T) {
+	assert.True(t, IsStreamEvent(loadStreamEvent(t, "link-stream.json")))
+	assert.False(t, IsStreamEvent(events.DynamoDBEvent{}))
+
+	var scheduled events.DynamoDBEvent
+	assert.NoError(t, json.Unmarshal([]byte(`{"source":"aws.events","detail-type":"Scheduled Event"}`), &scheduled))
+	assert.False(t, IsStreamEvent(scheduled))
+}
+
+func Test_TableOf(t *testing.T) {
+	assert.Equal(t, "url-clicks", tableOf("arn:aws:dynamodb:us-east-1:123456789012:table/url-clicks/stream/2025-03-01T00:00:00.000"))
+	assert.Empty(t, tableOf("arn:aws:sqs:us-east-1:123456789012:queue"))
+}
@@ -0,0 +1,91 @@
+// Package analytics rolls the change streams of the link and click tables up into counters in the
+// aggregate table, so reports read a handful of pre-aggregated items instead of raw clicks.
+package analytics
+
+import (
+	"fmt"
+	"time"
+)
+
+type Interval string
+
+const (
+	Hour Interval = "hour"
+	Day  Interval = "day"
+	// Week is not stored. Weeks start on Monday and are summed from Day buckets when reporting.
+	Week Interval = "week"
+
+	HourLayout = "2006-01-02T15"
+	DayLayout  = "2006-01-02"
+
+	// ClicksCounter and LinksCounter are the counter attributes of the aggregate items.
+	ClicksCounter = "clicks"
+	LinksCounter  = "links"
+	// LinksCreatedMetric counts the links created per day.
+	LinksCreatedMetric = "links#day"
+)
+
+// ParseInterval accepts hour, day and week.
+func ParseInterval(v string) (Interval, error) {
+	switch i := Interval(v); i {
+	case Hour, Day, Week:
+		return i, nil
+	default:
+		return "", fmt.Errorf("%w: interval must be %s, %s or %s", ErrInvalidQuery, Hour, Day, Week)
+	}
+}
+
+// ClicksMetric is the aggregate partition counting the clicks of a link per interval.
+func ClicksMetric(shortURL string, interval Interval) string {
+	return "clicks#" + shortURL + "#" + string(interval)
+}
+
+// Bucket returns the UTC bucket t is counted in. Buckets sort chronologically as strings.
+func (i Interval) Bucket(t time.Time) string {
+	if i == Hour {
+		return t.UTC().Format(HourLayout)
+	}
+	return t.UTC().Format(DayLayout)
+}
+
+// Truncate returns the start of the UTC interval t falls in.
+func (i Interval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch i {
+	case Hour:
+		return t.Truncate(time.Hour)
+	case Week:
+		day := Day.Truncate(t)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the start of the interval after the one starting at start.
+func (i Interval) Next(start time.Time) time.Time {
+	switch i {
+	case Hour:
+		return start.Add(time.Hour)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// stored is the interval the aggregate table keeps the counters of i in.
+func (i Interval) stored() Interval {
+	if i == Hour {
+		return Hour
+	}
+	return Day
+}
+
+// parseBucket is the inverse of Bucket.
+func (i Interval) parseBucket(bucket string) (time.Time, error) {
+	if i == Hour {
+		return time.Parse(HourLayout, bucket)
+	}
+	return time.Parse(DayLayout, bucket)
+}
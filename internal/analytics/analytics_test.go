@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Interval_Bucket(t *testing.T) {
+	clickedAt := time.Date(2025, 3, 1, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	assert.Equal(t, "2025-03-02T04", Hour.Bucket(clickedAt))
+	assert.Equal(t, "2025-03-02", Day.Bucket(clickedAt))
+}
+
+func Test_ClicksMetric(t *testing.T) {
+	assert.Equal(t, "clicks#b#hour", ClicksMetric("b", Hour))
+	assert.Equal(t, "clicks#b#day", ClicksMetric("b", Day))
+}
+
+func Test_Interval_Truncate(t *testing.T) {
+	// a Sunday
+	clickedAt := time.Date(2025, 3, 9, 23, 30, 15, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 9, 23, 0, 0, 0, time.UTC), Hour.Truncate(clickedAt))
+	assert.Equal(t, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC), Day.Truncate(clickedAt))
+	assert.Equal(t, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Week.Truncate(clickedAt))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Week.Truncate(clickedAt.Add(time.Hour)))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Week.Next(Week.Truncate(clickedAt)))
+}
+
+func Test_ParseInterval(t *testing.T) {
+	tests := map[string]struct {
+		value         string
+		expected      Interval
+		expectedError error
+	}{
+		"Happy Path hour": {value: "hour", expected: Hour},
+		"Happy Path week": {value: "week", expected: Week},
+		"Sad Path":        {value: "month", expectedError: ErrInvalidQuery},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			interval, err := ParseInterval(tc.value)
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Equal(t, tc.expected, interval)
+		})
+	}
+}
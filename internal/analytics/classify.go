@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	// Breakdown counters are stored next to ClicksCounter as <prefix><value>, e.g. device#mobile,
+	// since DynamoDB cannot add to nested attributes.
+	ReferrerPrefix = "referrer#"
+	DevicePrefix   = "device#"
+	BrowserPrefix  = "browser#"
+	CountryPrefix  = "country#"
+	// BotPrefix is put in front of every counter a bot's click adds to, e.g. bot#clicks, so
+	// reports can leave bots out.
+	BotPrefix = "bot#"
+
+	// Direct counts clicks without a referrer.
+	Direct  = "direct"
+	Unknown = "unknown"
+	Other   = "other"
+
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+
+	maxDomainLength = 253
+)
+
+// ClickCounters returns the counters a click adds to: ClicksCounter and one counter of every
+// breakdown, all prefixed with BotPrefix for bots.
+func ClickCounters(referrer, userAgent, country string, bot bool) map[string]int64 {
+	prefix := ""
+	if bot {
+		prefix = BotPrefix
+	}
+	return map[string]int64{
+		prefix + ClicksCounter:                             1,
+		prefix + ReferrerPrefix + ReferrerDomain(referrer): 1,
+		prefix + DevicePrefix + DeviceClass(userAgent):     1,
+		prefix + BrowserPrefix + Browser(userAgent):        1,
+		prefix + CountryPrefix + Country(country):          1,
+	}
+}
+
+// ReferrerDomain returns the lower cased host of referrer without a leading www., direct when
+// there is no referrer and unknown when it is not a URL.
+func ReferrerDomain(referrer string) string {
+	if referrer == "" {
+		return Direct
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return Unknown
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" || len(host) > maxDomainLength {
+		return Unknown
+	}
+	return strings.TrimPrefix(host, "www.")
+}
+
+// DeviceClass returns mobile, tablet or desktop, or unknown without a user agent.
+func DeviceClass(ua string) string {
+	switch {
+	case ua == "":
+		return Unknown
+	case strings.Contains(ua, "iPad"), strings.Contains(ua, "Tablet"),
+		strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "Mobile"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"),
+		strings.Contains(ua, "Android"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// Browser returns the browser family of a user agent. Most browsers claim to be several others,
+// so the more specific tokens are checked first.
+func Browser(ua string) string {
+	switch {
+	case ua == "":
+		return Unknown
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "EdgA/"), strings.Contains(ua, "EdgiOS/"):
+		return "edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		return "opera"
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return "samsung"
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		return "firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"), strings.Contains(ua, "Chromium/"):
+		return "chrome"
+	case strings.Contains(ua, "Safari/"):
+		return "safari"
+	default:
+		return Other
+	}
+}
+
+// Country returns the upper cased ISO 3166 code CloudFront reported, or unknown.
+func Country(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	// CloudFront sends XX when it cannot locate the viewer
+	if len(code) != 2 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" || code == "XX" {
+		return Unknown
+	}
+	return code
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneSafari  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	iPadSafari    = "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	androidChrome = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Mobile Safari/537.36"
+	androidTablet = "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36"
+	windowsEdge   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36 Edg/122.0.2365.80"
+	macFirefox    = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:124.0) Gecko/20100101 Firefox/124.0"
+	linuxOpera    = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36 OPR/108.0.0.0"
+)
+
+func Test_ClickCounters(t *testing.T) {
+	assert.Equal(t, map[string]int64{
+		"clicks":               1,
+		"referrer#example.com": 1,
+		"device#mobile":        1,
+		"browser#chrome":       1,
+		"country#DE":           1,
+	}, ClickCounters("https://www.example.com/post", androidChrome, "de", false))
+	assert.Equal(t, map[string]int64{
+		"bot#clicks":          1,
+		"bot#referrer#direct": 1,
+		"bot#device#desktop":  1,
+		"bot#browser#other":   1,
+		"bot#country#unknown": 1,
+	}, ClickCounters("", "Slackbot-LinkExpanding 1.0", "", true))
+}
+
+func Test_ReferrerDomain(t *testing.T) {
+	tests := map[string]struct {
+		referrer string
+		expected string
+	}{
+		"Happy Path":         {referrer: "https://news.example.com/a?b=c", expected: "news.example.com"},
+		"Happy Path www":     {referrer: "https://WWW.Example.com./", expected: "example.com"},
+		"Happy Path direct":  {expected: Direct},
+		"Sad Path not a URL": {referrer: "::", expected: Unknown},
+		"Sad Path no host":   {referrer: "android-app:", expected: Unknown},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ReferrerDomain(tc.referrer))
+		})
+	}
+}
+
+func Test_DeviceClassAndBrowser(t *testing.T) {
+	tests := map[string]struct {
+		userAgent       string
+		expectedDevice  string
+		expectedBrowser string
+	}{
+		"iPhone Safari":  {userAgent: iPhoneSafari, expectedDevice: DeviceMobile, expectedBrowser: "safari"},
+		"iPad Safari":    {userAgent: iPadSafari, expectedDevice: DeviceTablet, expectedBrowser: "safari"},
+		"Android Chrome": {userAgent: androidChrome, expectedDevice: DeviceMobile, expectedBrowser: "chrome"},
+		"Android tablet": {userAgent: androidTablet, expectedDevice: DeviceTablet, expectedBrowser: "samsung"},
+		"Windows Edge":   {userAgent: windowsEdge, expectedDevice: DeviceDesktop, expectedBrowser: "edge"},
+		"Mac Firefox":    {userAgent: macFirefox, expectedDevice: DeviceDesktop, expectedBrowser: "firefox"},
+		"Linux Opera":    {userAgent: linuxOpera, expectedDevice: DeviceDesktop, expectedBrowser: "opera"},
+		"curl":           {userAgent: "curl/8.5.0", expectedDevice: DeviceDesktop, expectedBrowser: Other},
+		"no user agent":  {expectedDevice: Unknown, expectedBrowser: Unknown},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedDevice, DeviceClass(tc.userAgent))
+			assert.Equal(t, tc.expectedBrowser, Browser(tc.userAgent))
+		})
+	}
+}
+
+func Test_Country(t *testing.T) {
+	assert.Equal(t, "US", Country("us"))
+	assert.Equal(t, Unknown, Country(""))
+	assert.Equal(t, Unknown, Country("XX"))
+	assert.Equal(t, Unknown, Country("U1"))
+}
@@ -0,0 +1,200 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+)
+
+type (
+	// ReportDB is implemented by persistence.UrlDB.
+	ReportDB interface {
+		QueryAggregates(ctx context.Context, metric, from, to string) ([]urlDB.AggregateItem, error)
+	}
+
+	// Reporter reads click reports from the counters the Aggregator maintains.
+	Reporter struct {
+		DB ReportDB
+		// Now returns the current time, time.Now when nil.
+		Now func() time.Time
+	}
+
+	// Query selects the clicks counted from From through To, in buckets of Interval. Zero values
+	// are filled in with DefaultSpans and the current time. Bots are left out of the clicks and
+	// breakdowns unless IncludeBots is set.
+	Query struct {
+		From        time.Time
+		To          time.Time
+		Interval    Interval
+		IncludeBots bool
+	}
+
+	// Breakdowns split clicks by referrer domain, device class, browser and country.
+	Breakdowns struct {
+		Referrers map[string]int64 `json:"referrers"`
+		Devices   map[string]int64 `json:"devices"`
+		Browsers  map[string]int64 `json:"browsers"`
+		Countries map[string]int64 `json:"countries"`
+	}
+
+	// BucketCount holds the clicks of the interval starting at Start.
+	BucketCount struct {
+		Start  time.Time `json:"start"`
+		Clicks int64     `json:"clicks"`
+		// Bots is the number of bot clicks, whether or not they are part of Clicks.
+		Bots int64 `json:"bots"`
+		Breakdowns
+	}
+
+	// Report is the response of GET /{shortUrl}/analytics. Buckets covers the whole range in
+	// order, including the ones without clicks.
+	Report struct {
+		ShortURL    string        `json:"short_url"`
+		Interval    Interval      `json:"interval"`
+		From        time.Time     `json:"from"`
+		To          time.Time     `json:"to"`
+		IncludeBots bool          `json:"include_bots"`
+		Clicks      int64         `json:"clicks"`
+		Bots        int64         `json:"bots"`
+		Buckets     []BucketCount `json:"buckets"`
+		Breakdowns
+	}
+)
+
+// MaxBuckets limits the size of a report, e.g. to about 41 days of hours.
+const MaxBuckets = 1000
+
+// ErrInvalidQuery is returned for queries that cannot be answered.
+var ErrInvalidQuery = errors.New("invalid analytics query")
+
+// DefaultSpans is how far back a report reaches when the query has no From.
+var DefaultSpans = map[Interval]time.Duration{
+	Hour: 24 * time.Hour,
+	Day:  30 * 24 * time.Hour,
+	Week: 12 * 7 * 24 * time.Hour,
+}
+
+// Report returns the clicks of shortURL selected by q. Counters are kept per hour and per day, so
+// the first and last buckets of a week report only count the days in range, and From and To are
+// effectively rounded to the hour or day they fall in.
+func (r *Reporter) Report(ctx context.Context, shortURL string, q Query) (*Report, error) {
+	q, err := r.normalize(q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		ShortURL:    shortURL,
+		Interval:    q.Interval,
+		From:        q.From,
+		To:          q.To,
+		IncludeBots: q.IncludeBots,
+		Buckets:     []BucketCount{},
+		Breakdowns:  newBreakdowns(),
+	}
+	index := map[time.Time]int{}
+	for start := q.Interval.Truncate(q.From); !start.After(q.To); start = q.Interval.Next(start) {
+		index[start] = len(report.Buckets)
+		report.Buckets = append(report.Buckets, BucketCount{Start: start, Breakdowns: newBreakdowns()})
+	}
+
+	stored := q.Interval.stored()
+	items, err := r.DB.QueryAggregates(ctx, ClicksMetric(shortURL, stored), stored.Bucket(q.From), stored.Bucket(q.To))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		t, err := stored.parseBucket(item.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %w", item.Bucket, err)
+		}
+		i, ok := index[q.Interval.Truncate(t)]
+		if !ok {
+			continue
+		}
+		bucket := &report.Buckets[i]
+		for name, n := range item.Counters {
+			counter, bot := strings.CutPrefix(name, BotPrefix)
+			if bot && counter == ClicksCounter {
+				bucket.Bots += n
+				report.Bots += n
+			}
+			if bot && !q.IncludeBots {
+				continue
+			}
+			if counter == ClicksCounter {
+				bucket.Clicks += n
+				report.Clicks += n
+			}
+			bucket.add(counter, n)
+			report.add(counter, n)
+		}
+	}
+	return report, nil
+}
+
+// normalize fills in the defaults of q and checks the report it asks for is not too large.
+func (r *Reporter) normalize(q Query) (Query, error) {
+	if q.Interval == "" {
+		q.Interval = Day
+	}
+	if _, err := ParseInterval(string(q.Interval)); err != nil {
+		return q, err
+	}
+	if q.To.IsZero() {
+		q.To = r.now()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-DefaultSpans[q.Interval])
+	}
+	q.From, q.To = q.From.UTC(), q.To.UTC()
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("%w: from must not be later than to", ErrInvalidQuery)
+	}
+
+	buckets := 0
+	for start := q.Interval.Truncate(q.From); !start.After(q.To); start = q.Interval.Next(start) {
+		if buckets++; buckets > MaxBuckets {
+			return q, fmt.Errorf("%w: at most %d %s buckets are supported", ErrInvalidQuery, MaxBuckets, q.Interval)
+		}
+	}
+	return q, nil
+}
+
+func (r *Reporter) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+func newBreakdowns() Breakdowns {
+	return Breakdowns{
+		Referrers: map[string]int64{},
+		Devices:   map[string]int64{},
+		Browsers:  map[string]int64{},
+		Countries: map[string]int64{},
+	}
+}
+
+// add adds n to the breakdown counter belongs to, if any.
+func (b Breakdowns) add(counter string, n int64) {
+	kind, value, ok := strings.Cut(counter, "#")
+	if !ok {
+		return
+	}
+	switch kind + "#" {
+	case ReferrerPrefix:
+		b.Referrers[value] += n
+	case DevicePrefix:
+		b.Devices[value] += n
+	case BrowserPrefix:
+		b.Browsers[value] += n
+	case CountryPrefix:
+		b.Countries[value] += n
+	}
+}
@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReportDB struct {
+	mock.Mock
+}
+
+func (m *MockReportDB) QueryAggregates(ctx context.Context, metric, from, to string) ([]urlDB.AggregateItem, error) {
+	args := m.Called(ctx, metric, from, to)
+	items, _ := args.Get(0).([]urlDB.AggregateItem)
+	return items, args.Error(1)
+}
+
+func Test_Reporter_Report(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 20, 0, 0, time.UTC)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	days := []urlDB.AggregateItem{
+		{Bucket: "2025-03-01", Counters: map[string]int64{"clicks": 2, "referrer#direct": 2, "device#mobile": 1, "device#desktop": 1, "browser#safari": 2, "country#US": 2}},
+		{Bucket: "2025-03-03", Counters: map[string]int64{"clicks": 1, "referrer#example.com": 1, "device#mobile": 1, "browser#chrome": 1, "country#DE": 1}},
+		{Bucket: "2025-03-10", Counters: map[string]int64{"clicks": 4, "referrer#direct": 4, "device#desktop": 4, "browser#firefox": 4, "country#US": 4}},
+	}
+
+	tests := map[string]struct {
+		query           Query
+		metric          string
+		fromBucket      string
+		toBucket        string
+		items           []urlDB.AggregateItem
+		queryError      error
+		expectedBuckets map[time.Time]int64
+		expectedLen     int
+		expectedError   error
+	}{
+		"Happy Path day": {
+			query:           Query{From: from, To: to, Interval: Day},
+			metric:          "clicks#b#day",
+			fromBucket:      "2025-03-01",
+			toBucket:        "2025-03-10",
+			items:           days,
+			expectedLen:     10,
+			expectedBuckets: map[time.Time]int64{from: 2, from.AddDate(0, 0, 2): 1, from.AddDate(0, 0, 9): 4},
+		},
+		"Happy Path week": {
+			query:       Query{From: from, To: to, Interval: Week},
+			metric:      "clicks#b#day",
+			fromBucket:  "2025-03-01",
+			toBucket:    "2025-03-10",
+			items:       days,
+			expectedLen: 3,
+			expectedBuckets: map[time.Time]int64{
+				time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC): 2,
+				time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC):  1,
+				time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC): 4,
+			},
+		},
+		"Happy Path defaults": {
+			query:       Query{Interval: Hour},
+			metric:      "clicks#b#hour",
+			fromBucket:  "2025-03-11T15",
+			toBucket:    "2025-03-12T15",
+			items:       []urlDB.AggregateItem{{Bucket: "2025-03-12T09", Counters: map[string]int64{"clicks": 3}}},
+			expectedLen: 25,
+			expectedBuckets: map[time.Time]int64{
+				time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC): 3,
+			},
+		},
+		"Sad Path from after to": {
+			query:         Query{From: to, To: from, Interval: Day},
+			expectedError: ErrInvalidQuery,
+		},
+		"Sad Path too many buckets": {
+			query:         Query{From: from, To: from.AddDate(0, 0, 60), Interval: Hour},
+			expectedError: ErrInvalidQuery,
+		},
+		"Sad Path invalid interval": {
+			query:         Query{Interval: "month"},
+			expectedError: ErrInvalidQuery,
+		},
+		"Sad Path error": {
+			query:      Query{From: from, To: to},
+			metric:     "clicks#b#day",
+			fromBucket: "2025-03-01",
+			toBucket:   "2025-03-10",
+			queryError: errors.New("error"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := new(MockReportDB)
+			if tc.metric != "" {
+				db.On("QueryAggregates", mock.Anything, tc.metric, tc.fromBucket, tc.toBucket).Return(tc.items, tc.queryError)
+			}
+			reporter := &Reporter{DB: db, Now: func() time.Time { return now }}
+
+			report, err := reporter.Report(context.Background(), "b", tc.query)
+
+			switch {
+			case tc.expectedError != nil:
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, report)
+			case tc.queryError != nil:
+				assert.ErrorIs(t, err, tc.queryError)
+				assert.Nil(t, report)
+			default:
+				assert.NoError(t, err)
+				assert.Len(t, report.Buckets, tc.expectedLen)
+				var total int64
+				for _, bucket := range report.Buckets {
+					assert.Equal(t, tc.expectedBuckets[bucket.Start], bucket.Clicks, bucket.Start.String())
+					total += bucket.Clicks
+				}
+				assert.Equal(t, total, report.Clicks)
+			}
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Reporter_Report_Breakdowns(t *testing.T) {
+	db := new(MockReportDB)
+	db.On("QueryAggregates", mock.Anything, "clicks#b#day", "2025-03-01", "2025-03-02").Return([]urlDB.AggregateItem{
+		{Bucket: "2025-03-01", Counters: map[string]int64{"clicks": 2, "referrer#direct": 2, "device#mobile": 1, "device#desktop": 1, "browser#safari": 2, "country#US": 2}},
+		{Bucket: "2025-03-02", Counters: map[string]int64{"clicks": 1, "referrer#example.com": 1, "device#mobile": 1, "browser#chrome": 1, "country#DE": 1}},
+	}, nil)
+	reporter := &Reporter{DB: db}
+
+	report, err := reporter.Report(context.Background(), "b", Query{
+		From:     time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 3, 2, 23, 0, 0, 0, time.UTC),
+		Interval: Day,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, report.Clicks)
+	assert.Equal(t, Breakdowns{
+		Referrers: map[string]int64{"direct": 2, "example.com": 1},
+		Devices:   map[string]int64{"mobile": 2, "desktop": 1},
+		Browsers:  map[string]int64{"safari": 2, "chrome": 1},
+		Countries: map[string]int64{"US": 2, "DE": 1},
+	}, report.Breakdowns)
+	assert.Equal(t, map[string]int64{"mobile": 1, "desktop": 1}, report.Buckets[0].Devices)
+	assert.Equal(t, map[string]int64{"DE": 1}, report.Buckets[1].Countries)
+}
+
+func Test_Reporter_Report_Bots(t *testing.T) {
+	items := []urlDB.AggregateItem{
+		{Bucket: "2025-03-01", Counters: map[string]int64{
+			"clicks": 2, "device#mobile": 2,
+			"bot#clicks": 5, "bot#device#desktop": 5,
+		}},
+	}
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		includeBots     bool
+		expectedClicks  int64
+		expectedDevices map[string]int64
+	}{
+		"Bots left out": {
+			expectedClicks:  2,
+			expectedDevices: map[string]int64{"mobile": 2},
+		},
+		"Bots included": {
+			includeBots:     true,
+			expectedClicks:  7,
+			expectedDevices: map[string]int64{"mobile": 2, "desktop": 5},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := new(MockReportDB)
+			db.On("QueryAggregates", mock.Anything, "clicks#b#day", "2025-03-01", "2025-03-01").Return(items, nil)
+			reporter := &Reporter{DB: db}
+
+			report, err := reporter.Report(context.Background(), "b", Query{From: from, To: from, Interval: Day, IncludeBots: tc.includeBots})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.includeBots, report.IncludeBots)
+			assert.Equal(t, tc.expectedClicks, report.Clicks)
+			assert.EqualValues(t, 5, report.Bots)
+			assert.Equal(t, tc.expectedDevices, report.Devices)
+			assert.Equal(t, tc.expectedClicks, report.Buckets[0].Clicks)
+			assert.EqualValues(t, 5, report.Buckets[0].Bots)
+		})
+	}
+}
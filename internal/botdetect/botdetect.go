@@ -0,0 +1,111 @@
+// Package botdetect tells crawlers, link unfurlers and prefetches apart from people following a
+// link, so they can be left out of click counts.
+package botdetect
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Reason says why a request was classified as a bot. It is empty for people.
+type Reason string
+
+const (
+	// UserAgent requests have no user agent or one matching a built-in pattern.
+	UserAgent Reason = "user_agent"
+	// Crawler requests have a user agent on the crawler list.
+	Crawler Reason = "crawler"
+	// Prefetch requests are sent by browsers speculatively, before anyone clicks.
+	Prefetch Reason = "prefetch"
+	// Head requests only look at the response headers, as link checkers and unfurlers do.
+	Head Reason = "head"
+
+	CrawlerListEnv = "CRAWLER_LIST_FILE"
+)
+
+// Patterns are matched case-insensitively anywhere in the user agent. They catch the common
+// crawlers, chat app unfurlers and HTTP libraries; anything more specific belongs on the list.
+var Patterns = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit", "facebookcatalog", "whatsapp",
+	"skypeuripreview", "embedly", "vkshare", "pinterest", "redditbot", "preview", "headlesschrome",
+	"phantomjs", "lighthouse", "curl/", "wget/", "python-requests", "python-urllib", "go-http-client",
+	"okhttp", "java/", "libwww-perl", "node-fetch", "axios/",
+}
+
+// prefetchHeaders mark speculative requests: Sec-Purpose and Purpose from Chrome and Safari,
+// X-Moz from Firefox and X-Purpose from older WebKit browsers.
+var prefetchHeaders = []string{"Sec-Purpose", "Purpose", "X-Moz", "X-Purpose"}
+
+// Classifier matches requests against Patterns and a crawler list.
+type Classifier struct {
+	crawlers []string
+}
+
+// LoadFile reads the crawler list at path, see ParseList.
+func LoadFile(path string) (*Classifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseList(f)
+}
+
+// ParseList reads one user agent substring per line, e.g. "Mastodon/" or "Google-InspectionTool".
+// Blank lines and lines starting with # are ignored.
+func ParseList(r io.Reader) (*Classifier, error) {
+	var c Classifier
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c.crawlers = append(c.crawlers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FromEnv loads the crawler list named by CRAWLER_LIST_FILE. Without it only Patterns are used.
+func FromEnv() (*Classifier, error) {
+	if path := os.Getenv(CrawlerListEnv); path != "" {
+		return LoadFile(path)
+	}
+	return &Classifier{}, nil
+}
+
+// Classify returns why r looks like it was not sent by a person clicking a link, or an empty
+// Reason.
+func (c *Classifier) Classify(r *http.Request) Reason {
+	if r.Method == http.MethodHead {
+		return Head
+	}
+	for _, header := range prefetchHeaders {
+		v := strings.ToLower(r.Header.Get(header))
+		if strings.Contains(v, "prefetch") || strings.Contains(v, "preview") {
+			return Prefetch
+		}
+	}
+
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return UserAgent
+	}
+	for _, crawler := range c.crawlers {
+		if strings.Contains(ua, crawler) {
+			return Crawler
+		}
+	}
+	for _, pattern := range Patterns {
+		if strings.Contains(ua, pattern) {
+			return UserAgent
+		}
+	}
+	return ""
+}
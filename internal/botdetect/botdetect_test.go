@@ -0,0 +1,123 @@
+package botdetect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+func Test_Classifier_Classify(t *testing.T) {
+	classifier, err := ParseList(strings.NewReader("# fediverse servers fetching link previews\nMastodon/\n\n  Google-InspectionTool \n"))
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		method   string
+		headers  map[string]string
+		expected Reason
+	}{
+		"Happy Path person": {
+			headers: map[string]string{"User-Agent": chrome},
+		},
+		"Slack unfurler": {
+			headers:  map[string]string{"User-Agent": "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+			expected: UserAgent,
+		},
+		"Facebook crawler": {
+			headers:  map[string]string{"User-Agent": "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)"},
+			expected: UserAgent,
+		},
+		"Googlebot": {
+			headers:  map[string]string{"User-Agent": "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+			expected: UserAgent,
+		},
+		"curl": {
+			headers:  map[string]string{"User-Agent": "curl/8.5.0"},
+			expected: UserAgent,
+		},
+		"no user agent": {
+			expected: UserAgent,
+		},
+		"crawler list": {
+			headers:  map[string]string{"User-Agent": "Mastodon/4.2.8 (http.rb/5.1.1; +https://mastodon.social/)"},
+			expected: Crawler,
+		},
+		"crawler list case-insensitive": {
+			headers:  map[string]string{"User-Agent": "Mozilla/5.0 (compatible; google-inspectiontool/1.0)"},
+			expected: Crawler,
+		},
+		"Chrome prefetch": {
+			headers:  map[string]string{"User-Agent": chrome, "Sec-Purpose": "prefetch;prerender"},
+			expected: Prefetch,
+		},
+		"Firefox prefetch": {
+			headers:  map[string]string{"User-Agent": chrome, "X-Moz": "prefetch"},
+			expected: Prefetch,
+		},
+		"Safari preview": {
+			headers:  map[string]string{"User-Agent": chrome, "X-Purpose": "preview"},
+			expected: Prefetch,
+		},
+		"HEAD": {
+			method:   http.MethodHead,
+			headers:  map[string]string{"User-Agent": chrome},
+			expected: Head,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/b", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, tc.expected, classifier.Classify(r))
+		})
+	}
+}
+
+func Test_FromEnv(t *testing.T) {
+	mastodon := httptest.NewRequest(http.MethodGet, "/b", nil)
+	mastodon.Header.Set("User-Agent", "Mastodon/4.2.8")
+
+	t.Run("None", func(t *testing.T) {
+		classifier, err := FromEnv()
+		assert.NoError(t, err)
+		assert.Empty(t, classifier.Classify(mastodon))
+	})
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "crawlers.txt")
+		assert.NoError(t, os.WriteFile(path, []byte("Mastodon/\n"), 0o600))
+		t.Setenv(CrawlerListEnv, path)
+
+		classifier, err := FromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, Crawler, classifier.Classify(mastodon))
+	})
+	t.Run("Sad Path missing file", func(t *testing.T) {
+		t.Setenv(CrawlerListEnv, filepath.Join(t.TempDir(), "missing.txt"))
+
+		_, err := FromEnv()
+		assert.Error(t, err)
+	})
+}
+
+func Test_LoadFile(t *testing.T) {
+	// the list deployed with the function
+	classifier, err := LoadFile(filepath.Join("..", "..", "crawlers.txt"))
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/b", nil)
+	r.Header.Set("User-Agent", "Iframely/1.3.1 (+https://iframely.com/docs/about)")
+	assert.Equal(t, Crawler, classifier.Classify(r))
+}
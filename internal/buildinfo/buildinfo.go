@@ -0,0 +1,95 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Commit and BuildTime are set at link time, e.g.
+// -ldflags "-X github.com/connorpalermo/url-shortener/internal/buildinfo.Commit=$(git rev-parse HEAD)".
+var (
+	Commit    string
+	BuildTime string
+)
+
+const (
+	RegionEnv          = "AWS_REGION"
+	DefaultRegionEnv   = "AWS_DEFAULT_REGION"
+	FunctionNameEnv    = "AWS_LAMBDA_FUNCTION_NAME"
+	FunctionVersionEnv = "AWS_LAMBDA_FUNCTION_VERSION"
+	Unknown            = "unknown"
+	LocalRegion        = "local"
+)
+
+var startTime = time.Now()
+
+type Info struct {
+	Commit          string
+	BuildTime       string
+	GoVersion       string
+	Region          string
+	FunctionName    string
+	FunctionVersion string
+	Uptime          time.Duration
+}
+
+// Get collects the link time metadata along with what can be detected from the Lambda runtime.
+func Get() Info {
+	commit, buildTime := Commit, BuildTime
+	if commit == "" || buildTime == "" {
+		vcsCommit, vcsTime := readVCS()
+		if commit == "" {
+			commit = vcsCommit
+		}
+		if buildTime == "" {
+			buildTime = vcsTime
+		}
+	}
+
+	return Info{
+		Commit:          orUnknown(commit),
+		BuildTime:       orUnknown(buildTime),
+		GoVersion:       runtime.Version(),
+		Region:          region(),
+		FunctionName:    os.Getenv(FunctionNameEnv),
+		FunctionVersion: os.Getenv(FunctionVersionEnv),
+		Uptime:          time.Since(startTime),
+	}
+}
+
+func region() string {
+	if r := os.Getenv(RegionEnv); r != "" {
+		return r
+	}
+	if r := os.Getenv(DefaultRegionEnv); r != "" {
+		return r
+	}
+	return LocalRegion
+}
+
+// readVCS falls back to the revision stamped by the go tool when ldflags were not provided.
+func readVCS() (string, string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	var revision, vcsTime string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			vcsTime = setting.Value
+		}
+	}
+	return revision, vcsTime
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return Unknown
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Get(t *testing.T) {
+	tests := map[string]struct {
+		env            map[string]string
+		commit         string
+		buildTime      string
+		expectedRegion string
+	}{
+		"Lambda environment": {
+			env: map[string]string{
+				RegionEnv:          "us-west-2",
+				FunctionNameEnv:    "urlShortenerLambda",
+				FunctionVersionEnv: "7",
+			},
+			commit:         "abc123",
+			buildTime:      "2024-01-01T00:00:00Z",
+			expectedRegion: "us-west-2",
+		},
+		"Default region fallback": {
+			env: map[string]string{
+				RegionEnv:        "",
+				DefaultRegionEnv: "eu-west-1",
+			},
+			expectedRegion: "eu-west-1",
+		},
+		"Local": {
+			env: map[string]string{
+				RegionEnv:        "",
+				DefaultRegionEnv: "",
+			},
+			expectedRegion: LocalRegion,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			Commit, BuildTime = tc.commit, tc.buildTime
+			defer func() { Commit, BuildTime = "", "" }()
+
+			info := Get()
+
+			assert.Equal(t, tc.expectedRegion, info.Region)
+			assert.Equal(t, runtime.Version(), info.GoVersion)
+			assert.Equal(t, tc.env[FunctionNameEnv], info.FunctionName)
+			assert.Equal(t, tc.env[FunctionVersionEnv], info.FunctionVersion)
+			assert.NotEmpty(t, info.Commit)
+			assert.NotEmpty(t, info.BuildTime)
+			if tc.commit != "" {
+				assert.Equal(t, tc.commit, info.Commit)
+				assert.Equal(t, tc.buildTime, info.BuildTime)
+			}
+			assert.Positive(t, int64(info.Uptime))
+		})
+	}
+}
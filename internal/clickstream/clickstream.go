@@ -0,0 +1,171 @@
+// Package clickstream collects click events off the redirect path and hands them to a ClickSink
+// in batches, so recording a click never waits on the place it is sent to.
+package clickstream
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"go.uber.org/zap"
+)
+
+type (
+	// ClickSink stores or forwards batches of clicks. Write is never called concurrently by a
+	// Buffer.
+	ClickSink interface {
+		Write(ctx context.Context, clicks []urlshortener.Click) error
+	}
+
+	// WriteError is returned when a sink wrote only some of the clicks. Failed are the ones worth
+	// trying again.
+	WriteError struct {
+		Failed []urlshortener.Click
+		Err    error
+	}
+
+	// Buffer keeps up to Size clicks in memory and writes them to Sink BatchSize at a time. Clicks
+	// recorded while the buffer is full are dropped, so a slow or failing sink costs analytics
+	// rather than redirect latency.
+	Buffer struct {
+		Sink   ClickSink
+		Logger *zap.Logger
+		// Size bounds the clicks held in memory, DefaultSize when zero.
+		Size int
+		// BatchSize is the most clicks handed to Sink at once, DefaultBatchSize when zero. Reaching
+		// it starts a flush in the background.
+		BatchSize int
+		// Salt keys the hash clients' IP addresses are stored as. A random salt is used when empty,
+		// so hashes differ between instances.
+		Salt string
+
+		mu      sync.Mutex
+		clicks  []urlshortener.Click
+		dropped int
+		queued  bool
+		// flushing serializes writes to Sink, keeping batches in order
+		flushing  sync.Mutex
+		saltOnce  sync.Once
+		saltBytes []byte
+	}
+)
+
+const (
+	DefaultSize      = 10000
+	DefaultBatchSize = 100
+)
+
+// Record adds a click to the buffer. The client's IP address is only kept as a salted hash.
+func (b *Buffer) Record(ctx context.Context, click urlshortener.Click, clientIP string) {
+	if clientIP != "" {
+		click.IPHash = b.HashIP(clientIP)
+	}
+
+	b.mu.Lock()
+	if len(b.clicks) >= b.size() {
+		b.dropped++
+		b.mu.Unlock()
+		return
+	}
+	b.clicks = append(b.clicks, click)
+	flush := len(b.clicks) >= b.batchSize() && !b.queued
+	b.queued = b.queued || flush
+	b.mu.Unlock()
+
+	if flush {
+		// the batch outlives the request that filled it
+		go func() { _ = b.Flush(context.WithoutCancel(ctx)) }()
+	}
+}
+
+// Flush writes every buffered click to Sink. Clicks the sink could not write are kept for the next
+// flush while there is room for them, and the last error is returned.
+func (b *Buffer) Flush(ctx context.Context) error {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+
+	b.mu.Lock()
+	clicks, dropped := b.clicks, b.dropped
+	b.clicks, b.dropped, b.queued = nil, 0, false
+	b.mu.Unlock()
+
+	if dropped > 0 {
+		b.Logger.Warn("click buffer full, dropped clicks", zap.Int(logkey.Dropped, dropped))
+	}
+
+	var failed []urlshortener.Click
+	var lastErr error
+	for start := 0; start < len(clicks); start += b.batchSize() {
+		batch := clicks[start:min(start+b.batchSize(), len(clicks))]
+		if err := b.Sink.Write(ctx, batch); err != nil {
+			var writeErr *WriteError
+			if !errors.As(err, &writeErr) {
+				writeErr = &WriteError{Failed: batch, Err: err}
+			}
+			b.Logger.Error("failed to write clicks", zap.Int(logkey.Clicks, len(writeErr.Failed)), zap.Error(err))
+			failed = append(failed, writeErr.Failed...)
+			lastErr = err
+		}
+	}
+	if len(failed) > 0 {
+		b.requeue(failed)
+	}
+	return lastErr
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("%d clicks not written: %v", len(e.Failed), e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// HashIP returns the hex HMAC-SHA256 of ip keyed with Salt, truncated to 128 bits.
+func (b *Buffer) HashIP(ip string) string {
+	b.saltOnce.Do(func() {
+		b.saltBytes = []byte(b.Salt)
+		if len(b.saltBytes) == 0 {
+			b.saltBytes = make([]byte, 32)
+			if _, err := rand.Read(b.saltBytes); err != nil {
+				panic(err)
+			}
+		}
+	})
+	mac := hmac.New(sha256.New, b.saltBytes)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// requeue puts failed clicks back in front of the ones recorded since, dropping what does not fit.
+func (b *Buffer) requeue(failed []urlshortener.Click) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	room := max(b.size()-len(b.clicks), 0)
+	if len(failed) > room {
+		b.dropped += len(failed) - room
+		failed = failed[len(failed)-room:]
+	}
+	b.clicks = append(failed, b.clicks...)
+}
+
+func (b *Buffer) size() int {
+	if b.Size > 0 {
+		return b.Size
+	}
+	return DefaultSize
+}
+
+func (b *Buffer) batchSize() int {
+	if b.BatchSize > 0 {
+		return b.BatchSize
+	}
+	return DefaultBatchSize
+}
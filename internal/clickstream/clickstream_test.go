@@ -0,0 +1,123 @@
+package clickstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordingSink keeps the batches written to it, failing the first fail calls with err.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]urlshortener.Click
+	fail    int
+	err     error
+}
+
+func (s *recordingSink) Write(_ context.Context, clicks []urlshortener.Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		return s.err
+	}
+	s.batches = append(s.batches, append([]urlshortener.Click(nil), clicks...))
+	return nil
+}
+
+func (s *recordingSink) codes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var codes []string
+	for _, batch := range s.batches {
+		for _, click := range batch {
+			codes = append(codes, click.ShortURL)
+		}
+	}
+	return codes
+}
+
+func Test_Buffer_Flush(t *testing.T) {
+	tests := map[string]struct {
+		size            int
+		record          []string
+		fail            int
+		err             error
+		expectedError   bool
+		expectedCodes   []string
+		expectedPending int
+	}{
+		"Happy Path": {
+			record:        []string{"a", "b", "c"},
+			expectedCodes: []string{"a", "b", "c"},
+		},
+		"Happy Path nothing recorded": {},
+		"Sad Path buffer full": {
+			size:          2,
+			record:        []string{"a", "b", "c"},
+			expectedCodes: []string{"a", "b"},
+		},
+		"Sad Path sink error": {
+			record:          []string{"a", "b", "c"},
+			fail:            1,
+			err:             errors.New("unavailable"),
+			expectedError:   true,
+			expectedPending: 3,
+		},
+		"Sad Path partial write": {
+			record:          []string{"a", "b", "c"},
+			fail:            1,
+			err:             &WriteError{Failed: []urlshortener.Click{{ShortURL: "b"}}, Err: errors.New("throttled")},
+			expectedError:   true,
+			expectedPending: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sink := &recordingSink{fail: tc.fail, err: tc.err}
+			b := &Buffer{Sink: sink, Logger: zaptest.NewLogger(t), Size: tc.size}
+			for _, code := range tc.record {
+				b.Record(context.Background(), urlshortener.Click{ShortURL: code}, "")
+			}
+
+			err := b.Flush(context.Background())
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedCodes, sink.codes())
+			assert.Len(t, b.clicks, tc.expectedPending)
+		})
+	}
+}
+
+func Test_Buffer_Record(t *testing.T) {
+	sink := &recordingSink{}
+	b := &Buffer{Sink: sink, Logger: zaptest.NewLogger(t), BatchSize: 2, Salt: "pepper"}
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	b.Record(context.Background(), urlshortener.Click{ShortURL: "a", ClickedAt: now}, "203.0.113.7")
+	b.Record(context.Background(), urlshortener.Click{ShortURL: "b", ClickedAt: now}, "")
+	assert.Eventually(t, func() bool { return len(sink.codes()) == 2 }, time.Second, time.Millisecond)
+	b.Record(context.Background(), urlshortener.Click{ShortURL: "c", ClickedAt: now}, "")
+	assert.NoError(t, b.Flush(context.Background()))
+
+	assert.Equal(t, []string{"a", "b", "c"}, sink.codes())
+	assert.Equal(t, b.HashIP("203.0.113.7"), sink.batches[0][0].IPHash)
+	assert.Len(t, sink.batches[0][0].IPHash, 32)
+	assert.NotContains(t, sink.batches[0][0].IPHash, "203.0.113.7")
+	assert.Empty(t, sink.batches[0][1].IPHash)
+}
+
+func Test_Buffer_HashIP(t *testing.T) {
+	salted := &Buffer{Salt: "pepper"}
+	assert.Equal(t, salted.HashIP("203.0.113.7"), (&Buffer{Salt: "pepper"}).HashIP("203.0.113.7"))
+	assert.NotEqual(t, salted.HashIP("203.0.113.7"), salted.HashIP("203.0.113.8"))
+	assert.NotEqual(t, salted.HashIP("203.0.113.7"), (&Buffer{Salt: "salt"}).HashIP("203.0.113.7"))
+	assert.NotEqual(t, (&Buffer{}).HashIP("203.0.113.7"), (&Buffer{}).HashIP("203.0.113.7"))
+}
@@ -0,0 +1,74 @@
+package clickstream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+)
+
+type (
+	// ClickDB is implemented by persistence.UrlDB.
+	ClickDB interface {
+		PutClicks(ctx context.Context, items []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error)
+	}
+
+	// DynamoDBSink stores every click as an item of the click table, whose stream feeds the
+	// analytics rollups. Items expire after Retention.
+	DynamoDBSink struct {
+		DB ClickDB
+		// Retention is how long raw clicks are kept, DefaultRetention when zero.
+		Retention time.Duration
+	}
+)
+
+const DefaultRetention = 90 * 24 * time.Hour
+
+func (s *DynamoDBSink) Write(ctx context.Context, clicks []urlshortener.Click) error {
+	retention := s.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	items := make([]map[string]types.AttributeValue, len(clicks))
+	byID := make(map[string]urlshortener.Click, len(clicks))
+	for i, click := range clicks {
+		item, err := attributevalue.MarshalMap(click)
+		if err != nil {
+			return err
+		}
+		id := newClickID()
+		item[urlDB.ClickID] = &types.AttributeValueMemberS{Value: id}
+		item[urlDB.ExpiresAt] = &types.AttributeValueMemberN{Value: strconv.FormatInt(click.ClickedAt.Add(retention).Unix(), 10)}
+		items[i], byID[id] = item, click
+	}
+
+	unprocessed, err := s.DB.PutClicks(ctx, items)
+	if len(unprocessed) == 0 {
+		return err
+	}
+	failed := make([]urlshortener.Click, 0, len(unprocessed))
+	for _, item := range unprocessed {
+		if id, ok := item[urlDB.ClickID].(*types.AttributeValueMemberS); ok {
+			failed = append(failed, byID[id.Value])
+		}
+	}
+	if err == nil {
+		err = errors.New("throughput exceeded")
+	}
+	return &WriteError{Failed: failed, Err: err}
+}
+
+func newClickID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
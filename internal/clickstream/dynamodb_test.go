@@ -0,0 +1,80 @@
+package clickstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClickDB struct {
+	mock.Mock
+}
+
+func (m *MockClickDB) PutClicks(_ context.Context, items []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	args := m.Called(items)
+	unprocessed, _ := args.Get(0).(func([]map[string]types.AttributeValue) []map[string]types.AttributeValue)
+	if unprocessed == nil {
+		return nil, args.Error(1)
+	}
+	return unprocessed(items), args.Error(1)
+}
+
+func Test_DynamoDBSink(t *testing.T) {
+	tests := map[string]struct {
+		unprocessed    func([]map[string]types.AttributeValue) []map[string]types.AttributeValue
+		putError       error
+		expectedFailed []string
+		expectedError  bool
+	}{
+		"Happy Path": {},
+		"Sad Path unprocessed": {
+			unprocessed: func(items []map[string]types.AttributeValue) []map[string]types.AttributeValue {
+				return items[1:]
+			},
+			expectedFailed: []string{"b"},
+			expectedError:  true,
+		},
+		"Sad Path error": {
+			unprocessed: func(items []map[string]types.AttributeValue) []map[string]types.AttributeValue {
+				return items
+			},
+			putError:       errors.New("error"),
+			expectedFailed: []string{"a", "b"},
+			expectedError:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockClickDB)
+			m.On("PutClicks", mock.MatchedBy(func(items []map[string]types.AttributeValue) bool {
+				if len(items) != 2 {
+					return false
+				}
+				var stored struct {
+					ClickID   string `dynamodbav:"click_id"`
+					ShortURL  string `dynamodbav:"short_url"`
+					IPHash    string `dynamodbav:"ip_hash"`
+					ExpiresAt int64  `dynamodbav:"expires_at"`
+				}
+				err := attributevalue.UnmarshalMap(items[0], &stored)
+				return err == nil && len(stored.ClickID) == 32 && stored.ShortURL == "a" && stored.IPHash == "f00d" &&
+					stored.ExpiresAt == clicks[0].ClickedAt.Add(DefaultRetention).Unix() &&
+					items[1][urlDB.ClickID].(*types.AttributeValueMemberS).Value != stored.ClickID
+			})).Return(tc.unprocessed, tc.putError)
+			sink := &DynamoDBSink{DB: m}
+
+			err := sink.Write(context.Background(), clicks)
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedFailed, failedCodes(err))
+			m.AssertExpectations(t)
+		})
+	}
+}
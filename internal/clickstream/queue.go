@@ -0,0 +1,120 @@
+package clickstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesisTypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+)
+
+type (
+	// SQSSink sends every click as a message to an SQS queue, or any queue speaking the SQS
+	// protocol such as ElasticMQ.
+	SQSSink struct {
+		QueueURL string
+		API      SQSProvider
+	}
+
+	// KinesisSink puts every click as a record on a Kinesis data stream, partitioned by short URL,
+	// or on any stream speaking the Kinesis protocol.
+	KinesisSink struct {
+		StreamName string
+		API        KinesisProvider
+	}
+
+	// SQSProvider is implemented by *sqs.Client.
+	SQSProvider interface {
+		SendMessageBatch(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+	}
+
+	// KinesisProvider is implemented by *kinesis.Client.
+	KinesisProvider interface {
+		PutRecords(context.Context, *kinesis.PutRecordsInput, ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+	}
+)
+
+const (
+	// SQSBatchSize and KinesisBatchSize are the most entries the APIs take in one call.
+	SQSBatchSize     = 10
+	KinesisBatchSize = 500
+	// DefaultAPITimeout bounds a single call to a queue API.
+	DefaultAPITimeout = 5 * time.Second
+)
+
+func (s *SQSSink) Write(ctx context.Context, clicks []urlshortener.Click) error {
+	var failed []urlshortener.Click
+	var lastErr error
+	for start := 0; start < len(clicks); start += SQSBatchSize {
+		batch := clicks[start:min(start+SQSBatchSize, len(clicks))]
+		entries := make([]sqsTypes.SendMessageBatchRequestEntry, len(batch))
+		for i, click := range batch {
+			b, err := json.Marshal(click)
+			if err != nil {
+				return err
+			}
+			entries[i] = sqsTypes.SendMessageBatchRequestEntry{Id: aws.String(strconv.Itoa(i)), MessageBody: aws.String(string(b))}
+		}
+
+		out, err := s.API.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: aws.String(s.QueueURL), Entries: entries})
+		if err != nil {
+			failed, lastErr = append(failed, batch...), err
+			continue
+		}
+		for _, f := range out.Failed {
+			i, err := strconv.Atoi(aws.ToString(f.Id))
+			if err != nil || i < 0 || i >= len(batch) {
+				continue
+			}
+			failed = append(failed, batch[i])
+			lastErr = fmt.Errorf("%s: %s", aws.ToString(f.Code), aws.ToString(f.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return &WriteError{Failed: failed, Err: lastErr}
+	}
+	return nil
+}
+
+func (s *KinesisSink) Write(ctx context.Context, clicks []urlshortener.Click) error {
+	var failed []urlshortener.Click
+	var lastErr error
+	for start := 0; start < len(clicks); start += KinesisBatchSize {
+		batch := clicks[start:min(start+KinesisBatchSize, len(clicks))]
+		records := make([]kinesisTypes.PutRecordsRequestEntry, len(batch))
+		for i, click := range batch {
+			b, err := json.Marshal(click)
+			if err != nil {
+				return err
+			}
+			records[i] = kinesisTypes.PutRecordsRequestEntry{Data: b, PartitionKey: aws.String(click.ShortURL)}
+		}
+
+		out, err := s.API.PutRecords(ctx, &kinesis.PutRecordsInput{StreamName: aws.String(s.StreamName), Records: records})
+		if err != nil {
+			failed, lastErr = append(failed, batch...), err
+			continue
+		}
+		if aws.ToInt32(out.FailedRecordCount) == 0 {
+			continue
+		}
+		// records are answered in the order they were sent
+		for i, r := range out.Records {
+			if r.ErrorCode != nil && i < len(batch) {
+				failed = append(failed, batch[i])
+				lastErr = fmt.Errorf("%s: %s", aws.ToString(r.ErrorCode), aws.ToString(r.ErrorMessage))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return &WriteError{Failed: failed, Err: lastErr}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package clickstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig answers the calls of clients built from the returned config with handler,
+// asserting they are signed and sent to target.
+func newTestConfig(t *testing.T, target string, handler func(body map[string]any) (int, string)) aws.Config {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, target, r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		status, response := handler(body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+	return aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:   srv.Client(),
+		BaseEndpoint: aws.String(srv.URL),
+		// failed calls are kept for the next flush rather than retried
+		RetryMaxAttempts: 1,
+	}
+}
+
+func Test_SQSSink(t *testing.T) {
+	tests := map[string]struct {
+		status         int
+		response       string
+		expectedFailed []string
+		expectedError  bool
+	}{
+		"Happy Path": {
+			status:   http.StatusOK,
+			response: `{"Successful": [{"Id": "0"}, {"Id": "1"}]}`,
+		},
+		"Sad Path partial failure": {
+			status:         http.StatusOK,
+			response:       `{"Successful": [{"Id": "0"}], "Failed": [{"Id": "1", "Code": "InternalError", "Message": "try again"}]}`,
+			expectedFailed: []string{"b"},
+			expectedError:  true,
+		},
+		"Sad Path error": {
+			status:         http.StatusBadRequest,
+			response:       `{"__type": "com.amazonaws.sqs#QueueDoesNotExist"}`,
+			expectedFailed: []string{"a", "b"},
+			expectedError:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := newTestConfig(t, "AmazonSQS.SendMessageBatch", func(body map[string]any) (int, string) {
+				assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123/clicks", body["QueueUrl"])
+				entries := body["Entries"].([]any)
+				assert.Len(t, entries, 2)
+				var click urlshortener.Click
+				assert.NoError(t, json.Unmarshal([]byte(entries[1].(map[string]any)["MessageBody"].(string)), &click))
+				assert.Equal(t, clicks[1], click)
+				return tc.status, tc.response
+			})
+			sink := &SQSSink{QueueURL: "https://sqs.us-east-1.amazonaws.com/123/clicks", API: sqs.NewFromConfig(cfg)}
+
+			err := sink.Write(context.Background(), clicks)
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedFailed, failedCodes(err))
+		})
+	}
+}
+
+func Test_KinesisSink(t *testing.T) {
+	tests := map[string]struct {
+		response       string
+		expectedFailed []string
+		expectedError  bool
+	}{
+		"Happy Path": {
+			response: `{"FailedRecordCount": 0, "Records": [{"SequenceNumber": "1"}, {"SequenceNumber": "2"}]}`,
+		},
+		"Sad Path partial failure": {
+			response:       `{"FailedRecordCount": 1, "Records": [{"ErrorCode": "ProvisionedThroughputExceededException", "ErrorMessage": "slow down"}, {"SequenceNumber": "2"}]}`,
+			expectedFailed: []string{"a"},
+			expectedError:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := newTestConfig(t, "Kinesis_20131202.PutRecords", func(body map[string]any) (int, string) {
+				assert.Equal(t, "clicks", body["StreamName"])
+				records := body["Records"].([]any)
+				assert.Len(t, records, 2)
+				assert.Equal(t, "a", records[0].(map[string]any)["PartitionKey"])
+				return http.StatusOK, tc.response
+			})
+			sink := &KinesisSink{StreamName: "clicks", API: kinesis.NewFromConfig(cfg)}
+
+			err := sink.Write(context.Background(), clicks)
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedFailed, failedCodes(err))
+		})
+	}
+}
+
+func failedCodes(err error) []string {
+	writeErr, ok := err.(*WriteError)
+	if !ok {
+		return nil
+	}
+	var codes []string
+	for _, click := range writeErr.Failed {
+		codes = append(codes, click.ShortURL)
+	}
+	return codes
+}
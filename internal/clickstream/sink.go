@@ -0,0 +1,124 @@
+package clickstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+)
+
+type (
+	// WriterSink writes clicks to W as JSON lines, e.g. to stdout where CloudWatch Logs picks
+	// them up.
+	WriterSink struct {
+		W  io.Writer
+		mu sync.Mutex
+	}
+
+	// FileSink appends clicks to the JSON lines file at Path, creating it when missing.
+	FileSink struct {
+		Path string
+		mu   sync.Mutex
+	}
+)
+
+const (
+	// SinkEnv selects the sink clicks are written to: stdout, file:<path>, dynamodb,
+	// sqs:<queue URL> or kinesis:<stream name>. Clicks are not collected when it is empty.
+	SinkEnv = "CLICK_SINK"
+	// SinkEndpointEnv points the sqs and kinesis sinks at a compatible API instead of AWS.
+	SinkEndpointEnv = "CLICK_SINK_ENDPOINT"
+	SaltEnv         = "CLICK_IP_SALT"
+)
+
+// SinkFromEnv configures the sink named by CLICK_SINK, nil when it is empty. The dynamodb sink
+// writes to the click table of db.
+func SinkFromEnv(ctx context.Context, db ClickDB) (ClickSink, error) {
+	v := os.Getenv(SinkEnv)
+	switch v {
+	case "":
+		return nil, nil
+	case "stdout":
+		return &WriterSink{W: os.Stdout}, nil
+	case "dynamodb":
+		return &DynamoDBSink{DB: db}, nil
+	}
+	kind, target, ok := strings.Cut(v, ":")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("%s: expected stdout, file:<path>, dynamodb, sqs:<queue URL> or kinesis:<stream name>, got %q", SinkEnv, v)
+	}
+	if kind == "file" {
+		return &FileSink{Path: target}, nil
+	}
+	if kind != "sqs" && kind != "kinesis" {
+		return nil, fmt.Errorf("%s: unknown sink %q", SinkEnv, kind)
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(urlDB.DefaultRegion))
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTPClient = awshttp.NewBuildableClient().WithTimeout(DefaultAPITimeout)
+	var endpoint *string
+	if v := os.Getenv(SinkEndpointEnv); v != "" {
+		endpoint = aws.String(v)
+	}
+	if kind == "sqs" {
+		api := sqs.NewFromConfig(cfg, func(o *sqs.Options) { o.BaseEndpoint = endpoint })
+		return &SQSSink{QueueURL: target, API: api}, nil
+	}
+	api := kinesis.NewFromConfig(cfg, func(o *kinesis.Options) { o.BaseEndpoint = endpoint })
+	return &KinesisSink{StreamName: target, API: api}, nil
+}
+
+func (s *WriterSink) Write(_ context.Context, clicks []urlshortener.Click) error {
+	b, err := encodeLines(clicks)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.W.Write(b)
+	return err
+}
+
+func (s *FileSink) Write(_ context.Context, clicks []urlshortener.Click) error {
+	b, err := encodeLines(clicks)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encodeLines(clicks []urlshortener.Click) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, click := range clicks {
+		if err := enc.Encode(click); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package clickstream
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/stretchr/testify/assert"
+)
+
+var clicks = []urlshortener.Click{
+	{ShortURL: "a", Destination: "http://www.example.com", IPHash: "f00d", ClickedAt: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)},
+	{ShortURL: "b", Destination: "http://b.example.com", Referrer: "https://news.example.com/", ClickedAt: time.Date(2025, 3, 1, 9, 0, 1, 0, time.UTC)},
+}
+
+const clickLines = `{"short_url":"a","destination":"http://www.example.com","ip_hash":"f00d","clicked_at":"2025-03-01T09:00:00Z"}
+{"short_url":"b","destination":"http://b.example.com","referrer":"https://news.example.com/","clicked_at":"2025-03-01T09:00:01Z"}
+`
+
+func Test_WriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &WriterSink{W: &buf}
+
+	assert.NoError(t, sink.Write(context.Background(), clicks))
+	assert.Equal(t, clickLines, buf.String())
+}
+
+func Test_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clicks.jsonl")
+	sink := &FileSink{Path: path}
+
+	assert.NoError(t, sink.Write(context.Background(), clicks[:1]))
+	assert.NoError(t, sink.Write(context.Background(), clicks[1:]))
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, clickLines, string(b))
+
+	assert.Error(t, (&FileSink{Path: t.TempDir()}).Write(context.Background(), clicks))
+}
+
+func Test_SinkFromEnv(t *testing.T) {
+	tests := map[string]struct {
+		value         string
+		expectedSink  ClickSink
+		expectedError bool
+	}{
+		"Happy Path disabled": {},
+		"Happy Path stdout": {
+			value:        "stdout",
+			expectedSink: &WriterSink{W: os.Stdout},
+		},
+		"Happy Path dynamodb": {
+			value:        "dynamodb",
+			expectedSink: &DynamoDBSink{},
+		},
+		"Happy Path file": {
+			value:        "file:/tmp/clicks.jsonl",
+			expectedSink: &FileSink{Path: "/tmp/clicks.jsonl"},
+		},
+		"Sad Path missing target": {
+			value:         "sqs:",
+			expectedError: true,
+		},
+		"Sad Path unknown sink": {
+			value:         "kafka:clicks",
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(SinkEnv, tc.value)
+
+			sink, err := SinkFromEnv(context.Background(), nil)
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedSink, sink)
+		})
+	}
+}
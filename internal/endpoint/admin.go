@@ -0,0 +1,31 @@
+package endpoint
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const (
+	AdminTokenEnv = "ADMIN_TOKEN"
+	// AdminError is returned by endpoints that change links when the admin token is missing.
+	AdminError = "admin token required"
+)
+
+// admin reports whether r carries the admin token as a bearer token. Nobody is an admin when no
+// token is configured.
+func (h *Handler) admin(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.AdminToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) == 1
+}
+
+// requireAdmin answers 401 and returns false unless r carries the admin token.
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.admin(r) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, AdminError, http.StatusUnauthorized)
+	return false
+}
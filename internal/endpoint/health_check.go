@@ -3,37 +3,52 @@ package endpoint
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/buildinfo"
 	"go.uber.org/zap"
 )
 
 const (
 	HealthCheckEndpoint = "/health"
 	ApiVersion          = "v1"
-	Environment         = "local" // TODO: update this to pull AWS environment
 	ErrorResp           = "error creating health check response"
 )
 
 func (h *Handler) HealthCheckHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		h.Logger.Info("retrieving details for health check")
+		logger := h.requestLogger(r)
+		logger.Info("retrieving details for health check")
+		info := buildinfo.Get()
 		body := &HealthCheck{
-			Version: ApiVersion,
-			Region:  Environment,
+			Version:         ApiVersion,
+			Region:          info.Region,
+			Commit:          info.Commit,
+			BuildTime:       info.BuildTime,
+			GoVersion:       info.GoVersion,
+			FunctionName:    info.FunctionName,
+			FunctionVersion: info.FunctionVersion,
+			Uptime:          info.Uptime.Round(time.Second).String(),
 		}
-		h.Logger.Info("successfully created health check response")
+		logger.Info("successfully created health check response")
 		b, _ := json.Marshal(body)
 		w.Header().Set("Content-Type", "application/json")
 		_, err := w.Write(b)
 		if err != nil {
-			h.Logger.Error("failed to write health check response", zap.String(logkey.Error, err.Error()))
+			logger.Error("failed to write health check response", zap.String(logkey.Error, err.Error()))
 			http.Error(w, ErrorResp, http.StatusInternalServerError)
 		}
 	}
 }
 
 type HealthCheck struct {
-	Region  string `json:"region"`
-	Version string `json:"version"`
+	Region          string `json:"region"`
+	Version         string `json:"version"`
+	Commit          string `json:"commit"`
+	BuildTime       string `json:"build_time"`
+	GoVersion       string `json:"go_version"`
+	FunctionName    string `json:"function_name,omitempty"`
+	FunctionVersion string `json:"function_version,omitempty"`
+	Uptime          string `json:"uptime"`
 }
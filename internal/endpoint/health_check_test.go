@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,10 +27,9 @@ func (e *errorResponseWriter) Write(b []byte) (int, error) {
 }
 
 func Test_HealthCheckHandelr(t *testing.T) {
-	wantResp := HealthCheck{
-		Region:  local,
-		Version: version,
-	}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "urlShortenerLambda")
 
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -51,7 +51,13 @@ func Test_HealthCheckHandelr(t *testing.T) {
 	bytes, _ := io.ReadAll(rr.Body)
 	_ = json.Unmarshal(bytes, &gotResp)
 
-	assert.EqualValues(t, wantResp, gotResp)
+	assert.Equal(t, local, gotResp.Region)
+	assert.Equal(t, version, gotResp.Version)
+	assert.Equal(t, "urlShortenerLambda", gotResp.FunctionName)
+	assert.Equal(t, runtime.Version(), gotResp.GoVersion)
+	assert.NotEmpty(t, gotResp.Commit)
+	assert.NotEmpty(t, gotResp.BuildTime)
+	assert.NotEmpty(t, gotResp.Uptime)
 }
 
 func Test_HealthCheckHandler_ErrorCase(t *testing.T) {
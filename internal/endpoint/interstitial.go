@@ -0,0 +1,107 @@
+package endpoint
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+)
+
+// InterstitialMode decides which redirects show a warning page before the destination.
+type InterstitialMode string
+
+const (
+	// InterstitialFlagged warns for links flagged by a reputation check and links that ask for it,
+	// the behavior of the empty mode.
+	InterstitialFlagged InterstitialMode = "flagged"
+	// InterstitialAll warns before every redirect.
+	InterstitialAll InterstitialMode = "all"
+	// InterstitialOff never warns.
+	InterstitialOff InterstitialMode = "off"
+
+	InterstitialEnv          = "INTERSTITIAL"
+	InterstitialSkipTokenEnv = "INTERSTITIAL_SKIP_TOKEN"
+	// SkipInterstitialParam skips the warning when its value is the configured skip token.
+	SkipInterstitialParam = "skip_interstitial"
+)
+
+var interstitialPage = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>You are leaving for {{.Host}}</title>
+</head>
+<body>
+<h1>You are leaving for {{.Host}}</h1>
+{{if .Threat}}<p><strong>This link was flagged as {{.Threat}}.</strong> Only continue if you trust where it came from.</p>
+{{else}}<p>This link takes you to a site we do not control.</p>
+{{end}}<p>{{.Destination}}</p>
+<p><a href="{{.Destination}}" rel="noreferrer noopener">Continue</a></p>
+</body>
+</html>
+`))
+
+// ParseInterstitialMode validates the INTERSTITIAL setting.
+func ParseInterstitialMode(v string) (InterstitialMode, error) {
+	switch mode := InterstitialMode(v); mode {
+	case "", InterstitialFlagged, InterstitialAll, InterstitialOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("interstitial mode must be %s, %s or %s", InterstitialFlagged, InterstitialAll, InterstitialOff)
+	}
+}
+
+func (h *Handler) showInterstitial(link *urlshortener.Link) bool {
+	switch h.Interstitials {
+	case InterstitialOff:
+		return false
+	case InterstitialAll:
+		return true
+	default:
+		return link.Interstitial || link.Flag != nil
+	}
+}
+
+// skipInterstitial reports whether r carries the skip token and removes the parameter, so it is
+// never forwarded to the destination.
+func (h *Handler) skipInterstitial(r *http.Request) bool {
+	query := r.URL.Query()
+	if !query.Has(SkipInterstitialParam) {
+		return false
+	}
+	token := query.Get(SkipInterstitialParam)
+	query.Del(SkipInterstitialParam)
+	r.URL.RawQuery = query.Encode()
+	return h.InterstitialSkipToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.InterstitialSkipToken)) == 1
+}
+
+// writeInterstitial shows where the link leads with a link to continue there. It is served
+// instead of the redirect, so the click has been counted by then.
+func writeInterstitial(w http.ResponseWriter, link *urlshortener.Link, destination string) {
+	page := struct {
+		Host        string
+		Destination string
+		Threat      string
+	}{Host: destination, Destination: destination}
+	if u, err := url.Parse(destination); err == nil && u.Host != "" {
+		page.Host = u.Hostname()
+	}
+	if link.Flag != nil {
+		page.Threat = string(link.Flag.Verdict)
+		if link.Flag.Threat != "" {
+			page.Threat += " (" + link.Flag.Threat + ")"
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_ = interstitialPage.Execute(w, page)
+}
@@ -0,0 +1,138 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_RedirectHandler_Interstitial(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	plain := &urlshortener.Link{
+		ShortURL:    "b",
+		OriginalURL: "https://www.example.com/offer",
+		LinkOptions: urlshortener.LinkOptions{QueryPolicy: urlshortener.QueryAppend},
+	}
+	flagged := &urlshortener.Link{
+		ShortURL:    "b",
+		OriginalURL: "https://www.example.com/offer",
+		Flag: &urlshortener.Flag{Match: reputation.Match{
+			URL:     "https://www.example.com/offer",
+			Verdict: reputation.Suspicious,
+			Threat:  "UNWANTED_SOFTWARE",
+		}},
+		LinkOptions: urlshortener.LinkOptions{QueryPolicy: urlshortener.QueryAppend},
+	}
+	optedIn := &urlshortener.Link{
+		ShortURL:    "b",
+		OriginalURL: "https://www.example.com/offer",
+		LinkOptions: urlshortener.LinkOptions{Interstitial: true},
+	}
+
+	tests := map[string]struct {
+		link             *urlshortener.Link
+		mode             InterstitialMode
+		skipToken        string
+		query            string
+		expectedCode     int
+		expectedLocation string
+		expectedBody     []string
+	}{
+		"Flagged link": {
+			link:         flagged,
+			expectedCode: http.StatusOK,
+			expectedBody: []string{
+				"You are leaving for www.example.com",
+				"suspicious (UNWANTED_SOFTWARE)",
+				`href="https://www.example.com/offer"`,
+			},
+		},
+		"Link asking for it": {
+			link:         optedIn,
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"a site we do not control"},
+		},
+		"Plain link": {
+			link:             plain,
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://www.example.com/offer",
+		},
+		"Every link": {
+			link:         plain,
+			mode:         InterstitialAll,
+			expectedCode: http.StatusOK,
+		},
+		"Turned off": {
+			link:             flagged,
+			mode:             InterstitialOff,
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://www.example.com/offer",
+		},
+		"Skipped with token": {
+			link:             flagged,
+			skipToken:        "trusted",
+			query:            "?ref=api&skip_interstitial=trusted",
+			expectedCode:     http.StatusFound,
+			expectedLocation: "https://www.example.com/offer?ref=api",
+		},
+		"Wrong token": {
+			link:         flagged,
+			skipToken:    "trusted",
+			query:        "?ref=api&skip_interstitial=guess",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`href="https://www.example.com/offer?ref=api"`},
+		},
+		"No token configured": {
+			link:         flagged,
+			query:        "?skip_interstitial=",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`href="https://www.example.com/offer"`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("ResolveLink", mock.Anything, "b").Return(tc.link, nil)
+			handler := &Handler{
+				Logger:                logger,
+				UrlShortenerProvider:  mockProvider,
+				Interstitials:         tc.mode,
+				InterstitialSkipToken: tc.skipToken,
+			}
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/b"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+				assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+			}
+			for _, body := range tc.expectedBody {
+				assert.Contains(t, w.Body.String(), body)
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ParseInterstitialMode(t *testing.T) {
+	for _, v := range []string{"", "flagged", "all", "off"} {
+		mode, err := ParseInterstitialMode(v)
+		assert.NoError(t, err)
+		assert.Equal(t, InterstitialMode(v), mode)
+	}
+	_, err := ParseInterstitialMode("sometimes")
+	assert.Error(t, err)
+}
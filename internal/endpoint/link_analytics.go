@@ -0,0 +1,134 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/analytics"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// AnalyticsEndpoint takes precedence over PrefixEndpoint, so prefix links cannot forward the
+	// path /analytics.
+	AnalyticsEndpoint    = RedirectEndpoint + "/analytics"
+	FromParam            = "from"
+	ToParam              = "to"
+	IntervalParam        = "interval"
+	IncludeBotsParam     = "include_bots"
+	AnalyticsError       = "failed to retrieve link analytics"
+	AnalyticsUnavailable = "link analytics are not enabled"
+)
+
+// AnalyticsReporter is implemented by analytics.Reporter.
+type AnalyticsReporter interface {
+	Report(ctx context.Context, shortURL string, q analytics.Query) (*analytics.Report, error)
+}
+
+// LinkAnalyticsHandler returns the clicks of a link over time, in hourly, daily or weekly buckets
+// broken down by referrer domain, device class, browser and country. Bots are only counted with
+// include_bots=true. Like link updates it requires the admin token, the breakdowns tell who
+// follows a link and from where.
+func (h *Handler) LinkAnalyticsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+		shortUrl := chi.URLParam(r, ShortUrlParam)
+		if !h.requireAdmin(w, r) {
+			logger.Info("rejected link analytics without admin token", zap.String(logkey.ShortenedURL, shortUrl))
+			return
+		}
+
+		if h.Analytics == nil {
+			http.Error(w, AnalyticsUnavailable, http.StatusNotImplemented)
+			return
+		}
+
+		query, err := ParseAnalyticsQuery(r.URL.Query())
+		if err != nil {
+			logger.Info("invalid analytics query", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_, err = h.UrlShortenerProvider.GetLink(r.Context(), shortUrl)
+		if errors.Is(err, urlshortener.ErrLinkNotFound) {
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to retrieve link", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, AnalyticsError, http.StatusInternalServerError)
+			return
+		}
+
+		report, err := h.Analytics.Report(r.Context(), shortUrl, query)
+		if errors.Is(err, analytics.ErrInvalidQuery) {
+			logger.Info("rejected analytics query", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to retrieve link analytics", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, AnalyticsError, http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, logger, report)
+	}
+}
+
+// ParseAnalyticsQuery reads the query parameters of GET /{shortUrl}/analytics. Times are RFC 3339,
+// missing ones are left zero for the reporter to fill in.
+func ParseAnalyticsQuery(query url.Values) (analytics.Query, error) {
+	var q analytics.Query
+	if interval := query.Get(IntervalParam); interval != "" {
+		var err error
+		if q.Interval, err = analytics.ParseInterval(interval); err != nil {
+			return q, err
+		}
+	}
+	if includeBots := query.Get(IncludeBotsParam); includeBots != "" {
+		var err error
+		if q.IncludeBots, err = strconv.ParseBool(includeBots); err != nil {
+			return q, fmt.Errorf("%w: %s must be true or false", analytics.ErrInvalidQuery, IncludeBotsParam)
+		}
+	}
+	for param, t := range map[string]*time.Time{FromParam: &q.From, ToParam: &q.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return q, fmt.Errorf("%w: %s must be an RFC 3339 time", analytics.ErrInvalidQuery, param)
+		}
+		*t = parsed
+	}
+	return q, nil
+}
+
+// AnalyticsQueryValues is the inverse of ParseAnalyticsQuery.
+func AnalyticsQueryValues(q analytics.Query) url.Values {
+	query := url.Values{}
+	if q.Interval != "" {
+		query.Set(IntervalParam, string(q.Interval))
+	}
+	if q.IncludeBots {
+		query.Set(IncludeBotsParam, "true")
+	}
+	if !q.From.IsZero() {
+		query.Set(FromParam, q.From.Format(time.RFC3339Nano))
+	}
+	if !q.To.IsZero() {
+		query.Set(ToParam, q.To.Format(time.RFC3339Nano))
+	}
+	return query
+}
@@ -0,0 +1,174 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/analytics"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockAnalyticsReporter struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsReporter) Report(ctx context.Context, shortURL string, q analytics.Query) (*analytics.Report, error) {
+	args := m.Called(ctx, shortURL, q)
+	report, _ := args.Get(0).(*analytics.Report)
+	return report, args.Error(1)
+}
+
+func Test_LinkAnalyticsHandler(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	report := &analytics.Report{
+		ShortURL: "b",
+		Interval: analytics.Week,
+		From:     from,
+		To:       to,
+		Clicks:   3,
+		Buckets:  []analytics.BucketCount{{Start: from, Clicks: 3}},
+		Breakdowns: analytics.Breakdowns{
+			Devices: map[string]int64{"mobile": 3},
+		},
+	}
+
+	tests := map[string]struct {
+		query          string
+		withoutToken   bool
+		authorization  string
+		getLinkError   error
+		expectedQuery  *analytics.Query
+		report         *analytics.Report
+		reportError    error
+		expectedStatus int
+	}{
+		"Happy Path": {
+			query:          "?from=2025-03-01T00:00:00Z&to=2025-03-07T00:00:00Z&interval=week&include_bots=true",
+			expectedQuery:  &analytics.Query{From: from, To: to, Interval: analytics.Week, IncludeBots: true},
+			report:         report,
+			expectedStatus: http.StatusOK,
+		},
+		"Happy Path defaults": {
+			expectedQuery:  &analytics.Query{},
+			report:         report,
+			expectedStatus: http.StatusOK,
+		},
+		"Sad Path without token": {
+			withoutToken:   true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path wrong token": {
+			authorization:  "Bearer other",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path invalid interval": {
+			query:          "?interval=month",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid include_bots": {
+			query:          "?include_bots=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid time": {
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path not found": {
+			getLinkError:   urlshortener.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		"Sad Path rejected query": {
+			query:          "?interval=hour&from=2025-01-01T00:00:00Z",
+			expectedQuery:  &analytics.Query{From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Interval: analytics.Hour},
+			reportError:    fmt.Errorf("%w: too many buckets", analytics.ErrInvalidQuery),
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path error": {
+			expectedQuery:  &analytics.Query{},
+			reportError:    errors.New("error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.expectedQuery != nil || tc.getLinkError != nil {
+				mockProvider.On("GetLink", mock.Anything, "b").Return(&urlshortener.Link{ShortURL: "b"}, tc.getLinkError)
+			}
+			reporter := new(MockAnalyticsReporter)
+			if tc.expectedQuery != nil {
+				reporter.On("Report", mock.Anything, "b", *tc.expectedQuery).Return(tc.report, tc.reportError)
+			}
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+				Analytics:            reporter,
+				AdminToken:           "admin",
+			}
+
+			r := chi.NewRouter()
+			r.Get(AnalyticsEndpoint, handler.LinkAnalyticsHandler())
+			r.Get(PrefixEndpoint, handler.RedirectHandler())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/b/analytics"+tc.query, nil)
+			if !tc.withoutToken {
+				req.Header.Set("Authorization", "Bearer admin")
+			}
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+			if tc.expectedStatus == http.StatusOK {
+				var body analytics.Report
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, *report, body)
+			}
+			mockProvider.AssertExpectations(t)
+			reporter.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_LinkAnalyticsHandler_Disabled(t *testing.T) {
+	handler := &Handler{Logger: zaptest.NewLogger(t), UrlShortenerProvider: new(MockUrlShortenerProvider), AdminToken: "admin"}
+
+	w := httptest.NewRecorder()
+	r := chi.NewRouter()
+	r.Get(AnalyticsEndpoint, handler.LinkAnalyticsHandler())
+	req := httptest.NewRequest(http.MethodGet, "/b/analytics", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func Test_AnalyticsQueryValues(t *testing.T) {
+	q := analytics.Query{
+		From:        time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 3, 7, 12, 30, 0, 0, time.UTC),
+		Interval:    analytics.Hour,
+		IncludeBots: true,
+	}
+
+	parsed, err := ParseAnalyticsQuery(AnalyticsQueryValues(q))
+	assert.NoError(t, err)
+	assert.Equal(t, q, parsed)
+	assert.Empty(t, AnalyticsQueryValues(analytics.Query{}))
+}
@@ -0,0 +1,77 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	LinkVariantsEndpoint = LinkDetailEndpoint + "/variants"
+	LinkVariantsError    = "failed to retrieve link variants"
+)
+
+// LinkVariantsHandler returns the weighted destinations of a link with their click counts. The
+// URLs of password protected links are left out unless the request carries the admin token.
+func (h *Handler) LinkVariantsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+		shortUrl := chi.URLParam(r, ShortUrlParam)
+
+		link, err := h.UrlShortenerProvider.GetLink(r.Context(), shortUrl)
+		if errors.Is(err, urlshortener.ErrLinkNotFound) {
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to retrieve link", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, LinkVariantsError, http.StatusInternalServerError)
+			return
+		}
+
+		body := &LinkVariants{
+			ShortURL: link.ShortURL,
+			Sticky:   link.Sticky,
+			Variants: make([]Variant, len(link.Destinations)),
+		}
+		if !h.admin(r) {
+			link = link.Redacted(time.Now())
+		}
+		for i, d := range link.Destinations {
+			body.Variants[i] = Variant{
+				Index:  i,
+				URL:    d.URL,
+				Weight: d.Weight,
+				Clicks: link.VariantClicks[strconv.Itoa(i)],
+			}
+		}
+
+		b, _ := json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(b)
+		if err != nil {
+			logger.Error("failed to write link variants response", zap.String(logkey.Error, err.Error()))
+			http.Error(w, LinkVariantsError, http.StatusInternalServerError)
+		}
+	}
+}
+
+type LinkVariants struct {
+	ShortURL string    `json:"short_url"`
+	Sticky   bool      `json:"sticky"`
+	Variants []Variant `json:"variants"`
+}
+
+type Variant struct {
+	Index  int    `json:"index"`
+	URL    string `json:"url,omitempty"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
@@ -0,0 +1,108 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_LinkVariantsHandler(t *testing.T) {
+	tests := map[string]struct {
+		link           *urlshortener.Link
+		getLinkError   error
+		expectedStatus int
+		expectedBody   *LinkVariants
+	}{
+		"Happy Path": {
+			link: &urlshortener.Link{
+				ShortURL:      "b",
+				OriginalURL:   "http://www.example.com",
+				VariantClicks: map[string]int64{"0": 4, "1": 12},
+				LinkOptions: urlshortener.LinkOptions{
+					Destinations: []urlshortener.Destination{
+						{URL: "http://a.example.com", Weight: 1},
+						{URL: "http://b.example.com", Weight: 3},
+					},
+					Sticky: true,
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: &LinkVariants{
+				ShortURL: "b",
+				Sticky:   true,
+				Variants: []Variant{
+					{Index: 0, URL: "http://a.example.com", Weight: 1, Clicks: 4},
+					{Index: 1, URL: "http://b.example.com", Weight: 3, Clicks: 12},
+				},
+			},
+		},
+		"Happy Path protected link": {
+			link: &urlshortener.Link{
+				ShortURL:      "b",
+				OriginalURL:   "http://www.example.com",
+				VariantClicks: map[string]int64{"1": 2},
+				LinkOptions: urlshortener.LinkOptions{
+					Destinations: []urlshortener.Destination{
+						{URL: "http://a.example.com", Weight: 1},
+						{URL: "http://b.example.com", Weight: 3},
+					},
+					PasswordHash: "secret",
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: &LinkVariants{
+				ShortURL: "b",
+				Variants: []Variant{
+					{Index: 0, Weight: 1},
+					{Index: 1, Weight: 3, Clicks: 2},
+				},
+			},
+		},
+		"Happy Path single destination": {
+			link:           &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &LinkVariants{ShortURL: "b", Variants: []Variant{}},
+		},
+		"Sad Path not found": {
+			getLinkError:   urlshortener.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		"Sad Path error": {
+			getLinkError:   errors.New("error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("GetLink", mock.Anything, "b").Return(tc.link, tc.getLinkError)
+
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+			}
+
+			r := chi.NewRouter()
+			r.Get(LinkVariantsEndpoint, handler.LinkVariantsHandler())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/links/b/variants", nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != nil {
+				var got LinkVariants
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tc.expectedBody, &got)
+			}
+		})
+	}
+}
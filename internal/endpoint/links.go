@@ -0,0 +1,221 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	LinksEndpoint      = "/links"
+	LinkDetailEndpoint = LinksEndpoint + "/{" + ShortUrlParam + "}"
+	OwnerParam         = "owner"
+	TagParam           = "tag"
+	DomainParam        = "domain"
+	CreatedAfterParam  = "created_after"
+	CreatedBeforeParam = "created_before"
+	LimitParam         = "limit"
+	CursorParam        = "cursor"
+	OrderParam         = "order"
+	OrderAscending     = "asc"
+	OrderDescending    = "desc"
+	LinkError          = "failed to retrieve link"
+	LinksError         = "failed to list links"
+	UpdateLinkError    = "failed to update link"
+	InvalidUpdateError = "invalid link update request"
+)
+
+// LinkList is the response of GET /links. NextCursor is passed back as the cursor parameter to
+// read the next page and is empty on the last one.
+type LinkList struct {
+	Links      []*urlshortener.Link `json:"links"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// LinkHandler returns the stored record of a link, including disabled ones. The destinations of
+// confidential links are left out unless the request carries the admin token.
+func (h *Handler) LinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+		shortUrl := chi.URLParam(r, ShortUrlParam)
+
+		link, err := h.UrlShortenerProvider.GetLink(r.Context(), shortUrl)
+		if errors.Is(err, urlshortener.ErrLinkNotFound) {
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to retrieve link", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, LinkError, http.StatusInternalServerError)
+			return
+		}
+		if !h.admin(r) {
+			link = link.Redacted(time.Now())
+		}
+
+		writeJSON(w, logger, link)
+	}
+}
+
+// UpdateLinkHandler changes the title, tags or notes of a link. Fields missing from the body are
+// left as they are. Only requests carrying the admin token are accepted.
+func (h *Handler) UpdateLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+		shortUrl := chi.URLParam(r, ShortUrlParam)
+		if !h.requireAdmin(w, r) {
+			logger.Info("rejected link update without admin token", zap.String(logkey.ShortenedURL, shortUrl))
+			return
+		}
+
+		var update urlshortener.MetadataUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			logger.Info("invalid link update request", zap.Error(err))
+			http.Error(w, InvalidUpdateError, http.StatusBadRequest)
+			return
+		}
+
+		link, err := h.UrlShortenerProvider.UpdateMetadata(r.Context(), shortUrl, update)
+		switch {
+		case errors.Is(err, urlshortener.ErrLinkNotFound):
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		case errors.Is(err, urlshortener.ErrInvalidLink):
+			logger.Info("rejected link update request", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Error("failed to update link", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, UpdateLinkError, http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, logger, link)
+	}
+}
+
+// ListLinksHandler returns a page of links, newest first unless order=asc, narrowed down by the
+// filters in the query string. Only requests carrying the admin token are accepted, since the
+// listing would reveal every short code.
+func (h *Handler) ListLinksHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+		if !h.requireAdmin(w, r) {
+			logger.Info("rejected link listing without admin token")
+			return
+		}
+
+		filter, err := ParseLinkFilter(r.URL.Query())
+		if err != nil {
+			logger.Info("invalid link filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := h.UrlShortenerProvider.ListLinks(r.Context(), filter)
+		if errors.Is(err, urlshortener.ErrInvalidFilter) {
+			logger.Info("rejected link filter", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to list links", zap.Error(err))
+			http.Error(w, LinksError, http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, logger, &LinkList{Links: page.Links, NextCursor: page.NextCursor})
+	}
+}
+
+// ParseLinkFilter reads the query parameters of GET /links. Times are RFC 3339.
+func ParseLinkFilter(query url.Values) (urlshortener.LinkFilter, error) {
+	filter := urlshortener.LinkFilter{
+		Owner:  query.Get(OwnerParam),
+		Tag:    query.Get(TagParam),
+		Domain: query.Get(DomainParam),
+		Cursor: query.Get(CursorParam),
+	}
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(query, CreatedAfterParam); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(query, CreatedBeforeParam); err != nil {
+		return filter, err
+	}
+	if limit := query.Get(LimitParam); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("%w: %s must be a positive number", urlshortener.ErrInvalidFilter, LimitParam)
+		}
+	}
+	switch query.Get(OrderParam) {
+	case "", OrderDescending:
+	case OrderAscending:
+		filter.Ascending = true
+	default:
+		return filter, fmt.Errorf("%w: %s must be %s or %s", urlshortener.ErrInvalidFilter, OrderParam, OrderAscending, OrderDescending)
+	}
+	return filter, nil
+}
+
+// LinkFilterQuery is the inverse of ParseLinkFilter.
+func LinkFilterQuery(filter urlshortener.LinkFilter) url.Values {
+	query := url.Values{}
+	for param, value := range map[string]string{
+		OwnerParam:  filter.Owner,
+		TagParam:    filter.Tag,
+		DomainParam: filter.Domain,
+		CursorParam: filter.Cursor,
+	} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		query.Set(CreatedAfterParam, filter.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if filter.CreatedBefore != nil {
+		query.Set(CreatedBeforeParam, filter.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	if filter.Limit != 0 {
+		query.Set(LimitParam, strconv.Itoa(filter.Limit))
+	}
+	if filter.Ascending {
+		query.Set(OrderParam, OrderAscending)
+	}
+	return query
+}
+
+func parseTimeParam(query url.Values, param string) (*time.Time, error) {
+	value := query.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an RFC 3339 time", urlshortener.ErrInvalidFilter, param)
+	}
+	return &t, nil
+}
+
+func writeJSON(w http.ResponseWriter, logger *zap.Logger, body any) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		logger.Error("failed to encode response", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(b); err != nil {
+		logger.Error("failed to write response", zap.String(logkey.Error, err.Error()))
+	}
+}
@@ -0,0 +1,382 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_LinkHandler(t *testing.T) {
+	link := &urlshortener.Link{
+		ShortURL:    "b",
+		ID:          1,
+		OriginalURL: "http://www.example.com",
+		Status:      urlshortener.StatusActive,
+		LinkOptions: urlshortener.LinkOptions{
+			PasswordHash: "secret",
+			Metadata:     urlshortener.Metadata{Title: "Launch", Tags: []string{"q3"}},
+		},
+	}
+
+	launch := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		link           *urlshortener.Link
+		getLinkError   error
+		authorization  string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"Happy Path": {
+			link:           link,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"","disabled":false,"status":"active","title":"Launch","tags":["q3"]}`,
+		},
+		"Happy Path admin sees destinations": {
+			link:           link,
+			authorization:  "Bearer admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"http://www.example.com","disabled":false,"status":"active","title":"Launch","tags":["q3"]}`,
+		},
+		"Happy Path wrong token is not admin": {
+			link:           link,
+			authorization:  "Bearer other",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"","disabled":false,"status":"active","title":"Launch","tags":["q3"]}`,
+		},
+		"Happy Path one-time link is redacted": {
+			link: &urlshortener.Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", ClicksRemaining: 1,
+				LinkOptions: urlshortener.LinkOptions{MaxClicks: 1, Metadata: urlshortener.Metadata{Notes: "for Alex"}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"","disabled":false,"clicks_remaining":1,"max_clicks":1}`,
+		},
+		"Happy Path scheduled link is redacted before launch": {
+			link: &urlshortener.Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", Status: urlshortener.StatusScheduled,
+				LinkOptions: urlshortener.LinkOptions{NotBefore: &launch, PrelaunchURL: "http://www.example.com/soon"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"","disabled":false,"status":"scheduled","not_before":"2100-01-01T00:00:00Z"}`,
+		},
+		"Happy Path unprotected link": {
+			link:           &urlshortener.Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"short_url":"b","id":1,"original_url":"http://www.example.com","disabled":false}`,
+		},
+		"Sad Path not found": {
+			getLinkError:   urlshortener.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		"Sad Path error": {
+			getLinkError:   errors.New("error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("GetLink", mock.Anything, "b").Return(tc.link, tc.getLinkError)
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+				AdminToken:           "admin",
+			}
+
+			r := chi.NewRouter()
+			r.Get(LinkDetailEndpoint, handler.LinkHandler())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/links/b", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_UpdateLinkHandler(t *testing.T) {
+	title := "Launch"
+	tags := []string{"q3"}
+
+	tests := map[string]struct {
+		body           string
+		adminToken     string
+		authorization  string
+		update         *urlshortener.MetadataUpdate
+		updateError    error
+		expectedStatus int
+	}{
+		"Happy Path": {
+			body:           `{"title": "Launch", "tags": ["q3"]}`,
+			update:         &urlshortener.MetadataUpdate{Title: &title, Tags: &tags},
+			expectedStatus: http.StatusOK,
+		},
+		"Sad Path without admin token": {
+			body:           `{"title": "Launch"}`,
+			authorization:  "-",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path wrong admin token": {
+			body:           `{"title": "Launch"}`,
+			authorization:  "Bearer other",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path no admin token configured": {
+			body:           `{"title": "Launch"}`,
+			adminToken:     "-",
+			authorization:  "Bearer ",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path invalid body": {
+			body:           `{"title": 1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid metadata": {
+			body:           `{"tags": ["q3"]}`,
+			update:         &urlshortener.MetadataUpdate{Tags: &tags},
+			updateError:    fmt.Errorf("%w: too long", urlshortener.ErrInvalidLink),
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path not found": {
+			body:           `{"tags": ["q3"]}`,
+			update:         &urlshortener.MetadataUpdate{Tags: &tags},
+			updateError:    urlshortener.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		"Sad Path error": {
+			body:           `{"tags": ["q3"]}`,
+			update:         &urlshortener.MetadataUpdate{Tags: &tags},
+			updateError:    errors.New("error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.update != nil {
+				updated := &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com"}
+				if tc.updateError != nil {
+					updated = nil
+				}
+				mockProvider.On("UpdateMetadata", mock.Anything, "b", *tc.update).Return(updated, tc.updateError)
+			}
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+				AdminToken:           "admin",
+			}
+			if tc.adminToken == "-" {
+				handler.AdminToken = ""
+			}
+
+			r := chi.NewRouter()
+			r.Patch(LinkDetailEndpoint, handler.UpdateLinkHandler())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/links/b", strings.NewReader(tc.body))
+			switch tc.authorization {
+			case "":
+				req.Header.Set("Authorization", "Bearer admin")
+			case "-":
+			default:
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListLinksHandler(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		query          string
+		filter         *urlshortener.LinkFilter
+		page           *urlshortener.LinkPage
+		listError      error
+		expectedStatus int
+		expectedLinks  int
+		expectedCursor string
+	}{
+		"Happy Path": {
+			filter:         &urlshortener.LinkFilter{},
+			page:           &urlshortener.LinkPage{Links: []*urlshortener.Link{{ShortURL: "b"}, {ShortURL: "c"}}, NextCursor: "next"},
+			expectedStatus: http.StatusOK,
+			expectedLinks:  2,
+			expectedCursor: "next",
+		},
+		"Happy Path with filters": {
+			query: "?owner=growth&tag=q3&domain=example.com&created_after=2025-01-01T00:00:00Z&limit=10&cursor=abc&order=asc",
+			filter: &urlshortener.LinkFilter{
+				Owner:        "growth",
+				Tag:          "q3",
+				Domain:       "example.com",
+				CreatedAfter: &after,
+				Limit:        10,
+				Cursor:       "abc",
+				Ascending:    true,
+			},
+			page:           &urlshortener.LinkPage{Links: []*urlshortener.Link{{ShortURL: "b"}}},
+			expectedStatus: http.StatusOK,
+			expectedLinks:  1,
+		},
+		"Happy Path no links": {
+			filter:         &urlshortener.LinkFilter{},
+			page:           &urlshortener.LinkPage{Links: []*urlshortener.Link{}},
+			expectedStatus: http.StatusOK,
+		},
+		"Sad Path invalid time": {
+			query:          "?created_before=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid limit": {
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid order": {
+			query:          "?order=random",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path invalid cursor": {
+			query:          "?cursor=abc",
+			filter:         &urlshortener.LinkFilter{Cursor: "abc"},
+			listError:      fmt.Errorf("%w: malformed cursor", urlshortener.ErrInvalidFilter),
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path error": {
+			filter:         &urlshortener.LinkFilter{},
+			listError:      errors.New("error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.filter != nil {
+				mockProvider.On("ListLinks", mock.Anything, *tc.filter).Return(tc.page, tc.listError)
+			}
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+				AdminToken:           "admin",
+			}
+
+			r := chi.NewRouter()
+			r.Get(LinksEndpoint, handler.ListLinksHandler())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, LinksEndpoint+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer admin")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var body LinkList
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.NotNil(t, body.Links)
+				assert.Len(t, body.Links, tc.expectedLinks)
+				assert.Equal(t, tc.expectedCursor, body.NextCursor)
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListLinksHandler_Admin(t *testing.T) {
+	tests := map[string]struct {
+		authorization  string
+		expectList     bool
+		expectedStatus int
+	}{
+		"Happy Path admin sees destinations": {
+			authorization:  "Bearer admin",
+			expectList:     true,
+			expectedStatus: http.StatusOK,
+		},
+		"Sad Path without token": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Sad Path wrong token": {
+			authorization:  "Bearer other",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.expectList {
+				mockProvider.On("ListLinks", mock.Anything, urlshortener.LinkFilter{}).Return(&urlshortener.LinkPage{Links: []*urlshortener.Link{
+					{ShortURL: "c", OriginalURL: "http://www.example.com/secret", LinkOptions: urlshortener.LinkOptions{PasswordHash: "secret"}},
+				}}, nil)
+			}
+			handler := &Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockProvider,
+				AdminToken:           "admin",
+			}
+
+			r := chi.NewRouter()
+			r.Get(LinksEndpoint, handler.ListLinksHandler())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, LinksEndpoint, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectList {
+				var body LinkList
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Len(t, body.Links, 1)
+				assert.Equal(t, "http://www.example.com/secret", body.Links[0].OriginalURL)
+			} else {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_LinkFilterQuery(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := after.Add(36 * time.Hour)
+	filter := urlshortener.LinkFilter{
+		Owner:         "growth",
+		Tag:           "q3",
+		Domain:        "example.com",
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+		Limit:         10,
+		Cursor:        "abc",
+		Ascending:     true,
+	}
+
+	parsed, err := ParseLinkFilter(LinkFilterQuery(filter))
+	assert.NoError(t, err)
+	assert.Equal(t, filter, parsed)
+	assert.Empty(t, LinkFilterQuery(urlshortener.LinkFilter{}))
+}
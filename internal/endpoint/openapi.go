@@ -0,0 +1,26 @@
+package endpoint
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"go.uber.org/zap"
+)
+
+const OpenAPIEndpoint = "/openapi.json"
+
+// OpenAPISpec is the OpenAPI 3 document describing every route registered by the router.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+func (h *Handler) OpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(OpenAPISpec)
+		if err != nil {
+			h.requestLogger(r).Error("failed to write OpenAPI response", zap.String(logkey.Error, err.Error()))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package endpoint
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"go.uber.org/zap"
+)
+
+const (
+	PasswordParam         = "password"
+	PasswordRequiredError = "this link is password protected"
+	WrongPasswordError    = "incorrect password"
+	TooManyAttemptsError  = "too many password attempts, try again later"
+
+	DefaultPasswordAttempts = 5
+	DefaultPasswordWindow   = time.Minute
+	// maxAttemptKeys bounds the limiter's memory before expired windows are pruned.
+	maxAttemptKeys = 10000
+)
+
+// defaultAttemptLimiter is shared by handlers that do not set PasswordAttempts.
+var defaultAttemptLimiter = NewAttemptLimiter(DefaultPasswordAttempts, DefaultPasswordWindow)
+
+var passwordPrompt = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>{{.Message}}</p>
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="current-password" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+type (
+	// AttemptLimiter allows a fixed number of attempts per key within a window. It only sees the
+	// requests of a single Lambda instance, which is enough to make guessing impractical.
+	AttemptLimiter struct {
+		Max    int
+		Window time.Duration
+
+		mu       sync.Mutex
+		attempts map[string]*attemptWindow
+	}
+
+	attemptWindow struct {
+		start time.Time
+		count int
+	}
+)
+
+func NewAttemptLimiter(max int, window time.Duration) *AttemptLimiter {
+	return &AttemptLimiter{
+		Max:      max,
+		Window:   window,
+		attempts: make(map[string]*attemptWindow),
+	}
+}
+
+// Allow records an attempt for key and reports whether it is within the limit. When it is not,
+// the returned duration is how long until the next attempt is allowed.
+func (l *AttemptLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.attempts) >= maxAttemptKeys {
+		for k, a := range l.attempts {
+			if now.Sub(a.start) >= l.Window {
+				delete(l.attempts, k)
+			}
+		}
+	}
+
+	a, ok := l.attempts[key]
+	if !ok || now.Sub(a.start) >= l.Window {
+		a = &attemptWindow{start: now}
+		l.attempts[key] = a
+	}
+	if a.count >= l.Max {
+		return false, a.start.Add(l.Window).Sub(now)
+	}
+	a.count++
+	return true, 0
+}
+
+func (h *Handler) attemptLimiter() *AttemptLimiter {
+	if h.PasswordAttempts != nil {
+		return h.PasswordAttempts
+	}
+	return defaultAttemptLimiter
+}
+
+// checkPassword verifies the submitted password of a protected link, writing the prompt or a
+// rate limit response and returning false when the visitor may not be redirected yet.
+func (h *Handler) checkPassword(w http.ResponseWriter, r *http.Request, shortUrl string, verify func(string) bool) bool {
+	if r.Method != http.MethodPost {
+		writePasswordPrompt(w, r, PasswordRequiredError)
+		return false
+	}
+
+	allowed, retryAfter := h.attemptLimiter().Allow(shortUrl+"|"+clientIP(r), time.Now())
+	if !allowed {
+		h.requestLogger(r).Warn("too many password attempts", zap.String(logkey.ShortenedURL, shortUrl))
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())))
+		http.Error(w, TooManyAttemptsError, http.StatusTooManyRequests)
+		return false
+	}
+
+	if !verify(r.PostFormValue(PasswordParam)) {
+		h.requestLogger(r).Info("wrong password", zap.String(logkey.ShortenedURL, shortUrl))
+		writePasswordPrompt(w, r, WrongPasswordError)
+		return false
+	}
+	return true
+}
+
+// writePasswordPrompt answers with 401 so API clients see the link as unauthorized, while
+// browsers render the form that posts the password back to the same URL.
+func writePasswordPrompt(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = passwordPrompt.Execute(w, struct{ Action, Message string }{r.URL.RequestURI(), message})
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
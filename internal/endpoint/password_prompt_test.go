@@ -0,0 +1,123 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_RedirectHandler_Password(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	hash, err := urlshortener.HashPassword("hunter2")
+	assert.NoError(t, err)
+	protected := &urlshortener.Link{
+		ShortURL:    "b",
+		OriginalURL: "http://www.example.com",
+		LinkOptions: urlshortener.LinkOptions{QueryPolicy: urlshortener.QueryAppend, PasswordHash: hash},
+	}
+
+	tests := map[string]struct {
+		link             *urlshortener.Link
+		method           string
+		password         string
+		attempts         int
+		expectedCode     int
+		expectedLocation string
+		expectedBody     string
+	}{
+		"Prompt": {
+			link:         protected,
+			method:       http.MethodGet,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `action="/b?ref=mail"`,
+		},
+		"Correct password": {
+			link:             protected,
+			method:           http.MethodPost,
+			password:         "hunter2",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "http://www.example.com?ref=mail",
+		},
+		"Wrong password": {
+			link:         protected,
+			method:       http.MethodPost,
+			password:     "hunter3",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: WrongPasswordError,
+		},
+		"Rate limited": {
+			link:         protected,
+			method:       http.MethodPost,
+			password:     "hunter2",
+			attempts:     1,
+			expectedCode: http.StatusTooManyRequests,
+			expectedBody: TooManyAttemptsError,
+		},
+		"Post to unprotected link": {
+			link:         &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com"},
+			method:       http.MethodPost,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("ResolveLink", mock.Anything, "b").Return(tc.link, nil)
+
+			limiter := NewAttemptLimiter(1, time.Minute)
+			for range tc.attempts {
+				limiter.Allow("b|192.0.2.1", time.Now())
+			}
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+				PasswordAttempts:     limiter,
+			}
+
+			form := url.Values{PasswordParam: {tc.password}}
+			req := httptest.NewRequest(tc.method, "/b?ref=mail", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.Post(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_AttemptLimiter(t *testing.T) {
+	limiter := NewAttemptLimiter(2, time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	allowed, _ := limiter.Allow("a", now)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("a", now.Add(time.Second))
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.Allow("a", now.Add(20*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	allowed, _ = limiter.Allow("b", now.Add(20*time.Second))
+	assert.True(t, allowed, "keys are limited independently")
+
+	allowed, _ = limiter.Allow("a", now.Add(time.Minute))
+	assert.True(t, allowed, "the window resets")
+}
@@ -1,8 +1,12 @@
 package endpoint
 
 import (
+	"context"
 	"net/http"
+	"time"
 
+	"github.com/connorpalermo/url-shortener/internal/botdetect"
+	"github.com/connorpalermo/url-shortener/internal/logging"
 	"github.com/connorpalermo/url-shortener/internal/urlshortener"
 	"go.uber.org/zap"
 )
@@ -10,12 +14,50 @@ import (
 type (
 	Provider interface {
 		HealthCheckHandler() http.HandlerFunc
+		ReadinessHandler() http.HandlerFunc
 		RedirectHandler() http.HandlerFunc
 		ShortenHandler() http.HandlerFunc
+		OpenAPIHandler() http.HandlerFunc
+		LinkVariantsHandler() http.HandlerFunc
+		LinkHandler() http.HandlerFunc
+		UpdateLinkHandler() http.HandlerFunc
+		ListLinksHandler() http.HandlerFunc
+		LinkAnalyticsHandler() http.HandlerFunc
 	}
 
 	Handler struct {
 		Logger               *zap.Logger
 		UrlShortenerProvider urlshortener.UrlShortenerProvider
+		Dependencies         []DependencyChecker
+		ReadinessTimeout     time.Duration
+		// PasswordAttempts limits password guesses per client and link, five a minute by default.
+		PasswordAttempts *AttemptLimiter
+		// Interstitials decides which redirects show a warning page first, InterstitialFlagged
+		// when empty. InterstitialSkipToken lets trusted clients skip it, nothing can when empty.
+		Interstitials         InterstitialMode
+		InterstitialSkipToken string
+		// Events is told about every redirect. Nothing is sent when nil.
+		Events urlshortener.Emitter
+		// Clicks collects every redirect for analytics. Nothing is collected when nil.
+		Clicks ClickRecorder
+		// Bots tags the clicks of crawlers, unfurlers and prefetches, which then do not count
+		// towards variant clicks. Every click counts as a person's when nil.
+		Bots *botdetect.Classifier
+		// Analytics answers GET /{shortUrl}/analytics, which returns 501 when nil.
+		Analytics AnalyticsReporter
+		// AdminToken is the bearer token needed to change links through the API and to read the
+		// destinations of password protected ones. Links cannot be changed when empty.
+		AdminToken string
+	}
+
+	// ClickRecorder is implemented by clickstream.Buffer. Record must return without waiting for
+	// the click to be written.
+	ClickRecorder interface {
+		Record(ctx context.Context, click urlshortener.Click, clientIP string)
 	}
 )
+
+// requestLogger returns the logger scoped to r by the logging middleware, falling back to h.Logger.
+func (h *Handler) requestLogger(r *http.Request) *zap.Logger {
+	return logging.FromContext(r.Context(), h.Logger)
+}
@@ -0,0 +1,102 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"go.uber.org/zap"
+)
+
+const (
+	LivenessEndpoint  = HealthCheckEndpoint + "/live"
+	ReadinessEndpoint = HealthCheckEndpoint + "/ready"
+	ReadinessTimeout  = 2 * time.Second
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	ReadinessErrResp  = "error creating readiness response"
+)
+
+// DependencyChecker is a downstream dependency the service needs to be able to serve traffic.
+type DependencyChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+func (h *Handler) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
+
+		timeout := h.ReadinessTimeout
+		if timeout <= 0 {
+			timeout = ReadinessTimeout
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		body := &Readiness{
+			Status:       StatusOK,
+			Dependencies: make([]DependencyStatus, len(h.Dependencies)),
+		}
+
+		var wg sync.WaitGroup
+		for i, dep := range h.Dependencies {
+			wg.Add(1)
+			go func(i int, dep DependencyChecker) {
+				defer wg.Done()
+				body.Dependencies[i] = checkDependency(ctx, dep)
+			}(i, dep)
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		for _, dep := range body.Dependencies {
+			if dep.Status != StatusOK {
+				logger.Warn("dependency is unavailable", zap.String(logkey.Dependency, dep.Name),
+					zap.String(logkey.Error, dep.Error))
+				body.Status = StatusDegraded
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		b, _ := json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, err := w.Write(b)
+		if err != nil {
+			logger.Error("failed to write readiness response", zap.String(logkey.Error, err.Error()))
+			http.Error(w, ReadinessErrResp, http.StatusInternalServerError)
+		}
+	}
+}
+
+func checkDependency(ctx context.Context, dep DependencyChecker) DependencyStatus {
+	start := time.Now()
+	err := dep.Check(ctx)
+	result := DependencyStatus{
+		Name:      dep.Name(),
+		Status:    StatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+type Readiness struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,90 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeDependency struct {
+	name  string
+	err   error
+	delay time.Duration
+}
+
+func (f *fakeDependency) Name() string {
+	return f.name
+}
+
+func (f *fakeDependency) Check(ctx context.Context) error {
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func Test_ReadinessHandler(t *testing.T) {
+	tests := map[string]struct {
+		dependencies   []DependencyChecker
+		expectedStatus int
+		expectedBody   string
+		expectedDeps   map[string]string
+	}{
+		"Happy Path all dependencies ok": {
+			dependencies:   []DependencyChecker{&fakeDependency{name: "dynamodb"}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   StatusOK,
+			expectedDeps:   map[string]string{"dynamodb": StatusOK},
+		},
+		"Sad Path dependency error": {
+			dependencies: []DependencyChecker{
+				&fakeDependency{name: "dynamodb", err: errors.New("table not found")},
+				&fakeDependency{name: "other"},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   StatusDegraded,
+			expectedDeps:   map[string]string{"dynamodb": StatusUnavailable, "other": StatusOK},
+		},
+		"Sad Path dependency timeout": {
+			dependencies:   []DependencyChecker{&fakeDependency{name: "dynamodb", delay: time.Second}},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   StatusDegraded,
+			expectedDeps:   map[string]string{"dynamodb": StatusUnavailable},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := Handler{
+				Logger:           zaptest.NewLogger(t),
+				Dependencies:     tc.dependencies,
+				ReadinessTimeout: 50 * time.Millisecond,
+			}
+
+			rr := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, ReadinessEndpoint, nil)
+			h.ReadinessHandler().ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+
+			var gotResp Readiness
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&gotResp))
+			assert.Equal(t, tc.expectedBody, gotResp.Status)
+
+			gotDeps := map[string]string{}
+			for _, dep := range gotResp.Dependencies {
+				gotDeps[dep.Name] = dep.Status
+			}
+			assert.Equal(t, tc.expectedDeps, gotDeps)
+		})
+	}
+}
@@ -2,9 +2,12 @@ package endpoint
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
 	"go.uber.org/zap"
 )
 
@@ -12,10 +15,12 @@ const (
 	ShortenURLEndpoint = "/shorten"
 	InvalidBodyError   = "invalid URL shorten request"
 	ShortenURLError    = "failed to generate shortenedURL"
+	ReputationError    = "destination could not be checked, try again later"
 )
 
 func (h *Handler) ShortenHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
 		var body ShortenRequest
 		err := json.NewDecoder(r.Body).Decode(&body)
 		if err != nil || body.OriginalURL == "" {
@@ -24,10 +29,20 @@ func (h *Handler) ShortenHandler() http.HandlerFunc {
 		}
 		originalURL := body.OriginalURL
 
-		h.Logger.Info("creating shortenedURL from originalURL", zap.String(logkey.OriginalURL, originalURL))
-		shortenedURL, err := h.UrlShortenerProvider.ShortenURL(originalURL)
+		logger.Info("creating shortenedURL from originalURL", zap.String(logkey.OriginalURL, originalURL))
+		shortenedURL, err := h.UrlShortenerProvider.ShortenURL(r.Context(), originalURL, body.linkOptions())
+		if errors.Is(err, urlshortener.ErrInvalidLink) {
+			logger.Info("rejected URL shorten request", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, urlshortener.ErrReputationUnavailable) {
+			logger.Error("failed to check destination reputation", zap.Error(err))
+			http.Error(w, ReputationError, http.StatusServiceUnavailable)
+			return
+		}
 		if err != nil {
-			h.Logger.Error("failed to create shortened URL", zap.Error(err))
+			logger.Error("failed to create shortened URL", zap.Error(err))
 			http.Error(w, ShortenURLError, http.StatusInternalServerError)
 			return
 		}
@@ -38,14 +53,53 @@ func (h *Handler) ShortenHandler() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		_, err = w.Write(b)
 		if err != nil {
-			h.Logger.Error("failed to write shortened URL response", zap.String(logkey.Error, err.Error()))
+			logger.Error("failed to write shortened URL response", zap.String(logkey.Error, err.Error()))
 			http.Error(w, ShortenURLError, http.StatusInternalServerError)
 		}
 	}
 }
 
 type ShortenRequest struct {
-	OriginalURL string `json:"original_url"`
+	OriginalURL  string                     `json:"original_url"`
+	RedirectType int                        `json:"redirect_type,omitempty"`
+	QueryPolicy  urlshortener.QueryPolicy   `json:"query_policy,omitempty"`
+	Prefix       bool                       `json:"prefix,omitempty"`
+	Destinations []urlshortener.Destination `json:"destinations,omitempty"`
+	Sticky       bool                       `json:"sticky,omitempty"`
+	Rules        []urlshortener.Rule        `json:"rules,omitempty"`
+	Password     string                     `json:"password,omitempty"`
+	MaxClicks    int64                      `json:"max_clicks,omitempty"`
+	NotBefore    *time.Time                 `json:"not_before,omitempty"`
+	NotAfter     *time.Time                 `json:"not_after,omitempty"`
+	PrelaunchURL string                     `json:"prelaunch_url,omitempty"`
+	Interstitial bool                       `json:"interstitial,omitempty"`
+	Owner        string                     `json:"owner,omitempty"`
+	Title        string                     `json:"title,omitempty"`
+	Tags         []string                   `json:"tags,omitempty"`
+	Notes        string                     `json:"notes,omitempty"`
+}
+
+func (s *ShortenRequest) linkOptions() urlshortener.LinkOptions {
+	return urlshortener.LinkOptions{
+		RedirectType: s.RedirectType,
+		QueryPolicy:  s.QueryPolicy,
+		Prefix:       s.Prefix,
+		Destinations: s.Destinations,
+		Sticky:       s.Sticky,
+		Rules:        s.Rules,
+		Password:     s.Password,
+		MaxClicks:    s.MaxClicks,
+		NotBefore:    s.NotBefore,
+		NotAfter:     s.NotAfter,
+		PrelaunchURL: s.PrelaunchURL,
+		Interstitial: s.Interstitial,
+		Owner:        s.Owner,
+		Metadata: urlshortener.Metadata{
+			Title: s.Title,
+			Tags:  s.Tags,
+			Notes: s.Notes,
+		},
+	}
 }
 
 type ShortenResponse struct {
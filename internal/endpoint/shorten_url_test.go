@@ -3,13 +3,16 @@ package endpoint
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -17,7 +20,7 @@ func Test_ShortenHandler_Success(t *testing.T) {
 	mockLogger := zaptest.NewLogger(t)
 	mockUrlShortenerProvider := new(MockUrlShortenerProvider)
 
-	mockUrlShortenerProvider.On("ShortenURL", "http://example.com").Return("short.ly/123", nil)
+	mockUrlShortenerProvider.On("ShortenURL", mock.Anything, "http://example.com", urlshortener.LinkOptions{}).Return("short.ly/123", nil)
 
 	handler := Handler{
 		Logger:               mockLogger,
@@ -64,7 +67,7 @@ func Test_ShortenHandler_ErrorInShortenURL(t *testing.T) {
 	mockLogger := zaptest.NewLogger(t)
 	mockUrlShortenerProvider := new(MockUrlShortenerProvider)
 
-	mockUrlShortenerProvider.On("ShortenURL", "http://example.com").Return("", errors.New("some error"))
+	mockUrlShortenerProvider.On("ShortenURL", mock.Anything, "http://example.com", urlshortener.LinkOptions{}).Return("", errors.New("some error"))
 
 	handler := Handler{
 		Logger:               mockLogger,
@@ -86,7 +89,7 @@ func Test_ShortenHandler_ErrorWritingResponse(t *testing.T) {
 	mockLogger := zaptest.NewLogger(t)
 	mockUrlShortenerProvider := new(MockUrlShortenerProvider)
 
-	mockUrlShortenerProvider.On("ShortenURL", "http://example.com").Return("short.ly/123", nil)
+	mockUrlShortenerProvider.On("ShortenURL", mock.Anything, "http://example.com", urlshortener.LinkOptions{}).Return("short.ly/123", nil)
 
 	handler := Handler{
 		Logger:               mockLogger,
@@ -104,3 +107,54 @@ func Test_ShortenHandler_ErrorWritingResponse(t *testing.T) {
 
 	mockUrlShortenerProvider.AssertExpectations(t)
 }
+
+func Test_ShortenHandler_RedirectType(t *testing.T) {
+	tests := map[string]struct {
+		body           string
+		opts           urlshortener.LinkOptions
+		shortenError   error
+		expectedStatus int
+	}{
+		"Happy Path permanent redirect": {
+			body:           `{"original_url": "http://example.com", "redirect_type": 301}`,
+			opts:           urlshortener.LinkOptions{RedirectType: http.StatusMovedPermanently},
+			expectedStatus: http.StatusOK,
+		},
+		"Sad Path unsupported redirect type": {
+			body:           `{"original_url": "http://example.com", "redirect_type": 200}`,
+			opts:           urlshortener.LinkOptions{RedirectType: http.StatusOK},
+			shortenError:   urlshortener.ErrInvalidLink,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path flagged destination": {
+			body:           `{"original_url": "http://example.com"}`,
+			shortenError:   urlshortener.ErrDestinationFlagged,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Sad Path reputation check unavailable": {
+			body:           `{"original_url": "http://example.com"}`,
+			shortenError:   fmt.Errorf("%w: timeout", urlshortener.ErrReputationUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockUrlShortenerProvider := new(MockUrlShortenerProvider)
+			mockUrlShortenerProvider.On("ShortenURL", mock.Anything, "http://example.com", tc.opts).Return("b", tc.shortenError)
+
+			handler := Handler{
+				Logger:               zaptest.NewLogger(t),
+				UrlShortenerProvider: mockUrlShortenerProvider,
+			}
+
+			request := httptest.NewRequest("POST", ShortenURLEndpoint, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			handler.ShortenHandler().ServeHTTP(rr, request)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			mockUrlShortenerProvider.AssertExpectations(t)
+		})
+	}
+}
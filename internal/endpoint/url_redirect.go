@@ -1,38 +1,252 @@
 package endpoint
 
 import (
+	"errors"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/botdetect"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
 const (
 	RedirectEndpoint   = "/{" + ShortUrlParam + "}"
+	PrefixEndpoint     = RedirectEndpoint + "/*"
 	ShortUrlParam      = "shortUrl"
 	RedirectError      = "shortUrl mapping invalid or not found in database"
 	ShortUrlParamError = "shortUrl parameter is missing"
+	NotFoundError      = "shortUrl not found"
+	DisabledError      = "shortUrl has been disabled"
+	BlockedError       = "shortUrl was blocked as unsafe"
+	ExhaustedError     = "shortUrl has no clicks left"
+	ExpiredError       = "shortUrl has expired"
+	NotActiveError     = "shortUrl is not active yet"
+	InvalidPathError   = "path after shortUrl is invalid"
+
+	VariantCookiePrefix = "variant_"
+	VariantCookieMaxAge = 30 * 24 * time.Hour
 )
 
+// pickIntn draws the weighted destination of a link, replaced in tests.
+var pickIntn = rand.IntN
+
 func (h *Handler) RedirectHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		logger := h.requestLogger(r)
 		shortUrl := chi.URLParam(r, ShortUrlParam)
 		if shortUrl == "" {
-			h.Logger.Error("shortUrl parameter is missing in the request")
+			logger.Error("shortUrl parameter is missing in the request")
 			http.Error(w, ShortUrlParamError, http.StatusBadRequest)
 			return
 		}
 
-		h.Logger.Info("redirecting from shortUrl", zap.String(logkey.ShortenedURL, shortUrl))
+		logger.Info("redirecting from shortUrl", zap.String(logkey.ShortenedURL, shortUrl))
 
-		originalURL, err := h.UrlShortenerProvider.GetOriginalURL(shortUrl)
-		if err != nil {
-			h.Logger.Error("failed to retrieve original URL", zap.Error(err))
+		link, err := h.UrlShortenerProvider.ResolveLink(r.Context(), shortUrl)
+		switch {
+		case errors.Is(err, urlshortener.ErrLinkNotFound):
+			logger.Info("shortUrl not found", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		case errors.Is(err, urlshortener.ErrLinkDisabled):
+			logger.Info("shortUrl is disabled", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, DisabledError, http.StatusGone)
+			return
+		case errors.Is(err, urlshortener.ErrLinkBlocked):
+			logger.Warn("shortUrl is blocked", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, BlockedError, http.StatusGone)
+			return
+		case errors.Is(err, urlshortener.ErrLinkExhausted):
+			logger.Info("shortUrl has no clicks left", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, ExhaustedError, http.StatusGone)
+			return
+		case errors.Is(err, urlshortener.ErrLinkExpired):
+			logger.Info("shortUrl has expired", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, ExpiredError, http.StatusGone)
+			return
+		case errors.Is(err, urlshortener.ErrLinkNotActive):
+			logger.Info("shortUrl is not active yet", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, NotActiveError, http.StatusNotFound)
+			return
+		case err != nil:
+			logger.Error("failed to retrieve original URL", zap.Error(err))
 			http.Error(w, RedirectError, http.StatusInternalServerError)
 			return
 		}
 
-		http.Redirect(w, r, originalURL, http.StatusFound)
+		skipInterstitial := h.skipInterstitial(r)
+		status := link.RedirectStatus()
+		if link.Protected() {
+			if !h.checkPassword(w, r, shortUrl, link.CheckPassword) {
+				return
+			}
+			// the browser has to follow up the password form with a GET
+			status = http.StatusSeeOther
+		} else if r.Method == http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		variant := -1
+		target, matched := link.MatchRule(urlshortener.NewVisitor(r.Header))
+		if !matched {
+			variant = link.PickVariant(stickyVariant(r, shortUrl), pickIntn)
+			target = link.VariantURL(variant)
+		}
+		if vary := link.Vary(); vary != "" {
+			w.Header().Set("Vary", vary)
+		}
+
+		destination, err := redirectDestination(r, link, target)
+		switch {
+		case errors.Is(err, urlshortener.ErrLinkNotFound):
+			logger.Info("path suffix on a link without prefix mode", zap.String(logkey.ShortenedURL, shortUrl))
+			http.Error(w, NotFoundError, http.StatusNotFound)
+			return
+		case errors.Is(err, urlshortener.ErrInvalidPath):
+			logger.Info("rejected path suffix", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+			http.Error(w, InvalidPathError, http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Error("failed to build redirect destination", zap.Error(err))
+			http.Error(w, RedirectError, http.StatusInternalServerError)
+			return
+		}
+
+		if !link.Cacheable() {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		// bots are redirected like anyone else but tagged, and do not count towards variants
+		bot := h.classify(r)
+		if link.MaxClicks > 0 {
+			// every redirect of a link with a click budget spends a click, so bots and HEAD
+			// requests, which would not, are answered without the destination instead
+			if bot != "" || r.Method == http.MethodHead {
+				logger.Info("not redirecting automated request to a link with max clicks",
+					zap.String(logkey.ShortenedURL, shortUrl), zap.String(logkey.Bot, string(bot)))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			// the budget is only spent once a redirect is certain
+			err = h.UrlShortenerProvider.ConsumeClick(r.Context(), shortUrl)
+			switch {
+			case errors.Is(err, urlshortener.ErrLinkExhausted):
+				logger.Info("shortUrl has no clicks left", zap.String(logkey.ShortenedURL, shortUrl))
+				http.Error(w, ExhaustedError, http.StatusGone)
+				return
+			case err != nil:
+				logger.Error("failed to consume link click", zap.String(logkey.ShortenedURL, shortUrl), zap.Error(err))
+				http.Error(w, RedirectError, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if variant >= 0 {
+			if link.Sticky {
+				http.SetCookie(w, variantCookie(shortUrl, variant))
+			}
+			if bot == "" {
+				err = h.UrlShortenerProvider.RecordVariantClick(r.Context(), shortUrl, variant)
+				if err != nil {
+					logger.Warn("failed to record variant click", zap.String(logkey.ShortenedURL, shortUrl),
+						zap.Int(logkey.Variant, variant), zap.Error(err))
+				}
+			}
+		}
+
+		h.recordClick(r, shortUrl, destination, bot)
+
+		if !skipInterstitial && h.showInterstitial(link) {
+			logger.Info("showing interstitial", zap.String(logkey.ShortenedURL, shortUrl))
+			writeInterstitial(w, link, destination)
+			return
+		}
+		http.Redirect(w, r, destination, status)
+	}
+}
+
+// recordClick reports a redirect, or an interstitial standing in for one, to h.Events and
+// h.Clicks.
+func (h *Handler) recordClick(r *http.Request, shortUrl, destination string, bot botdetect.Reason) {
+	click := urlshortener.Click{
+		ShortURL:    shortUrl,
+		Destination: destination,
+		Referrer:    r.Referer(),
+		UserAgent:   r.UserAgent(),
+		Country:     strings.ToUpper(r.Header.Get(urlshortener.CountryHeader)),
+		Bot:         string(bot),
+		ClickedAt:   time.Now().UTC(),
+	}
+	if h.Events != nil {
+		h.Events.Emit(r.Context(), webhook.LinkClicked, &click)
+	}
+	if h.Clicks != nil {
+		h.Clicks.Record(r.Context(), click, clientIP(r))
+	}
+}
+
+// classify returns why r looks automated, or nothing when h.Bots is not configured.
+func (h *Handler) classify(r *http.Request) botdetect.Reason {
+	if h.Bots == nil {
+		return ""
+	}
+	return h.Bots.Classify(r)
+}
+
+// redirectDestination applies the link's path forwarding and query policy to the request.
+func redirectDestination(r *http.Request, link *urlshortener.Link, destination string) (string, error) {
+	if suffix := pathSuffix(r); suffix != "" {
+		if !link.Prefix {
+			return "", urlshortener.ErrLinkNotFound
+		}
+		var err error
+		destination, err = urlshortener.JoinPath(destination, suffix)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return urlshortener.MergeQuery(destination, r.URL.Query(), link.QueryPolicy)
+}
+
+// pathSuffix returns the still escaped path after the short code segment.
+func pathSuffix(r *http.Request) string {
+	escaped := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+	i := strings.IndexByte(escaped, '/')
+	if i < 0 {
+		return ""
+	}
+	return escaped[i+1:]
+}
+
+// stickyVariant returns the variant remembered for shortUrl, or -1.
+func stickyVariant(r *http.Request, shortUrl string) int {
+	cookie, err := r.Cookie(VariantCookiePrefix + shortUrl)
+	if err != nil {
+		return -1
+	}
+	variant, err := strconv.Atoi(cookie.Value)
+	if err != nil {
+		return -1
+	}
+	return variant
+}
+
+func variantCookie(shortUrl string, variant int) *http.Cookie {
+	return &http.Cookie{
+		Name:     VariantCookiePrefix + shortUrl,
+		Value:    strconv.Itoa(variant),
+		Path:     "/" + shortUrl,
+		MaxAge:   int(VariantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
 	}
 }
@@ -1,11 +1,17 @@
 package endpoint
 
 import (
+	"context"
 	"errors"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/connorpalermo/url-shortener/internal/botdetect"
+	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,26 +22,62 @@ type MockUrlShortenerProvider struct {
 	mock.Mock
 }
 
-func (m *MockUrlShortenerProvider) GetOriginalURL(shortened string) (string, error) {
-	args := m.Called(shortened)
+func (m *MockUrlShortenerProvider) GetOriginalURL(ctx context.Context, shortened string) (string, error) {
+	args := m.Called(ctx, shortened)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockUrlShortenerProvider) ShortenURL(originalUrl string) (string, error) {
-	args := m.Called(originalUrl)
+func (m *MockUrlShortenerProvider) ShortenURL(ctx context.Context, originalUrl string, opts urlshortener.LinkOptions) (string, error) {
+	args := m.Called(ctx, originalUrl, opts)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockUrlShortenerProvider) GetLink(ctx context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) RecordVariantClick(ctx context.Context, shortened string, variant int) error {
+	args := m.Called(ctx, shortened, variant)
+	return args.Error(0)
+}
+
+func (m *MockUrlShortenerProvider) ConsumeClick(ctx context.Context, shortened string) error {
+	args := m.Called(ctx, shortened)
+	return args.Error(0)
+}
+
+func (m *MockUrlShortenerProvider) UpdateMetadata(ctx context.Context, shortened string, update urlshortener.MetadataUpdate) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened, update)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) ListLinks(ctx context.Context, filter urlshortener.LinkFilter) (*urlshortener.LinkPage, error) {
+	args := m.Called(ctx, filter)
+	page, _ := args.Get(0).(*urlshortener.LinkPage)
+	return page, args.Error(1)
+}
+
+func (m *MockUrlShortenerProvider) ResolveLink(ctx context.Context, shortened string) (*urlshortener.Link, error) {
+	args := m.Called(ctx, shortened)
+	link, _ := args.Get(0).(*urlshortener.Link)
+	return link, args.Error(1)
+}
+
 func Test_RedirectHandler(t *testing.T) {
 	logger, _ := zap.NewProduction()
 
 	tests := []struct {
-		name                string
-		shortUrl            string
-		getOriginalURL      string
-		getOriginalURLError error
-		expectedStatus      int
-		expectedLocation    string
+		name             string
+		shortUrl         string
+		suffix           string
+		query            string
+		link             *urlshortener.Link
+		resolveError     error
+		expectedStatus   int
+		expectedLocation string
 	}{
 		{
 			name:             "Sad Path Missing shortUrl",
@@ -44,45 +86,142 @@ func Test_RedirectHandler(t *testing.T) {
 			expectedLocation: "",
 		},
 		{
-			name:                "Sad Path GetOriginalURL fails",
-			shortUrl:            "b",
-			getOriginalURL:      "",
-			getOriginalURLError: errors.New("failed to fetch URL"),
-			expectedStatus:      http.StatusInternalServerError,
-			expectedLocation:    "",
+			name:             "Sad Path ResolveLink fails",
+			shortUrl:         "b",
+			resolveError:     errors.New("failed to fetch URL"),
+			expectedStatus:   http.StatusInternalServerError,
+			expectedLocation: "",
+		},
+		{
+			name:           "Sad Path shortUrl not found",
+			shortUrl:       "b",
+			resolveError:   urlshortener.ErrLinkNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Sad Path shortUrl disabled",
+			shortUrl:       "b",
+			resolveError:   urlshortener.ErrLinkDisabled,
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "Sad Path shortUrl blocked",
+			shortUrl:       "b",
+			resolveError:   urlshortener.ErrLinkBlocked,
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "Sad Path shortUrl expired",
+			shortUrl:       "b",
+			resolveError:   urlshortener.ErrLinkExpired,
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "Sad Path shortUrl not active yet",
+			shortUrl:       "b",
+			resolveError:   urlshortener.ErrLinkNotActive,
+			expectedStatus: http.StatusNotFound,
 		},
 		{
 			name:             "Happy Path Successful Redirect",
 			shortUrl:         "b",
-			getOriginalURL:   "http://www.example.com",
+			link:             &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com"},
 			expectedStatus:   http.StatusFound,
 			expectedLocation: "http://www.example.com",
 		},
+		{
+			name:     "Happy Path Permanent Redirect",
+			shortUrl: "b",
+			link: &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com",
+				LinkOptions: urlshortener.LinkOptions{RedirectType: http.StatusMovedPermanently}},
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "http://www.example.com",
+		},
+		{
+			name:     "Happy Path Method Preserving Redirect",
+			shortUrl: "b",
+			link: &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com",
+				LinkOptions: urlshortener.LinkOptions{RedirectType: http.StatusTemporaryRedirect}},
+			expectedStatus:   http.StatusTemporaryRedirect,
+			expectedLocation: "http://www.example.com",
+		},
+		{
+			name:             "Happy Path Query Dropped By Default",
+			shortUrl:         "b",
+			query:            "?ref=twitter",
+			link:             &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com?a=1"},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://www.example.com?a=1",
+		},
+		{
+			name:     "Happy Path Query Appended",
+			shortUrl: "b",
+			query:    "?ref=twitter",
+			link: &urlshortener.Link{ShortURL: "b", OriginalURL: "http://www.example.com/page?a=1#top",
+				LinkOptions: urlshortener.LinkOptions{QueryPolicy: urlshortener.QueryAppend}},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://www.example.com/page?a=1&ref=twitter#top",
+		},
+		{
+			name:     "Happy Path Prefix Forwards Suffix",
+			shortUrl: "docs",
+			suffix:   "/getting-started/install",
+			query:    "?v=2",
+			link: &urlshortener.Link{ShortURL: "docs", OriginalURL: "https://example.com/manual#intro",
+				LinkOptions: urlshortener.LinkOptions{Prefix: true, QueryPolicy: urlshortener.QueryAppend}},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/manual/getting-started/install?v=2#intro",
+		},
+		{
+			name:     "Happy Path Prefix Encoded Characters",
+			shortUrl: "docs",
+			suffix:   "/a%2Fb/caf%C3%A9%20menu",
+			link: &urlshortener.Link{ShortURL: "docs", OriginalURL: "https://example.com/files",
+				LinkOptions: urlshortener.LinkOptions{Prefix: true}},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/files/a%2Fb/caf%C3%A9%20menu",
+		},
+		{
+			name:     "Sad Path Prefix Traversal",
+			shortUrl: "docs",
+			suffix:   "/%2E%2E/admin",
+			link: &urlshortener.Link{ShortURL: "docs", OriginalURL: "https://example.com/files",
+				LinkOptions: urlshortener.LinkOptions{Prefix: true}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Sad Path Suffix Without Prefix Mode",
+			shortUrl:       "b",
+			suffix:         "/extra",
+			link:           &urlshortener.Link{ShortURL: "b", OriginalURL: "https://example.com"},
+			expectedStatus: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			mockProvider := new(MockUrlShortenerProvider)
-			mockProvider.On("GetOriginalURL", tt.shortUrl).Return(tt.getOriginalURL, tt.getOriginalURLError)
+			mockProvider.On("ResolveLink", mock.Anything, tt.shortUrl).Return(tt.link, tt.resolveError)
 
 			handler := &Handler{
 				Logger:               logger,
 				UrlShortenerProvider: mockProvider,
 			}
 
-			req := httptest.NewRequest(http.MethodGet, "/"+tt.shortUrl, nil)
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.shortUrl+tt.suffix+tt.query, nil)
 			w := httptest.NewRecorder()
 
 			r := chi.NewRouter()
 			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.Get(PrefixEndpoint, handler.RedirectHandler())
 			r.Get("/", handler.RedirectHandler())
 
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
-			if tt.expectedStatus == http.StatusFound {
+			if tt.expectedLocation != "" {
 				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
 			}
 
@@ -92,3 +231,436 @@ func Test_RedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func Test_RedirectHandler_Variants(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	destinations := []urlshortener.Destination{
+		{URL: "http://a.example.com", Weight: 1},
+		{URL: "http://b.example.com", Weight: 1},
+	}
+
+	tests := map[string]struct {
+		sticky           bool
+		cookie           *http.Cookie
+		draw             int
+		recordError      error
+		expectedVariant  int
+		expectedLocation string
+		expectCookie     bool
+	}{
+		"Weighted pick": {
+			draw:             1,
+			expectedVariant:  1,
+			expectedLocation: "http://b.example.com",
+		},
+		"Sticky sets cookie": {
+			sticky:           true,
+			draw:             0,
+			expectedVariant:  0,
+			expectedLocation: "http://a.example.com",
+			expectCookie:     true,
+		},
+		"Sticky cookie wins": {
+			sticky:           true,
+			cookie:           &http.Cookie{Name: VariantCookiePrefix + "b", Value: "1"},
+			draw:             0,
+			expectedVariant:  1,
+			expectedLocation: "http://b.example.com",
+			expectCookie:     true,
+		},
+		"Click count failure still redirects": {
+			draw:             0,
+			recordError:      errors.New("error"),
+			expectedVariant:  0,
+			expectedLocation: "http://a.example.com",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pickIntn = func(int) int { return tc.draw }
+			defer func() { pickIntn = rand.IntN }()
+
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("ResolveLink", mock.Anything, "b").Return(&urlshortener.Link{
+				ShortURL:    "b",
+				OriginalURL: "http://www.example.com",
+				LinkOptions: urlshortener.LinkOptions{Destinations: destinations, Sticky: tc.sticky},
+			}, nil)
+			mockProvider.On("RecordVariantClick", mock.Anything, "b", tc.expectedVariant).Return(tc.recordError)
+
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/b", nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+
+			cookies := w.Result().Cookies()
+			if tc.expectCookie {
+				assert.Len(t, cookies, 1)
+				assert.Equal(t, VariantCookiePrefix+"b", cookies[0].Name)
+				assert.Equal(t, strconv.Itoa(tc.expectedVariant), cookies[0].Value)
+			} else {
+				assert.Empty(t, cookies)
+			}
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RedirectHandler_Rules(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	link := &urlshortener.Link{
+		ShortURL:    "b",
+		OriginalURL: "http://www.example.com",
+		LinkOptions: urlshortener.LinkOptions{
+			Destinations: []urlshortener.Destination{
+				{URL: "http://a.example.com", Weight: 1},
+				{URL: "http://b.example.com", Weight: 1},
+			},
+			Rules: []urlshortener.Rule{
+				{Device: urlshortener.DeviceIOS, URL: "https://apps.apple.com/app"},
+				{Language: "de", URL: "http://de.example.com"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		userAgent        string
+		acceptLanguage   string
+		expectedLocation string
+		expectClick      bool
+	}{
+		"Device rule": {
+			userAgent:        "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+			expectedLocation: "https://apps.apple.com/app",
+		},
+		"Language rule": {
+			acceptLanguage:   "de-AT, en;q=0.5",
+			expectedLocation: "http://de.example.com",
+		},
+		"No rule falls back to destinations": {
+			acceptLanguage:   "en-US",
+			expectedLocation: "http://a.example.com",
+			expectClick:      true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pickIntn = func(int) int { return 0 }
+			defer func() { pickIntn = rand.IntN }()
+
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("ResolveLink", mock.Anything, "b").Return(link, nil)
+			if tc.expectClick {
+				mockProvider.On("RecordVariantClick", mock.Anything, "b", 0).Return(nil)
+			}
+
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/b", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tc.expectedLocation, w.Header().Get("Location"))
+			assert.Equal(t, "User-Agent, Accept-Language", w.Header().Get("Vary"))
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RedirectHandler_ClickBudget(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	const browser = "Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0"
+
+	tests := map[string]struct {
+		method        string
+		userAgent     string
+		headers       map[string]string
+		bots          bool
+		resolveError  error
+		consumeError  error
+		expectConsume bool
+		expectedCode  int
+		expectResolve bool
+	}{
+		"Click consumed": {
+			expectConsume: true,
+			expectedCode:  http.StatusFound,
+		},
+		"HEAD request gets no destination": {
+			method:       http.MethodHead,
+			bots:         true,
+			expectedCode: http.StatusNoContent,
+		},
+		"HEAD request without bot detection gets no destination": {
+			method:       http.MethodHead,
+			expectedCode: http.StatusNoContent,
+		},
+		"Bot gets no destination": {
+			userAgent:    "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			bots:         true,
+			expectedCode: http.StatusNoContent,
+		},
+		"Prefetch gets no destination": {
+			headers:      map[string]string{"Sec-Purpose": "prefetch"},
+			bots:         true,
+			expectedCode: http.StatusNoContent,
+		},
+		"Budget used up by a concurrent redirect": {
+			consumeError:  urlshortener.ErrLinkExhausted,
+			expectConsume: true,
+			expectedCode:  http.StatusGone,
+		},
+		"Budget already used up": {
+			resolveError: urlshortener.ErrLinkExhausted,
+			expectedCode: http.StatusGone,
+		},
+		"Consume failure": {
+			consumeError:  errors.New("error"),
+			expectConsume: true,
+			expectedCode:  http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.resolveError != nil {
+				mockProvider.On("ResolveLink", mock.Anything, "b").Return(nil, tc.resolveError)
+			} else {
+				mockProvider.On("ResolveLink", mock.Anything, "b").Return(&urlshortener.Link{
+					ShortURL:        "b",
+					OriginalURL:     "http://www.example.com",
+					ClicksRemaining: 1,
+					LinkOptions:     urlshortener.LinkOptions{MaxClicks: 1},
+				}, nil)
+			}
+			if tc.expectConsume {
+				mockProvider.On("ConsumeClick", mock.Anything, "b").Return(tc.consumeError)
+			}
+
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+			}
+			if tc.bots {
+				handler.Bots = &botdetect.Classifier{}
+			}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/b", nil)
+			userAgent := tc.userAgent
+			if userAgent == "" {
+				userAgent = browser
+			}
+			req.Header.Set("User-Agent", userAgent)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.Head(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusFound {
+				assert.Equal(t, "http://www.example.com", w.Header().Get("Location"))
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			} else {
+				assert.Empty(t, w.Header().Get("Location"))
+				assert.NotContains(t, w.Body.String(), "www.example.com")
+			}
+			mockProvider.AssertExpectations(t)
+			if !tc.expectConsume {
+				mockProvider.AssertNotCalled(t, "ConsumeClick", mock.Anything, "b")
+			}
+		})
+	}
+}
+
+type MockEmitter struct {
+	mock.Mock
+}
+
+func (m *MockEmitter) Emit(_ context.Context, eventType webhook.EventType, data any) {
+	m.Called(eventType, data)
+}
+
+type MockClickRecorder struct {
+	mock.Mock
+}
+
+func (m *MockClickRecorder) Record(_ context.Context, click urlshortener.Click, clientIP string) {
+	m.Called(click, clientIP)
+}
+
+func Test_RedirectHandler_Events(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	tests := map[string]struct {
+		resolveError error
+		expectClick  bool
+	}{
+		"Click reported": {
+			expectClick: true,
+		},
+		"Failed redirect not reported": {
+			resolveError: urlshortener.ErrLinkDisabled,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockProvider := new(MockUrlShortenerProvider)
+			if tc.resolveError != nil {
+				mockProvider.On("ResolveLink", mock.Anything, "b").Return(nil, tc.resolveError)
+			} else {
+				mockProvider.On("ResolveLink", mock.Anything, "b").Return(&urlshortener.Link{
+					ShortURL:    "b",
+					OriginalURL: "http://www.example.com",
+				}, nil)
+			}
+			events := new(MockEmitter)
+			events.On("Emit", webhook.LinkClicked, mock.MatchedBy(func(c *urlshortener.Click) bool {
+				return c.ShortURL == "b" && c.Destination == "http://www.example.com" &&
+					c.Referrer == "https://news.example.com" && c.UserAgent == "test-agent" && !c.ClickedAt.IsZero()
+			})).Return()
+			clicks := new(MockClickRecorder)
+			clicks.On("Record", mock.MatchedBy(func(c urlshortener.Click) bool {
+				return c.ShortURL == "b" && c.Destination == "http://www.example.com" && c.UserAgent == "test-agent" && c.Country == "DE"
+			}), "203.0.113.7").Return()
+
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+				Events:               events,
+				Clicks:               clicks,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/b", nil)
+			req.RemoteAddr = "203.0.113.7:4711"
+			req.Header.Set("Referer", "https://news.example.com")
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set(urlshortener.CountryHeader, "de")
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			if tc.expectClick {
+				events.AssertExpectations(t)
+				clicks.AssertExpectations(t)
+			} else {
+				events.AssertNotCalled(t, "Emit", mock.Anything, mock.Anything)
+				clicks.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func Test_RedirectHandler_Bots(t *testing.T) {
+	logger, _ := zap.NewProduction()
+
+	tests := map[string]struct {
+		method      string
+		headers     map[string]string
+		expectedBot botdetect.Reason
+	}{
+		"Person counted": {
+			method:  http.MethodGet,
+			headers: map[string]string{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0"},
+		},
+		"Unfurler tagged": {
+			method:      http.MethodGet,
+			headers:     map[string]string{"User-Agent": "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+			expectedBot: botdetect.UserAgent,
+		},
+		"Prefetch tagged": {
+			method:      http.MethodGet,
+			headers:     map[string]string{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0", "X-Moz": "prefetch"},
+			expectedBot: botdetect.Prefetch,
+		},
+		"HEAD tagged": {
+			method:      http.MethodHead,
+			headers:     map[string]string{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0"},
+			expectedBot: botdetect.Head,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pickIntn = func(int) int { return 0 }
+			defer func() { pickIntn = rand.IntN }()
+
+			mockProvider := new(MockUrlShortenerProvider)
+			mockProvider.On("ResolveLink", mock.Anything, "b").Return(&urlshortener.Link{
+				ShortURL:    "b",
+				OriginalURL: "http://www.example.com",
+				LinkOptions: urlshortener.LinkOptions{Destinations: []urlshortener.Destination{
+					{URL: "http://a.example.com", Weight: 1},
+					{URL: "http://b.example.com", Weight: 1},
+				}},
+			}, nil)
+			if tc.expectedBot == "" {
+				mockProvider.On("RecordVariantClick", mock.Anything, "b", 0).Return(nil)
+			}
+			clicks := new(MockClickRecorder)
+			clicks.On("Record", mock.MatchedBy(func(c urlshortener.Click) bool {
+				return c.ShortURL == "b" && c.Bot == string(tc.expectedBot)
+			}), mock.Anything).Return()
+
+			handler := &Handler{
+				Logger:               logger,
+				UrlShortenerProvider: mockProvider,
+				Clicks:               clicks,
+				Bots:                 &botdetect.Classifier{},
+			}
+
+			req := httptest.NewRequest(tc.method, "/b", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get(RedirectEndpoint, handler.RedirectHandler())
+			r.Head(RedirectEndpoint, handler.RedirectHandler())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, "http://a.example.com", w.Header().Get("Location"))
+			mockProvider.AssertExpectations(t)
+			clicks.AssertExpectations(t)
+		})
+	}
+}
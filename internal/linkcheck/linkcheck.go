@@ -0,0 +1,189 @@
+// Package linkcheck probes link destinations to find the ones that stopped working.
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/netaddr"
+)
+
+type (
+	// Checker sends a HEAD request to each URL, falling back to GET when that fails, since plenty
+	// of servers answer HEAD with an error. Requests to the same host are sent one at a time with
+	// HostDelay between them.
+	Checker struct {
+		// Client defaults to NewClient(DefaultTimeout).
+		Client *http.Client
+		// Concurrency is the number of hosts checked at once, DefaultConcurrency when zero.
+		Concurrency int
+		// HostDelay is the pause between requests to the same host, DefaultHostDelay when zero.
+		HostDelay time.Duration
+		UserAgent string
+
+		mu   sync.Mutex
+		last map[string]time.Time
+	}
+
+	// Result is the outcome of checking one URL. StatusCode is zero when no response came back.
+	Result struct {
+		URL        string
+		StatusCode int
+		Err        error
+	}
+)
+
+const (
+	DefaultConcurrency = 8
+	DefaultHostDelay   = time.Second
+	DefaultTimeout     = 10 * time.Second
+	DefaultUserAgent   = "url-shortener-linkcheck/1.0"
+
+	// maxBodyRead is how much of a GET response is drained so the connection can be reused.
+	maxBodyRead = 64 << 10
+)
+
+// Broken reports whether the destination is gone: it could not be reached, or answered 404, 410
+// or a server error.
+func (r Result) Broken() bool {
+	return r.Err != nil || r.StatusCode == http.StatusNotFound || r.StatusCode == http.StatusGone ||
+		r.StatusCode >= http.StatusInternalServerError
+}
+
+// NewClient returns a client that refuses to connect to non-public addresses, so links to hosts
+// resolving to internal services cannot be used to probe them.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: netaddr.DialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// CheckAll checks every distinct URL in urls, keyed by URL. Hosts are spread over Concurrency
+// workers, and each worker goes through the URLs of its host in order.
+func (c *Checker) CheckAll(ctx context.Context, urls []string) map[string]Result {
+	results := make(map[string]Result, len(urls))
+	var hosts []string
+	byHost := map[string][]string{}
+	for _, rawURL := range urls {
+		if _, seen := results[rawURL]; seen {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			results[rawURL] = Result{URL: rawURL, Err: fmt.Errorf("%q is not an absolute http(s) URL", rawURL)}
+			continue
+		}
+		results[rawURL] = Result{}
+		if _, ok := byHost[u.Host]; !ok {
+			hosts = append(hosts, u.Host)
+		}
+		byHost[u.Host] = append(byHost[u.Host], rawURL)
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	queue := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(concurrency, len(hosts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range queue {
+				for _, rawURL := range byHost[host] {
+					result := c.check(ctx, host, rawURL)
+					mu.Lock()
+					results[rawURL] = result
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, host := range hosts {
+		queue <- host
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// check waits until host may be contacted again and probes rawURL.
+func (c *Checker) check(ctx context.Context, host, rawURL string) Result {
+	if err := c.wait(ctx, host); err != nil {
+		return Result{URL: rawURL, Err: err}
+	}
+	defer c.done(host)
+
+	status, err := c.probe(ctx, http.MethodHead, rawURL)
+	if err != nil || status >= http.StatusBadRequest {
+		status, err = c.probe(ctx, http.MethodGet, rawURL)
+	}
+	return Result{URL: rawURL, StatusCode: status, Err: err}
+}
+
+func (c *Checker) probe(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	client := c.Client
+	if client == nil {
+		client = NewClient(DefaultTimeout)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyRead))
+	return resp.StatusCode, nil
+}
+
+// wait sleeps until HostDelay has passed since the last request to host finished. The delay is
+// remembered across calls, so a job checking links page by page stays polite too.
+func (c *Checker) wait(ctx context.Context, host string) error {
+	delay := c.HostDelay
+	if delay == 0 {
+		delay = DefaultHostDelay
+	}
+	c.mu.Lock()
+	last, ok := c.last[host]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Until(last.Add(delay)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Checker) done(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil {
+		c.last = map[string]time.Time{}
+	}
+	c.last[host] = time.Now()
+}
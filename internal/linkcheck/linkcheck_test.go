@@ -0,0 +1,163 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/netaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckAll(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		assert.Equal(t, "test-agent", r.UserAgent())
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/error":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	checker := &Checker{Client: srv.Client(), HostDelay: time.Millisecond, UserAgent: "test-agent"}
+	results := checker.CheckAll(context.Background(), []string{
+		srv.URL + "/ok",
+		srv.URL + "/ok",
+		srv.URL + "/no-head",
+		srv.URL + "/moved",
+		srv.URL + "/missing",
+		srv.URL + "/error",
+		srv.URL + "/forbidden",
+		closed.URL + "/ok",
+		"mailto:someone@example.com",
+	})
+
+	tests := map[string]struct {
+		url            string
+		expectedStatus int
+		expectedBroken bool
+	}{
+		"Happy Path":                 {url: srv.URL + "/ok", expectedStatus: http.StatusOK},
+		"Happy Path GET fallback":    {url: srv.URL + "/no-head", expectedStatus: http.StatusOK},
+		"Happy Path forbidden":       {url: srv.URL + "/forbidden", expectedStatus: http.StatusForbidden},
+		"Sad Path redirect to gone":  {url: srv.URL + "/moved", expectedStatus: http.StatusGone, expectedBroken: true},
+		"Sad Path not found":         {url: srv.URL + "/missing", expectedStatus: http.StatusNotFound, expectedBroken: true},
+		"Sad Path server error":      {url: srv.URL + "/error", expectedStatus: http.StatusBadGateway, expectedBroken: true},
+		"Sad Path connection failed": {url: closed.URL + "/ok", expectedBroken: true},
+		"Sad Path not http":          {url: "mailto:someone@example.com", expectedBroken: true},
+	}
+	assert.Len(t, results, len(tests))
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := results[tc.url]
+			assert.Equal(t, tc.url, result.URL)
+			assert.Equal(t, tc.expectedStatus, result.StatusCode)
+			assert.Equal(t, tc.expectedBroken, result.Broken())
+		})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, count(methods, "HEAD /ok"), "duplicate URLs are checked once")
+	assert.Equal(t, 1, count(methods, "GET /no-head"))
+	assert.Equal(t, 0, count(methods, "GET /ok"))
+}
+
+func Test_CheckAll_Politeness(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	var inFlight, maxInFlight atomic.Int32
+	var mu sync.Mutex
+	seen := map[string][]time.Time{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		mu.Lock()
+		seen[r.Host] = append(seen[r.Host], time.Now())
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	var urls []string
+	for range 3 {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+		urls = append(urls, srv.URL+"/a", srv.URL+"/b", srv.URL+"/c")
+	}
+
+	checker := &Checker{Client: http.DefaultClient, Concurrency: 2, HostDelay: delay}
+	results := checker.CheckAll(context.Background(), urls)
+	assert.Len(t, results, len(urls))
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, seen, 3)
+	for host, times := range seen {
+		assert.Len(t, times, 3, host)
+		for i := 1; i < len(times); i++ {
+			assert.GreaterOrEqual(t, times[i].Sub(times[i-1]), delay, host)
+		}
+	}
+}
+
+func Test_CheckAll_Canceled(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	checker := &Checker{Client: srv.Client(), HostDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	checker.CheckAll(ctx, []string{srv.URL + "/a"})
+	cancel()
+
+	results := checker.CheckAll(ctx, []string{srv.URL + "/b"})
+	assert.ErrorIs(t, results[srv.URL+"/b"].Err, context.Canceled)
+}
+
+func Test_NewClient(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	result := (&Checker{Client: NewClient(time.Second)}).CheckAll(context.Background(), []string{srv.URL})[srv.URL]
+	assert.ErrorIs(t, result.Err, netaddr.ErrNotPublic)
+	assert.True(t, result.Broken())
+}
+
+func count(values []string, value string) int {
+	n := 0
+	for _, v := range values {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
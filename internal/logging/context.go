@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type (
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+// WithLogger returns a copy of ctx carrying the request scoped logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request scoped logger stored in ctx, or fallback if there is none.
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return fallback
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID resolves the ID of the incoming request, preferring the API Gateway request context,
+// then the X-Request-Id header, and generating one otherwise. The ID and a logger tagged with it
+// are stored on the request context and the ID is echoed back in the response headers.
+func RequestID(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := resolveRequestID(r)
+
+			ctx := WithRequestID(r.Context(), requestID)
+			ctx = WithLogger(ctx, logger.With(zap.String(logkey.RequestID, requestID)))
+
+			w.Header().Set(RequestIDHeader, requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AccessLog writes one structured line per request once the response has been written.
+// It should be mounted after RequestID so the line carries the request ID.
+func AccessLog(logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				FromContext(r.Context(), logger).Info("request completed",
+					zap.String(logkey.Method, r.Method),
+					zap.String(logkey.Path, r.URL.Path),
+					zap.Int(logkey.Status, status),
+					zap.Int(logkey.Bytes, ww.BytesWritten()),
+					zap.Duration(logkey.Duration, time.Since(start)),
+					zap.String(logkey.RemoteAddr, r.RemoteAddr),
+					zap.String(logkey.UserAgent, r.UserAgent()),
+				)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func resolveRequestID(r *http.Request) string {
+	if apiCtx, ok := core.GetAPIGatewayContextFromContext(r.Context()); ok && apiCtx.RequestID != "" {
+		return apiCtx.RequestID
+	}
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		return requestID
+	}
+	return newRequestID()
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
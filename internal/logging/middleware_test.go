@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_RequestID(t *testing.T) {
+	tests := map[string]struct {
+		header      string
+		apiGateway  bool
+		expectedID  string
+		generatedID bool
+	}{
+		"API Gateway request ID": {
+			header:     "from-header",
+			apiGateway: true,
+			expectedID: "from-gateway",
+		},
+		"Header request ID": {
+			header:     "from-header",
+			expectedID: "from-header",
+		},
+		"Generated request ID": {
+			generatedID: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			obs, logs := observer.New(zapcore.InfoLevel)
+			logger := zap.New(obs)
+
+			req := httptest.NewRequest(http.MethodGet, "/b", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			if tc.apiGateway {
+				req = withGatewayRequestID(t, req, "from-gateway")
+			}
+
+			var gotID string
+			handler := RequestID(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = RequestIDFromContext(r.Context())
+				FromContext(r.Context(), nil).Info("inside handler")
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.generatedID {
+				assert.Len(t, gotID, 32)
+			} else {
+				assert.Equal(t, tc.expectedID, gotID)
+			}
+			assert.Equal(t, gotID, rr.Header().Get(RequestIDHeader))
+
+			entries := logs.All()
+			assert.Len(t, entries, 1)
+			assert.Equal(t, gotID, entries[0].ContextMap()[logkey.RequestID])
+		})
+	}
+}
+
+func Test_AccessLog(t *testing.T) {
+	obs, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(obs)
+
+	handler := RequestID(logger)(AccessLog(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/shorten", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "abc", fields[logkey.RequestID])
+	assert.Equal(t, http.MethodPost, fields[logkey.Method])
+	assert.Equal(t, "/shorten", fields[logkey.Path])
+	assert.EqualValues(t, http.StatusTeapot, fields[logkey.Status])
+	assert.EqualValues(t, len("short and stout"), fields[logkey.Bytes])
+}
+
+func Test_FromContext_Fallback(t *testing.T) {
+	fallback := zap.NewNop()
+	assert.Equal(t, fallback, FromContext(context.Background(), fallback))
+	assert.Equal(t, "", RequestIDFromContext(context.Background()))
+}
+
+func withGatewayRequestID(t *testing.T, req *http.Request, requestID string) *http.Request {
+	t.Helper()
+	accessor := core.RequestAccessor{}
+	proxied, err := accessor.EventToRequestWithContext(req.Context(), events.APIGatewayProxyRequest{
+		HTTPMethod:     req.Method,
+		Path:           req.URL.Path,
+		Headers:        map[string]string{RequestIDHeader: req.Header.Get(RequestIDHeader)},
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: requestID},
+	})
+	assert.NoError(t, err)
+	return proxied
+}
@@ -0,0 +1,47 @@
+// Package netaddr classifies IP addresses.
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+// ErrNotPublic is returned by DialControl for connections to non-public addresses.
+var ErrNotPublic = errors.New("destination is not a public address")
+
+// reserved are the non-public ranges netip has no predicate for.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Public reports whether addr is reachable on the internet, as opposed to private, loopback,
+// link-local or otherwise reserved. IPv4-mapped IPv6 addresses are judged by their IPv4 address.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// DialControl is a net.Dialer Control function refusing to connect to non-public addresses. It
+// runs after name resolution, so host names pointing at internal services are caught too.
+func DialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNotPublic, addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package netaddr
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Public(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":                      true,
+		"2606:2800:220:1:248:1893:25c8:1946": true,
+		"127.0.0.1":                          false,
+		"10.1.2.3":                           false,
+		"172.16.0.1":                         false,
+		"192.168.1.1":                        false,
+		"169.254.169.254":                    false,
+		"100.64.0.1":                         false,
+		"0.0.0.0":                            false,
+		"0.1.2.3":                            false,
+		"::1":                                false,
+		"fd00::1":                            false,
+		"fe80::1":                            false,
+		"::ffff:10.0.0.1":                    false,
+	}
+	for addr, public := range tests {
+		t.Run(addr, func(t *testing.T) {
+			assert.Equal(t, public, Public(netip.MustParseAddr(addr)))
+		})
+	}
+	assert.False(t, Public(netip.Addr{}))
+}
+
+func Test_DialControl(t *testing.T) {
+	assert.NoError(t, DialControl("tcp", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, DialControl("tcp", "127.0.0.1:80", nil), ErrNotPublic)
+	assert.ErrorIs(t, DialControl("tcp6", "[::1]:80", nil), ErrNotPublic)
+	assert.Error(t, DialControl("tcp", "not an address", nil))
+}
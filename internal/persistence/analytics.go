@@ -0,0 +1,272 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/smithy-go"
+)
+
+type (
+	// AggregateUpdate adds Counters to the counter attributes of the aggregate item Metric, Bucket.
+	AggregateUpdate struct {
+		Metric   string
+		Bucket   string
+		Counters map[string]int64
+		// Limit keeps counters named after client input, such as referrer domains, from growing
+		// the item without bound.
+		Limit *CounterLimit
+	}
+
+	// CounterLimit only adds Counter, one of the update's counters, while the item holds fewer
+	// than MaxLimitedCounters distinct limited counters or already has Counter. Otherwise its
+	// value is added to Fallback instead.
+	CounterLimit struct {
+		Counter  string
+		Fallback string
+	}
+
+	// AggregateItem holds the counters of one bucket of a metric.
+	AggregateItem struct {
+		Bucket   string
+		Counters map[string]int64
+	}
+)
+
+const (
+	ClickTable     = "url-clicks"
+	AggregateTable = "url-aggregates"
+	ClickID        = "click_id"
+	// Metric and Bucket are the partition and sort key of the aggregate table, e.g. the clicks of
+	// a link per hour and the hour they were counted in.
+	Metric = "metric"
+	Bucket = "bucket"
+	// ProcessedMetric marks the stream events already counted, one partition per event ID
+	// (processed#<event ID>) so the markers are spread over the table, all under ProcessedBucket.
+	ProcessedMetric = "processed"
+	ProcessedBucket = "event"
+	// ProcessedRetention outlives the 24 hours a stream keeps records, so a replayed event is
+	// always recognized.
+	ProcessedRetention = 48 * time.Hour
+	// MaxBatchWrite is the most items BatchWriteItem takes at once.
+	MaxBatchWrite = 25
+	// LimitedCounters is the string set of the limited counters an aggregate item holds, and
+	// MaxLimitedCounters how many it may hold, which keeps items far below the 400KB item limit.
+	LimitedCounters    = "limited_counters"
+	MaxLimitedCounters = 100
+
+	conditionalCheckFailed = "ConditionalCheckFailed"
+)
+
+// ErrAggregateRejected is returned when DynamoDB refuses an aggregate update as invalid, e.g.
+// because the item would grow too large. Retrying the same update cannot succeed.
+var ErrAggregateRejected = errors.New("aggregate update rejected")
+
+// PutClicks writes click items to the click table, MaxBatchWrite at a time. It returns the items
+// DynamoDB did not process, which are worth writing again.
+func (db *UrlDB) PutClicks(ctx context.Context, items []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	var unprocessed []map[string]types.AttributeValue
+	for start := 0; start < len(items); start += MaxBatchWrite {
+		requests := make([]types.WriteRequest, 0, MaxBatchWrite)
+		for _, item := range items[start:min(start+MaxBatchWrite, len(items))] {
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+		result, err := db.DBClient.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{db.ClickTable: requests},
+		})
+		if err != nil {
+			return append(unprocessed, items[start:]...), err
+		}
+		for _, r := range result.UnprocessedItems[db.ClickTable] {
+			if r.PutRequest != nil {
+				unprocessed = append(unprocessed, r.PutRequest.Item)
+			}
+		}
+	}
+	return unprocessed, nil
+}
+
+// ApplyAggregates adds the counters of updates in one transaction that also records eventID as
+// processed. It reports false without changing anything when eventID was processed before, so
+// replaying a stream record never counts it twice. Limited counters over their limit are folded
+// into their fallback and the transaction is sent again.
+func (db *UrlDB) ApplyAggregates(ctx context.Context, eventID string, updates []AggregateUpdate) (bool, error) {
+	applied, limited, err := db.applyAggregates(ctx, eventID, updates)
+	if len(limited) == 0 {
+		return applied, err
+	}
+
+	folded := make([]AggregateUpdate, len(updates))
+	copy(folded, updates)
+	for _, i := range limited {
+		folded[i] = folded[i].fold()
+	}
+	applied, _, err = db.applyAggregates(ctx, eventID, folded)
+	return applied, err
+}
+
+// applyAggregates runs the transaction of ApplyAggregates. When it is canceled only because
+// limited counters were over their limit, it returns the indexes of those updates instead of an
+// error.
+func (db *UrlDB) applyAggregates(ctx context.Context, eventID string, updates []AggregateUpdate) (bool, []int, error) {
+	items := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName: &db.AggregateTable,
+			Item: map[string]types.AttributeValue{
+				Metric:    &types.AttributeValueMemberS{Value: ProcessedMetric + "#" + eventID},
+				Bucket:    &types.AttributeValueMemberS{Value: ProcessedBucket},
+				ExpiresAt: &types.AttributeValueMemberN{Value: strconv.FormatInt(db.now().Add(ProcessedRetention).Unix(), 10)},
+			},
+			ConditionExpression: aws.String(fmt.Sprintf("attribute_not_exists(%s)", Metric)),
+		},
+	}}
+	// indexes maps the transaction items after the processed marker to their update
+	var indexes []int
+	for i, u := range updates {
+		if len(u.Counters) == 0 {
+			continue
+		}
+		items = append(items, types.TransactWriteItem{Update: db.aggregateUpdate(u)})
+		indexes = append(indexes, i)
+	}
+
+	_, err := db.DBClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) && len(canceled.CancellationReasons) > 0 {
+		reasons := canceled.CancellationReasons
+		if aws.StringValue(reasons[0].Code) == conditionalCheckFailed {
+			return false, nil, nil
+		}
+		var limited []int
+		for i, reason := range reasons[1:] {
+			switch aws.StringValue(reason.Code) {
+			case conditionalCheckFailed:
+				if i < len(indexes) {
+					limited = append(limited, indexes[i])
+				}
+			case "ValidationError":
+				return false, nil, fmt.Errorf("%w: %w", ErrAggregateRejected, err)
+			}
+		}
+		if len(limited) > 0 {
+			return false, limited, nil
+		}
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException" {
+		return false, nil, fmt.Errorf("%w: %w", ErrAggregateRejected, err)
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, nil, nil
+}
+
+// aggregateUpdate adds the counters of u to its item, on the condition that a limited counter
+// is still within its limit.
+func (db *UrlDB) aggregateUpdate(u AggregateUpdate) *types.Update {
+	names := make(map[string]string, len(u.Counters))
+	values := make(map[string]types.AttributeValue, len(u.Counters))
+	adds := make([]string, 0, len(u.Counters))
+	counters := make([]string, 0, len(u.Counters))
+	for counter := range u.Counters {
+		counters = append(counters, counter)
+	}
+	sort.Strings(counters)
+	for i, counter := range counters {
+		names[fmt.Sprintf("#c%d", i)] = counter
+		values[fmt.Sprintf(":c%d", i)] = &types.AttributeValueMemberN{Value: strconv.FormatInt(u.Counters[counter], 10)}
+		adds = append(adds, fmt.Sprintf("#c%[1]d :c%[1]d", i))
+	}
+
+	update := &types.Update{
+		TableName: &db.AggregateTable,
+		Key: map[string]types.AttributeValue{
+			Metric: &types.AttributeValueMemberS{Value: u.Metric},
+			Bucket: &types.AttributeValueMemberS{Value: u.Bucket},
+		},
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+	if u.Limit != nil {
+		names["#limited"] = LimitedCounters
+		values[":limited"] = &types.AttributeValueMemberSS{Value: []string{u.Limit.Counter}}
+		values[":counter"] = &types.AttributeValueMemberS{Value: u.Limit.Counter}
+		values[":max"] = &types.AttributeValueMemberN{Value: strconv.Itoa(MaxLimitedCounters)}
+		adds = append(adds, "#limited :limited")
+		update.ConditionExpression = aws.String("attribute_not_exists(#limited) OR contains(#limited, :counter) OR size(#limited) < :max")
+	}
+	update.UpdateExpression = aws.String("ADD " + strings.Join(adds, ", "))
+	return update
+}
+
+// fold moves the value of the limited counter to its fallback.
+func (u AggregateUpdate) fold() AggregateUpdate {
+	counters := make(map[string]int64, len(u.Counters))
+	for counter, n := range u.Counters {
+		counters[counter] = n
+	}
+	n := counters[u.Limit.Counter]
+	delete(counters, u.Limit.Counter)
+	counters[u.Limit.Fallback] += n
+	return AggregateUpdate{Metric: u.Metric, Bucket: u.Bucket, Counters: counters}
+}
+
+// QueryAggregates reads the buckets of metric from the bucket from through the bucket to, in
+// order. Buckets nothing was counted in are not stored and so not returned.
+func (db *UrlDB) QueryAggregates(ctx context.Context, metric, from, to string) ([]AggregateItem, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              &db.AggregateTable,
+		KeyConditionExpression: aws.String("#pk = :pk AND #sk BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": Metric,
+			"#sk": Bucket,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":   &types.AttributeValueMemberS{Value: metric},
+			":from": &types.AttributeValueMemberS{Value: from},
+			":to":   &types.AttributeValueMemberS{Value: to},
+		},
+	}
+
+	var items []AggregateItem
+	for {
+		result, err := db.DBClient.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			aggregate := AggregateItem{Counters: map[string]int64{}}
+			for name, value := range item {
+				switch v := value.(type) {
+				case *types.AttributeValueMemberS:
+					if name == Bucket {
+						aggregate.Bucket = v.Value
+					}
+				case *types.AttributeValueMemberN:
+					if name == ExpiresAt {
+						continue
+					}
+					n, err := strconv.ParseInt(v.Value, 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid counter %s: %w", name, err)
+					}
+					aggregate.Counters[name] = n
+				}
+			}
+			items = append(items, aggregate)
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
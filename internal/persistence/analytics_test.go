@@ -0,0 +1,331 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func clickItem(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{ClickID: &types.AttributeValueMemberS{Value: id}}
+}
+
+func Test_PutClicks(t *testing.T) {
+	items := make([]map[string]types.AttributeValue, 30)
+	for i := range items {
+		items[i] = clickItem(strconv.Itoa(i))
+	}
+
+	tests := map[string]struct {
+		unprocessed         []map[string]types.AttributeValue
+		writeError          error
+		expectedUnprocessed int
+		checkError          bool
+	}{
+		"PutClicks Happy Path": {},
+		"PutClicks unprocessed items": {
+			unprocessed:         items[3:5],
+			expectedUnprocessed: 4,
+		},
+		"PutClicks Sad Path": {
+			writeError:          errors.New("error"),
+			expectedUnprocessed: 30,
+			checkError:          true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var unprocessed map[string][]types.WriteRequest
+			for _, item := range tc.unprocessed {
+				if unprocessed == nil {
+					unprocessed = map[string][]types.WriteRequest{}
+				}
+				unprocessed[ClickTable] = append(unprocessed[ClickTable], types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+			}
+			m := &MockDynamoDBClient{}
+			m.On("BatchWriteItem", context.Background(), mock.MatchedBy(func(in *dynamodb.BatchWriteItemInput) bool {
+				requests := in.RequestItems[ClickTable]
+				return len(requests) == MaxBatchWrite && requests[0].PutRequest.Item[ClickID].(*types.AttributeValueMemberS).Value == "0"
+			})).Return(&dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, tc.writeError).Once()
+			m.On("BatchWriteItem", context.Background(), mock.MatchedBy(func(in *dynamodb.BatchWriteItemInput) bool {
+				return len(in.RequestItems[ClickTable]) == 5
+			})).Return(&dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, nil).Maybe()
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, ClickTable: ClickTable}
+			left, err := db.PutClicks(context.Background(), items)
+
+			assert.Equal(t, tc.checkError, err != nil)
+			assert.Len(t, left, tc.expectedUnprocessed)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ApplyAggregates(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	aggregateTable := AggregateTable
+	expected := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: &aggregateTable,
+					Item: map[string]types.AttributeValue{
+						Metric:    &types.AttributeValueMemberS{Value: "processed#evt-1"},
+						Bucket:    &types.AttributeValueMemberS{Value: ProcessedBucket},
+						ExpiresAt: &types.AttributeValueMemberN{Value: "1740992400"},
+					},
+					ConditionExpression: aws.String("attribute_not_exists(metric)"),
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: &aggregateTable,
+					Key: map[string]types.AttributeValue{
+						Metric: &types.AttributeValueMemberS{Value: "clicks#b#hour"},
+						Bucket: &types.AttributeValueMemberS{Value: "2025-03-01T09"},
+					},
+					UpdateExpression:          aws.String("ADD #c0 :c0, #c1 :c1"),
+					ExpressionAttributeNames:  map[string]string{"#c0": "clicks", "#c1": "referrer#example.com"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{":c0": &types.AttributeValueMemberN{Value: "1"}, ":c1": &types.AttributeValueMemberN{Value: "1"}},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		transactError   error
+		expectedApplied bool
+		checkError      bool
+		checkRejected   bool
+	}{
+		"ApplyAggregates Happy Path": {
+			expectedApplied: true,
+		},
+		"ApplyAggregates already processed": {
+			transactError: &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")},
+			}},
+		},
+		"ApplyAggregates Sad Path conflict": {
+			transactError: &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")}, {Code: aws.String("TransactionConflict")},
+			}},
+			checkError: true,
+		},
+		"ApplyAggregates Sad Path invalid update": {
+			transactError: &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")}, {Code: aws.String("ValidationError")},
+			}},
+			checkError:    true,
+			checkRejected: true,
+		},
+		"ApplyAggregates Sad Path validation exception": {
+			transactError: &smithy.GenericAPIError{Code: "ValidationException", Message: "Item size has exceeded the maximum allowed size"},
+			checkError:    true,
+			checkRejected: true,
+		},
+		"ApplyAggregates Sad Path": {
+			transactError: errors.New("error"),
+			checkError:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("TransactWriteItems", context.Background(), expected).Return(&dynamodb.TransactWriteItemsOutput{}, tc.transactError)
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, AggregateTable: AggregateTable, Now: func() time.Time { return now }}
+			applied, err := db.ApplyAggregates(context.Background(), "evt-1", []AggregateUpdate{
+				{Metric: "clicks#b#hour", Bucket: "2025-03-01T09", Counters: map[string]int64{"referrer#example.com": 1, "clicks": 1}},
+				{Metric: "clicks#b#day", Bucket: "2025-03-01"},
+			})
+
+			assert.Equal(t, tc.checkError, err != nil)
+			assert.Equal(t, tc.checkRejected, errors.Is(err, ErrAggregateRejected))
+			assert.Equal(t, tc.expectedApplied, applied)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ApplyAggregates_Limit(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	aggregateTable := AggregateTable
+	processed := types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: &aggregateTable,
+			Item: map[string]types.AttributeValue{
+				Metric:    &types.AttributeValueMemberS{Value: "processed#evt-1"},
+				Bucket:    &types.AttributeValueMemberS{Value: ProcessedBucket},
+				ExpiresAt: &types.AttributeValueMemberN{Value: "1740992400"},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(metric)"),
+		},
+	}
+	key := map[string]types.AttributeValue{
+		Metric: &types.AttributeValueMemberS{Value: "clicks#b#hour"},
+		Bucket: &types.AttributeValueMemberS{Value: "2025-03-01T09"},
+	}
+	limited := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{processed, {
+			Update: &types.Update{
+				TableName:        &aggregateTable,
+				Key:              key,
+				UpdateExpression: aws.String("ADD #c0 :c0, #c1 :c1, #limited :limited"),
+				ExpressionAttributeNames: map[string]string{
+					"#c0": "clicks", "#c1": "referrer#example.com", "#limited": LimitedCounters,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":c0":      &types.AttributeValueMemberN{Value: "1"},
+					":c1":      &types.AttributeValueMemberN{Value: "1"},
+					":limited": &types.AttributeValueMemberSS{Value: []string{"referrer#example.com"}},
+					":counter": &types.AttributeValueMemberS{Value: "referrer#example.com"},
+					":max":     &types.AttributeValueMemberN{Value: "100"},
+				},
+				ConditionExpression: aws.String("attribute_not_exists(#limited) OR contains(#limited, :counter) OR size(#limited) < :max"),
+			},
+		}},
+	}
+	folded := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{processed, {
+			Update: &types.Update{
+				TableName:                 &aggregateTable,
+				Key:                       key,
+				UpdateExpression:          aws.String("ADD #c0 :c0, #c1 :c1"),
+				ExpressionAttributeNames:  map[string]string{"#c0": "clicks", "#c1": "referrer#other"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":c0": &types.AttributeValueMemberN{Value: "1"}, ":c1": &types.AttributeValueMemberN{Value: "1"}},
+			},
+		}},
+	}
+	overLimit := &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+		{Code: aws.String("None")}, {Code: aws.String("ConditionalCheckFailed")},
+	}}
+
+	tests := map[string]struct {
+		limitedError    error
+		foldedError     error
+		expectFolded    bool
+		expectedApplied bool
+		checkError      bool
+	}{
+		"ApplyAggregates Limit Happy Path within the limit": {
+			expectedApplied: true,
+		},
+		"ApplyAggregates Limit Happy Path over the limit counts as other": {
+			limitedError:    overLimit,
+			expectFolded:    true,
+			expectedApplied: true,
+		},
+		"ApplyAggregates Limit Sad Path": {
+			limitedError: overLimit,
+			foldedError:  errors.New("error"),
+			expectFolded: true,
+			checkError:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("TransactWriteItems", context.Background(), limited).Return(&dynamodb.TransactWriteItemsOutput{}, tc.limitedError).Once()
+			if tc.expectFolded {
+				m.On("TransactWriteItems", context.Background(), folded).Return(&dynamodb.TransactWriteItemsOutput{}, tc.foldedError).Once()
+			}
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, AggregateTable: AggregateTable, Now: func() time.Time { return now }}
+			applied, err := db.ApplyAggregates(context.Background(), "evt-1", []AggregateUpdate{{
+				Metric:   "clicks#b#hour",
+				Bucket:   "2025-03-01T09",
+				Counters: map[string]int64{"referrer#example.com": 1, "clicks": 1},
+				Limit:    &CounterLimit{Counter: "referrer#example.com", Fallback: "referrer#other"},
+			}})
+
+			assert.Equal(t, tc.checkError, err != nil)
+			assert.Equal(t, tc.expectedApplied, applied)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_QueryAggregates(t *testing.T) {
+	aggregateTable := AggregateTable
+	input := func(startKey map[string]types.AttributeValue) *dynamodb.QueryInput {
+		return &dynamodb.QueryInput{
+			TableName:              &aggregateTable,
+			KeyConditionExpression: aws.String("#pk = :pk AND #sk BETWEEN :from AND :to"),
+			ExpressionAttributeNames: map[string]string{
+				"#pk": Metric,
+				"#sk": Bucket,
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":   &types.AttributeValueMemberS{Value: "clicks#b#day"},
+				":from": &types.AttributeValueMemberS{Value: "2025-03-01"},
+				":to":   &types.AttributeValueMemberS{Value: "2025-03-07"},
+			},
+			ExclusiveStartKey: startKey,
+		}
+	}
+	bucket := func(day, clicks string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			Metric:          &types.AttributeValueMemberS{Value: "clicks#b#day"},
+			Bucket:          &types.AttributeValueMemberS{Value: day},
+			"clicks":        &types.AttributeValueMemberN{Value: clicks},
+			"device#mobile": &types.AttributeValueMemberN{Value: "1"},
+		}
+	}
+	lastKey := map[string]types.AttributeValue{
+		Metric: &types.AttributeValueMemberS{Value: "clicks#b#day"},
+		Bucket: &types.AttributeValueMemberS{Value: "2025-03-01"},
+	}
+
+	tests := map[string]struct {
+		queryError    error
+		expectedItems []AggregateItem
+		checkError    bool
+	}{
+		"QueryAggregates Happy Path": {
+			expectedItems: []AggregateItem{
+				{Bucket: "2025-03-01", Counters: map[string]int64{"clicks": 3, "device#mobile": 1}},
+				{Bucket: "2025-03-04", Counters: map[string]int64{"clicks": 1, "device#mobile": 1}},
+			},
+		},
+		"QueryAggregates Sad Path": {
+			queryError: errors.New("error"),
+			checkError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("Query", context.Background(), input(nil)).Return(&dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{bucket("2025-03-01", "3")},
+				LastEvaluatedKey: lastKey,
+			}, tc.queryError).Once()
+			if tc.queryError == nil {
+				m.On("Query", context.Background(), input(lastKey)).Return(&dynamodb.QueryOutput{
+					Items: []map[string]types.AttributeValue{bucket("2025-03-04", "1")},
+				}, nil).Once()
+			}
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, AggregateTable: AggregateTable}
+			items, err := db.QueryAggregates(context.Background(), "clicks#b#day", "2025-03-01", "2025-03-07")
+
+			assert.Equal(t, tc.checkError, err != nil)
+			assert.Equal(t, tc.expectedItems, items)
+			m.AssertExpectations(t)
+		})
+	}
+}
@@ -2,14 +2,20 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/logging"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +24,36 @@ type (
 		Logger    *zap.Logger
 		DBClient  DBProvider
 		TableName string
+		// ClickTable and AggregateTable hold raw clicks and the counters derived from both tables.
+		ClickTable     string
+		AggregateTable string
+		// Now stamps created_at on new links, time.Now when nil.
+		Now func() time.Time
+	}
+
+	// LinkQuery selects a page of links. Owner picks the owner index, the other filters apply to
+	// either index.
+	LinkQuery struct {
+		Owner         string
+		Tag           string
+		Domain        string
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
+		Limit         int32
+		Ascending     bool
+		StartKey      map[string]types.AttributeValue
+	}
+
+	// EntityQuery selects a page of the items stored alongside links, such as webhook subscriptions,
+	// from the creation time index. Attribute and Value optionally narrow it down to items whose
+	// string attribute has that value.
+	EntityQuery struct {
+		Entity    string
+		Attribute string
+		Value     string
+		Limit     int32
+		Ascending bool
+		StartKey  map[string]types.AttributeValue
 	}
 
 	DBProvider interface {
@@ -25,19 +61,52 @@ type (
 		PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 		UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 		Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+		Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+		DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+		BatchWriteItem(context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+		TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 	}
 )
 
 const (
-	ShortURL      = "short_url"
-	ID            = "id"
-	OriginalURL   = "original_url"
-	DefaultRegion = "us-east-1"
-	URLTable      = "url-mapping"
-	URLCounter    = "url-counter"
-	CounterValue  = "counter_value"
+	ShortURL        = "short_url"
+	ID              = "id"
+	OriginalURL     = "original_url"
+	DefaultRegion   = "us-east-1"
+	URLTable        = "url-mapping"
+	URLCounter      = "url-counter"
+	CounterValue    = "counter_value"
+	Disabled        = "disabled"
+	VariantClicks   = "variant_clicks"
+	ClicksRemaining = "clicks_remaining"
+	Tags            = "tags"
+	Flag            = "flag"
+	Health          = "health"
+	Owner           = "owner"
+	Entity          = "entity"
+	EntityLink      = "link"
+	EntityWebhook   = "webhook"
+	EntityDelivery  = "delivery"
+	// ExpiresAt is the table's TTL attribute, DynamoDB deletes items some time after it passed.
+	ExpiresAt       = "expires_at"
+	CreatedAt       = "created_at"
+	CreatedAtLayout = "2006-01-02T15:04:05.000Z"
+	DestinationHost = "destination_host"
+	// CreatedIndex orders every link by created_at under the same entity partition. That partition
+	// takes every link write, which bounds the index to a single partition's throughput; it only
+	// serves the admin listing.
+	CreatedIndex = "links-by-created"
+	// OwnerIndex orders the links of each owner by created_at.
+	OwnerIndex     = "links-by-owner"
+	DependencyName = "dynamodb"
+	// LinkCheckCursor is kept on the url-counter item and holds the key the link check continues
+	// from.
+	LinkCheckCursor = "link_check_cursor"
 )
 
+// ErrNoClicksLeft is returned by ConsumeClick once a link has used up its click budget.
+var ErrNoClicksLeft = errors.New("no clicks left")
+
 func New(logger *zap.Logger) (*UrlDB, error) {
 	cfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithRegion(DefaultRegion),
@@ -48,27 +117,29 @@ func New(logger *zap.Logger) (*UrlDB, error) {
 
 	db := dynamodb.NewFromConfig(cfg)
 	return &UrlDB{
-		Logger:    logger,
-		DBClient:  db,
-		TableName: URLTable,
+		Logger:         logger,
+		DBClient:       db,
+		TableName:      URLTable,
+		ClickTable:     ClickTable,
+		AggregateTable: AggregateTable,
 	}, nil
 }
 
-func (db *UrlDB) GetItemByPK(shortUrl string) (*dynamodb.GetItemOutput, error) {
+func (db *UrlDB) GetItemByPK(ctx context.Context, shortUrl string) (*dynamodb.GetItemOutput, error) {
 	input := &dynamodb.GetItemInput{
 		TableName: &db.TableName,
 		Key: map[string]types.AttributeValue{
 			ShortURL: &types.AttributeValueMemberS{Value: shortUrl},
 		},
 	}
-	result, err := db.DBClient.GetItem(context.Background(), input)
+	result, err := db.DBClient.GetItem(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (db *UrlDB) GetItemByNonPK(attributeName, attributeValue string) (*dynamodb.ScanOutput, error) {
+func (db *UrlDB) GetItemByNonPK(ctx context.Context, attributeName, attributeValue string) (*dynamodb.ScanOutput, error) {
 	input := &dynamodb.ScanInput{
 		TableName:        &db.TableName,
 		FilterExpression: aws.String(fmt.Sprintf("%s = :value", attributeName)),
@@ -77,7 +148,7 @@ func (db *UrlDB) GetItemByNonPK(attributeName, attributeValue string) (*dynamodb
 		},
 	}
 
-	result, err := db.DBClient.Scan(context.Background(), input)
+	result, err := db.DBClient.Scan(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -85,13 +156,22 @@ func (db *UrlDB) GetItemByNonPK(attributeName, attributeValue string) (*dynamodb
 	return result, nil
 }
 
-func (db *UrlDB) WriteItem(id int64, shortUrl, originalUrl string) error {
+// WriteItem stores a new link. attributes holds optional per-link settings and may be nil;
+// it cannot override the key, id, original URL or the attributes the listing indexes use.
+func (db *UrlDB) WriteItem(ctx context.Context, id int64, shortUrl, originalUrl string, attributes map[string]types.AttributeValue) error {
 	idStr := aws.String(fmt.Sprintf("%d", id))
 
-	item := map[string]types.AttributeValue{
-		ShortURL:    &types.AttributeValueMemberS{Value: shortUrl},
-		ID:          &types.AttributeValueMemberN{Value: *idStr},
-		OriginalURL: &types.AttributeValueMemberS{Value: originalUrl},
+	item := make(map[string]types.AttributeValue, len(attributes)+6)
+	for k, v := range attributes {
+		item[k] = v
+	}
+	item[ShortURL] = &types.AttributeValueMemberS{Value: shortUrl}
+	item[ID] = &types.AttributeValueMemberN{Value: *idStr}
+	item[OriginalURL] = &types.AttributeValueMemberS{Value: originalUrl}
+	item[Entity] = &types.AttributeValueMemberS{Value: EntityLink}
+	item[CreatedAt] = &types.AttributeValueMemberS{Value: FormatCreatedAt(db.now())}
+	if host := DestinationHostOf(originalUrl); host != "" {
+		item[DestinationHost] = &types.AttributeValueMemberS{Value: host}
 	}
 
 	input := &dynamodb.PutItemInput{
@@ -99,16 +179,16 @@ func (db *UrlDB) WriteItem(id int64, shortUrl, originalUrl string) error {
 		Item:      item,
 	}
 
-	_, err := db.DBClient.PutItem(context.Background(), input)
+	_, err := db.DBClient.PutItem(ctx, input)
 	if err != nil {
 		return err
 	}
-	db.Logger.Info("successfully created database entry for the following values:", zap.String(logkey.ID, *idStr),
+	logging.FromContext(ctx, db.Logger).Info("successfully created database entry for the following values:", zap.String(logkey.ID, *idStr),
 		zap.String(logkey.OriginalURL, originalUrl), zap.String(logkey.ShortenedURL, shortUrl))
 	return nil
 }
 
-func (db *UrlDB) IncrementCounter() (int64, error) {
+func (db *UrlDB) IncrementCounter(ctx context.Context) (int64, error) {
 	input := &dynamodb.UpdateItemInput{
 		TableName: &db.TableName,
 		Key: map[string]types.AttributeValue{
@@ -122,7 +202,7 @@ func (db *UrlDB) IncrementCounter() (int64, error) {
 		ReturnValues: types.ReturnValueUpdatedNew,
 	}
 
-	result, err := db.DBClient.UpdateItem(context.Background(), input)
+	result, err := db.DBClient.UpdateItem(ctx, input)
 	if err != nil {
 		return 0, err
 	}
@@ -138,3 +218,386 @@ func (db *UrlDB) IncrementCounter() (int64, error) {
 	}
 	return counter, nil
 }
+
+// SetDisabled marks an existing link as disabled, or enables it again.
+func (db *UrlDB) SetDisabled(ctx context.Context, shortUrl string, disabled bool) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: shortUrl},
+		},
+		UpdateExpression:    aws.String(fmt.Sprintf("SET %s = :disabled", Disabled)),
+		ConditionExpression: aws.String(fmt.Sprintf("attribute_exists(%s)", OriginalURL)),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":disabled": &types.AttributeValueMemberBOOL{Value: disabled},
+		},
+	}
+
+	_, err := db.DBClient.UpdateItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx, db.Logger).Info("updated disabled flag", zap.String(logkey.ShortenedURL, shortUrl),
+		zap.Bool(logkey.Disabled, disabled))
+	return nil
+}
+
+// IncrementVariantClicks counts a redirect to one of the weighted destinations of a link.
+// The variant_clicks map is created along with the link.
+func (db *UrlDB) IncrementVariantClicks(ctx context.Context, shortUrl string, variant int) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: shortUrl},
+		},
+		UpdateExpression:    aws.String(fmt.Sprintf("SET %[1]s.#variant = if_not_exists(%[1]s.#variant, :start) + :inc", VariantClicks)),
+		ConditionExpression: aws.String(fmt.Sprintf("attribute_exists(%s)", VariantClicks)),
+		ExpressionAttributeNames: map[string]string{
+			"#variant": strconv.Itoa(variant),
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":start": &types.AttributeValueMemberN{Value: "0"},
+			":inc":   &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+
+	_, err := db.DBClient.UpdateItem(ctx, input)
+	return err
+}
+
+// ConsumeClick takes one click from the budget of a link and returns how many are left. The
+// conditional update keeps the budget exact across concurrent redirects.
+func (db *UrlDB) ConsumeClick(ctx context.Context, shortUrl string) (int64, error) {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: shortUrl},
+		},
+		UpdateExpression:    aws.String(fmt.Sprintf("SET %[1]s = %[1]s - :dec", ClicksRemaining)),
+		ConditionExpression: aws.String(fmt.Sprintf("%s > :zero", ClicksRemaining)),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":dec":  &types.AttributeValueMemberN{Value: "1"},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	}
+
+	result, err := db.DBClient.UpdateItem(ctx, input)
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return 0, ErrNoClicksLeft
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	remaining, ok := result.Attributes[ClicksRemaining].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("failed to retrieve remaining clicks")
+	}
+	return strconv.ParseInt(remaining.Value, 10, 64)
+}
+
+// UpdateAttributes sets and removes attributes of an existing link. Attribute names are passed as
+// expression attribute names, so they may be DynamoDB reserved words.
+func (db *UrlDB) UpdateAttributes(ctx context.Context, shortUrl string, set map[string]types.AttributeValue, remove []string) error {
+	if len(set) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	names := make(map[string]string, len(set)+len(remove))
+	values := make(map[string]types.AttributeValue, len(set))
+	var sets, removes []string
+	for _, name := range sortedKeys(set) {
+		i := len(names)
+		names[fmt.Sprintf("#a%d", i)] = name
+		values[fmt.Sprintf(":a%d", i)] = set[name]
+		sets = append(sets, fmt.Sprintf("#a%[1]d = :a%[1]d", i))
+	}
+	for _, name := range remove {
+		i := len(names)
+		names[fmt.Sprintf("#a%d", i)] = name
+		removes = append(removes, fmt.Sprintf("#a%d", i))
+	}
+
+	var update []string
+	if len(sets) > 0 {
+		update = append(update, "SET "+strings.Join(sets, ", "))
+	}
+	if len(removes) > 0 {
+		update = append(update, "REMOVE "+strings.Join(removes, ", "))
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: shortUrl},
+		},
+		UpdateExpression:         aws.String(strings.Join(update, " ")),
+		ConditionExpression:      aws.String(fmt.Sprintf("attribute_exists(%s)", OriginalURL)),
+		ExpressionAttributeNames: names,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+
+	_, err := db.DBClient.UpdateItem(ctx, input)
+	return err
+}
+
+// QueryLinks reads one page of links from the creation time indexes, newest first unless
+// q.Ascending is set. It returns the key to pass as StartKey for the next page, which is nil on
+// the last page. Filtering on tag and domain happens after Limit is applied, so a page may hold
+// fewer items than Limit even when more follow.
+func (db *UrlDB) QueryLinks(ctx context.Context, q LinkQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	input := &dynamodb.QueryInput{
+		TableName:         &db.TableName,
+		IndexName:         aws.String(CreatedIndex),
+		ScanIndexForward:  aws.Bool(q.Ascending),
+		ExclusiveStartKey: q.StartKey,
+		ExpressionAttributeNames: map[string]string{
+			"#pk":      Entity,
+			"#created": CreatedAt,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: EntityLink},
+		},
+	}
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(q.Limit)
+	}
+	if q.Owner != "" {
+		input.IndexName = aws.String(OwnerIndex)
+		input.ExpressionAttributeNames["#pk"] = Owner
+		input.ExpressionAttributeValues[":pk"] = &types.AttributeValueMemberS{Value: q.Owner}
+	}
+
+	keyCondition := "#pk = :pk"
+	if q.CreatedAfter != nil {
+		input.ExpressionAttributeValues[":after"] = &types.AttributeValueMemberS{Value: FormatCreatedAt(*q.CreatedAfter)}
+	}
+	if q.CreatedBefore != nil {
+		input.ExpressionAttributeValues[":before"] = &types.AttributeValueMemberS{Value: FormatCreatedAt(*q.CreatedBefore)}
+	}
+	switch {
+	case q.CreatedAfter != nil && q.CreatedBefore != nil:
+		// BETWEEN is inclusive, the filter below keeps the upper bound exclusive
+		keyCondition += " AND #created BETWEEN :after AND :before"
+	case q.CreatedAfter != nil:
+		keyCondition += " AND #created >= :after"
+	case q.CreatedBefore != nil:
+		keyCondition += " AND #created < :before"
+	}
+	input.KeyConditionExpression = aws.String(keyCondition)
+
+	var filters []string
+	if q.CreatedAfter != nil && q.CreatedBefore != nil {
+		filters = append(filters, "#created < :before")
+	}
+	if q.Tag != "" {
+		input.ExpressionAttributeNames["#tags"] = Tags
+		input.ExpressionAttributeValues[":tag"] = &types.AttributeValueMemberS{Value: q.Tag}
+		filters = append(filters, "contains(#tags, :tag)")
+	}
+	if q.Domain != "" {
+		input.ExpressionAttributeNames["#host"] = DestinationHost
+		input.ExpressionAttributeValues[":host"] = &types.AttributeValueMemberS{Value: q.Domain}
+		filters = append(filters, "#host = :host")
+	}
+	if len(filters) > 0 {
+		input.FilterExpression = aws.String(strings.Join(filters, " AND "))
+	}
+
+	result, err := db.DBClient.Query(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, result.LastEvaluatedKey, nil
+}
+
+// PutEntity stores an item that is not a link under key, replacing any item already stored there.
+// Keys must not be valid short codes, so they never collide with links. The entity and created_at
+// attributes are set like WriteItem sets them, so the item can be read back with QueryEntities.
+func (db *UrlDB) PutEntity(ctx context.Context, key, entity string, attributes map[string]types.AttributeValue) error {
+	item := make(map[string]types.AttributeValue, len(attributes)+3)
+	for k, v := range attributes {
+		item[k] = v
+	}
+	item[ShortURL] = &types.AttributeValueMemberS{Value: key}
+	item[Entity] = &types.AttributeValueMemberS{Value: entity}
+	if _, ok := item[CreatedAt]; !ok {
+		item[CreatedAt] = &types.AttributeValueMemberS{Value: FormatCreatedAt(db.now())}
+	}
+
+	_, err := db.DBClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &db.TableName,
+		Item:      item,
+	})
+	return err
+}
+
+// QueryEntities reads one page of items of q.Entity, newest first unless q.Ascending is set. Like
+// QueryLinks it returns the key of the next page, and filters after Limit is applied.
+func (db *UrlDB) QueryEntities(ctx context.Context, q EntityQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              &db.TableName,
+		IndexName:              aws.String(CreatedIndex),
+		ScanIndexForward:       aws.Bool(q.Ascending),
+		ExclusiveStartKey:      q.StartKey,
+		KeyConditionExpression: aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": Entity,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: q.Entity},
+		},
+	}
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(q.Limit)
+	}
+	if q.Attribute != "" {
+		input.ExpressionAttributeNames["#attr"] = q.Attribute
+		input.ExpressionAttributeValues[":value"] = &types.AttributeValueMemberS{Value: q.Value}
+		input.FilterExpression = aws.String("#attr = :value")
+	}
+
+	result, err := db.DBClient.Query(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, result.LastEvaluatedKey, nil
+}
+
+// DeleteItem removes the item stored under key. Deleting a missing item is not an error.
+func (db *UrlDB) DeleteItem(ctx context.Context, key string) error {
+	_, err := db.DBClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx, db.Logger).Info("deleted database entry", zap.String(logkey.ShortenedURL, key))
+	return nil
+}
+
+// FormatCreatedAt formats t with a fixed width, so created_at sorts chronologically as a string.
+func FormatCreatedAt(t time.Time) string {
+	return t.UTC().Format(CreatedAtLayout)
+}
+
+// GetCounter reads the current url-counter value without incrementing it.
+func (db *UrlDB) GetCounter(ctx context.Context) (int64, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+
+	result, err := db.DBClient.GetItem(ctx, input)
+	if err != nil {
+		return 0, err
+	}
+
+	counterValue, ok := result.Item[CounterValue].(*types.AttributeValueMemberN)
+	if !ok {
+		// nothing has been shortened yet
+		return 0, nil
+	}
+
+	return strconv.ParseInt(counterValue.Value, 10, 64)
+}
+
+// GetLinkCheckCursor returns the key the link check continues from, or nil when the next run
+// starts with the first link.
+func (db *UrlDB) GetLinkCheckCursor(ctx context.Context) (map[string]types.AttributeValue, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+		},
+		ProjectionExpression:     aws.String("#cursor"),
+		ExpressionAttributeNames: map[string]string{"#cursor": LinkCheckCursor},
+		ConsistentRead:           aws.Bool(true),
+	}
+
+	result, err := db.DBClient.GetItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	cursor, ok := result.Item[LinkCheckCursor].(*types.AttributeValueMemberM)
+	if !ok || len(cursor.Value) == 0 {
+		return nil, nil
+	}
+	return cursor.Value, nil
+}
+
+// SetLinkCheckCursor stores the key the link check continues from, or removes it once the check
+// went through every link.
+func (db *UrlDB) SetLinkCheckCursor(ctx context.Context, key map[string]types.AttributeValue) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+		},
+		UpdateExpression:         aws.String("REMOVE #cursor"),
+		ExpressionAttributeNames: map[string]string{"#cursor": LinkCheckCursor},
+	}
+	if len(key) > 0 {
+		input.UpdateExpression = aws.String("SET #cursor = :cursor")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":cursor": &types.AttributeValueMemberM{Value: key},
+		}
+	}
+
+	_, err := db.DBClient.UpdateItem(ctx, input)
+	return err
+}
+
+// Name identifies the table in readiness reports.
+func (db *UrlDB) Name() string {
+	return DependencyName
+}
+
+// Check verifies the table is reachable by reading the key of the url-counter item. An eventually
+// consistent read is enough to tell and costs half as much.
+func (db *UrlDB) Check(ctx context.Context) error {
+	input := &dynamodb.GetItemInput{
+		TableName: &db.TableName,
+		Key: map[string]types.AttributeValue{
+			ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+		},
+		ProjectionExpression: aws.String(ShortURL),
+	}
+	_, err := db.DBClient.GetItem(ctx, input)
+	return err
+}
+
+func sortedKeys(m map[string]types.AttributeValue) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DestinationHostOf returns the lower cased host links to originalUrl are listed under.
+func DestinationHostOf(originalUrl string) string {
+	u, err := url.Parse(originalUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func (db *UrlDB) now() time.Time {
+	if db.Now != nil {
+		return db.Now()
+	}
+	return time.Now()
+}
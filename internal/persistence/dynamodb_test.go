@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -33,11 +34,31 @@ func (m *MockDynamoDBClient) UpdateItem(ctx context.Context, params *dynamodb.Up
 	return args.Get(0).(*dynamodb.UpdateItemOutput), args.Error(1)
 }
 
+func (m *MockDynamoDBClient) Query(ctx context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.QueryOutput), args.Error(1)
+}
+
 func (m *MockDynamoDBClient) Scan(ctx context.Context, params *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
 	args := m.Called(ctx, params)
 	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
 }
 
+func (m *MockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.DeleteItemOutput), args.Error(1)
+}
+
+func (m *MockDynamoDBClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.BatchWriteItemOutput), args.Error(1)
+}
+
+func (m *MockDynamoDBClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), args.Error(1)
+}
+
 var tableName = "url-mapping"
 
 func Test_CreateClient(t *testing.T) {
@@ -95,7 +116,7 @@ func Test_GetItemByPK(t *testing.T) {
 			TableName: URLTable,
 		}
 
-		output, err := db.GetItemByPK(tc.shortUrl)
+		output, err := db.GetItemByPK(context.Background(), tc.shortUrl)
 
 		if tc.checkError {
 			assert.Error(t, err)
@@ -111,6 +132,7 @@ func Test_WriteItem(t *testing.T) {
 		shortUrl       string
 		originalUrl    string
 		id             int64
+		attributes     map[string]types.AttributeValue
 		input          *dynamodb.PutItemInput
 		output         *dynamodb.PutItemOutput
 		writeItemError error
@@ -123,13 +145,39 @@ func Test_WriteItem(t *testing.T) {
 			input: &dynamodb.PutItemInput{
 				TableName: &tableName,
 				Item: map[string]types.AttributeValue{
-					ID:          &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
-					ShortURL:    &types.AttributeValueMemberS{Value: "b"},
-					OriginalURL: &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					ID:              &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
+					ShortURL:        &types.AttributeValueMemberS{Value: "b"},
+					OriginalURL:     &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					Entity:          &types.AttributeValueMemberS{Value: EntityLink},
+					CreatedAt:       &types.AttributeValueMemberS{Value: "2025-03-01T09:30:00.000Z"},
+					DestinationHost: &types.AttributeValueMemberS{Value: "www.example.com"},
 				},
 			},
 			output: &dynamodb.PutItemOutput{}, // we don't care about this
 		},
+		"WriteItem Happy Path with attributes": {
+			shortUrl:    "b",
+			originalUrl: "http://www.example.com",
+			id:          int64(1),
+			attributes: map[string]types.AttributeValue{
+				"redirect_type": &types.AttributeValueMemberN{Value: "301"},
+				OriginalURL:     &types.AttributeValueMemberS{Value: "http://www.ignored.com"},
+				CreatedAt:       &types.AttributeValueMemberS{Value: "ignored"},
+			},
+			input: &dynamodb.PutItemInput{
+				TableName: &tableName,
+				Item: map[string]types.AttributeValue{
+					ID:              &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
+					ShortURL:        &types.AttributeValueMemberS{Value: "b"},
+					OriginalURL:     &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					"redirect_type": &types.AttributeValueMemberN{Value: "301"},
+					Entity:          &types.AttributeValueMemberS{Value: EntityLink},
+					CreatedAt:       &types.AttributeValueMemberS{Value: "2025-03-01T09:30:00.000Z"},
+					DestinationHost: &types.AttributeValueMemberS{Value: "www.example.com"},
+				},
+			},
+			output: &dynamodb.PutItemOutput{},
+		},
 		"WriteItem Sad Path": {
 			shortUrl:    "b",
 			originalUrl: "http://www.example.com",
@@ -137,9 +185,12 @@ func Test_WriteItem(t *testing.T) {
 			input: &dynamodb.PutItemInput{
 				TableName: &tableName,
 				Item: map[string]types.AttributeValue{
-					ID:          &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
-					ShortURL:    &types.AttributeValueMemberS{Value: "b"},
-					OriginalURL: &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					ID:              &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
+					ShortURL:        &types.AttributeValueMemberS{Value: "b"},
+					OriginalURL:     &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					Entity:          &types.AttributeValueMemberS{Value: EntityLink},
+					CreatedAt:       &types.AttributeValueMemberS{Value: "2025-03-01T09:30:00.000Z"},
+					DestinationHost: &types.AttributeValueMemberS{Value: "www.example.com"},
 				},
 			},
 			writeItemError: errors.New("error"),
@@ -157,9 +208,10 @@ func Test_WriteItem(t *testing.T) {
 			Logger:    logger,
 			DBClient:  m,
 			TableName: URLTable,
+			Now:       func() time.Time { return time.Date(2025, 3, 1, 10, 30, 0, 0, time.FixedZone("CET", 60*60)) },
 		}
 
-		err := db.WriteItem(tc.id, tc.shortUrl, tc.originalUrl)
+		err := db.WriteItem(context.Background(), tc.id, tc.shortUrl, tc.originalUrl, tc.attributes)
 
 		if tc.checkError {
 			assert.Error(t, err)
@@ -278,7 +330,7 @@ func Test_IncrementCounter(t *testing.T) {
 			TableName: URLTable,
 		}
 
-		counter, err := db.IncrementCounter()
+		counter, err := db.IncrementCounter(context.Background())
 
 		if tc.checkError {
 			assert.Error(t, err)
@@ -344,7 +396,7 @@ func Test_GetItemByNonPK(t *testing.T) {
 			TableName: URLTable,
 		}
 
-		res, err := db.GetItemByNonPK(tc.fieldName, tc.value)
+		res, err := db.GetItemByNonPK(context.Background(), tc.fieldName, tc.value)
 
 		if tc.checkError {
 			assert.Error(t, err)
@@ -354,3 +406,785 @@ func Test_GetItemByNonPK(t *testing.T) {
 		assert.Equal(t, res, tc.output)
 	}
 }
+
+func Test_Check(t *testing.T) {
+	tests := map[string]struct {
+		getItemError error
+		checkError   bool
+	}{
+		"Check Happy Path": {},
+		"Check Sad Path": {
+			getItemError: errors.New("error"),
+			checkError:   true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+
+			input := &dynamodb.GetItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+				},
+				ProjectionExpression: aws.String(ShortURL),
+			}
+			m.On("GetItem", context.Background(), input).Return(&dynamodb.GetItemOutput{}, tc.getItemError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			err := db.Check(context.Background())
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, DependencyName, db.Name())
+		})
+	}
+}
+
+func Test_SetDisabled(t *testing.T) {
+	tests := map[string]struct {
+		updateError error
+		checkError  bool
+	}{
+		"SetDisabled Happy Path": {},
+		"SetDisabled Sad Path": {
+			updateError: errors.New("error"),
+			checkError:  true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+
+			input := &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:    aws.String("SET disabled = :disabled"),
+				ConditionExpression: aws.String("attribute_exists(original_url)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":disabled": &types.AttributeValueMemberBOOL{Value: true},
+				},
+			}
+			m.On("UpdateItem", context.Background(), input).Return(&dynamodb.UpdateItemOutput{}, tc.updateError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			err := db.SetDisabled(context.Background(), "b", true)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertNumberOfCalls(t, "UpdateItem", 1)
+		})
+	}
+}
+
+func Test_GetCounter(t *testing.T) {
+	tests := map[string]struct {
+		output          *dynamodb.GetItemOutput
+		getItemError    error
+		expectedCounter int64
+		checkError      bool
+	}{
+		"GetCounter Happy Path": {
+			output: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					CounterValue: &types.AttributeValueMemberN{Value: "42"},
+				},
+			},
+			expectedCounter: 42,
+		},
+		"GetCounter no counter yet": {
+			output: &dynamodb.GetItemOutput{},
+		},
+		"GetCounter Sad Path": {
+			output:       &dynamodb.GetItemOutput{},
+			getItemError: errors.New("error"),
+			checkError:   true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+
+			input := &dynamodb.GetItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+				},
+				ConsistentRead: aws.Bool(true),
+			}
+			m.On("GetItem", context.Background(), input).Return(tc.output, tc.getItemError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			counter, err := db.GetCounter(context.Background())
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCounter, counter)
+		})
+	}
+}
+
+func Test_GetLinkCheckCursor(t *testing.T) {
+	key := map[string]types.AttributeValue{
+		ShortURL:  &types.AttributeValueMemberS{Value: "b"},
+		Entity:    &types.AttributeValueMemberS{Value: EntityLink},
+		CreatedAt: &types.AttributeValueMemberS{Value: "2025-01-02T00:00:00.000Z"},
+	}
+
+	tests := map[string]struct {
+		output         *dynamodb.GetItemOutput
+		getItemError   error
+		expectedCursor map[string]types.AttributeValue
+		checkError     bool
+	}{
+		"GetLinkCheckCursor Happy Path": {
+			output: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{LinkCheckCursor: &types.AttributeValueMemberM{Value: key}},
+			},
+			expectedCursor: key,
+		},
+		"GetLinkCheckCursor no cursor": {
+			output: &dynamodb.GetItemOutput{},
+		},
+		"GetLinkCheckCursor Sad Path": {
+			output:       &dynamodb.GetItemOutput{},
+			getItemError: errors.New("error"),
+			checkError:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("GetItem", context.Background(), &dynamodb.GetItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+				},
+				ProjectionExpression:     aws.String("#cursor"),
+				ExpressionAttributeNames: map[string]string{"#cursor": LinkCheckCursor},
+				ConsistentRead:           aws.Bool(true),
+			}).Return(tc.output, tc.getItemError)
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, TableName: URLTable}
+			cursor, err := db.GetLinkCheckCursor(context.Background())
+
+			assert.Equal(t, tc.checkError, err != nil)
+			assert.Equal(t, tc.expectedCursor, cursor)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_SetLinkCheckCursor(t *testing.T) {
+	key := map[string]types.AttributeValue{
+		ShortURL:  &types.AttributeValueMemberS{Value: "b"},
+		Entity:    &types.AttributeValueMemberS{Value: EntityLink},
+		CreatedAt: &types.AttributeValueMemberS{Value: "2025-01-02T00:00:00.000Z"},
+	}
+
+	tests := map[string]struct {
+		key            map[string]types.AttributeValue
+		updateError    error
+		expectedUpdate string
+		expectedValues map[string]types.AttributeValue
+		checkError     bool
+	}{
+		"SetLinkCheckCursor Happy Path": {
+			key:            key,
+			expectedUpdate: "SET #cursor = :cursor",
+			expectedValues: map[string]types.AttributeValue{":cursor": &types.AttributeValueMemberM{Value: key}},
+		},
+		"SetLinkCheckCursor Happy Path last page": {
+			expectedUpdate: "REMOVE #cursor",
+		},
+		"SetLinkCheckCursor Sad Path": {
+			expectedUpdate: "REMOVE #cursor",
+			updateError:    errors.New("error"),
+			checkError:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("UpdateItem", context.Background(), &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: URLCounter},
+				},
+				UpdateExpression:          aws.String(tc.expectedUpdate),
+				ExpressionAttributeNames:  map[string]string{"#cursor": LinkCheckCursor},
+				ExpressionAttributeValues: tc.expectedValues,
+			}).Return(&dynamodb.UpdateItemOutput{}, tc.updateError)
+
+			db := &UrlDB{Logger: zap.NewNop(), DBClient: m, TableName: URLTable}
+			err := db.SetLinkCheckCursor(context.Background(), tc.key)
+
+			assert.Equal(t, tc.checkError, err != nil)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_IncrementVariantClicks(t *testing.T) {
+	tests := map[string]struct {
+		updateError error
+		checkError  bool
+	}{
+		"IncrementVariantClicks Happy Path": {},
+		"IncrementVariantClicks Sad Path": {
+			updateError: errors.New("error"),
+			checkError:  true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+
+			input := &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:    aws.String("SET variant_clicks.#variant = if_not_exists(variant_clicks.#variant, :start) + :inc"),
+				ConditionExpression: aws.String("attribute_exists(variant_clicks)"),
+				ExpressionAttributeNames: map[string]string{
+					"#variant": "1",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":start": &types.AttributeValueMemberN{Value: "0"},
+					":inc":   &types.AttributeValueMemberN{Value: "1"},
+				},
+			}
+			m.On("UpdateItem", context.Background(), input).Return(&dynamodb.UpdateItemOutput{}, tc.updateError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			err := db.IncrementVariantClicks(context.Background(), "b", 1)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_ConsumeClick(t *testing.T) {
+	tests := map[string]struct {
+		output        *dynamodb.UpdateItemOutput
+		updateError   error
+		expected      int64
+		expectedError error
+		checkError    bool
+	}{
+		"ConsumeClick Happy Path": {
+			output: &dynamodb.UpdateItemOutput{
+				Attributes: map[string]types.AttributeValue{
+					ClicksRemaining: &types.AttributeValueMemberN{Value: "4"},
+				},
+			},
+			expected: 4,
+		},
+		"ConsumeClick budget used up": {
+			updateError:   &types.ConditionalCheckFailedException{},
+			expectedError: ErrNoClicksLeft,
+			checkError:    true,
+		},
+		"ConsumeClick Sad Path": {
+			updateError: errors.New("error"),
+			checkError:  true,
+		},
+		"ConsumeClick missing attribute": {
+			output:     &dynamodb.UpdateItemOutput{},
+			checkError: true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+
+			input := &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:    aws.String("SET clicks_remaining = clicks_remaining - :dec"),
+				ConditionExpression: aws.String("clicks_remaining > :zero"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":dec":  &types.AttributeValueMemberN{Value: "1"},
+					":zero": &types.AttributeValueMemberN{Value: "0"},
+				},
+				ReturnValues: types.ReturnValueUpdatedNew,
+			}
+			m.On("UpdateItem", context.Background(), input).Return(tc.output, tc.updateError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			remaining, err := db.ConsumeClick(context.Background(), "b")
+
+			if tc.checkError {
+				assert.Error(t, err)
+				if tc.expectedError != nil {
+					assert.ErrorIs(t, err, tc.expectedError)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, remaining)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_UpdateAttributes(t *testing.T) {
+	tests := map[string]struct {
+		set         map[string]types.AttributeValue
+		remove      []string
+		input       *dynamodb.UpdateItemInput
+		updateError error
+		checkError  bool
+	}{
+		"UpdateAttributes set and remove": {
+			set: map[string]types.AttributeValue{
+				"title": &types.AttributeValueMemberS{Value: "Launch"},
+				"notes": &types.AttributeValueMemberS{Value: "Q3"},
+			},
+			remove: []string{"tags"},
+			input: &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:    aws.String("SET #a0 = :a0, #a1 = :a1 REMOVE #a2"),
+				ConditionExpression: aws.String("attribute_exists(original_url)"),
+				ExpressionAttributeNames: map[string]string{
+					"#a0": "notes",
+					"#a1": "title",
+					"#a2": "tags",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":a0": &types.AttributeValueMemberS{Value: "Q3"},
+					":a1": &types.AttributeValueMemberS{Value: "Launch"},
+				},
+			},
+		},
+		"UpdateAttributes remove only": {
+			remove: []string{"title"},
+			input: &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:         aws.String("REMOVE #a0"),
+				ConditionExpression:      aws.String("attribute_exists(original_url)"),
+				ExpressionAttributeNames: map[string]string{"#a0": "title"},
+			},
+		},
+		"UpdateAttributes nothing to do": {},
+		"UpdateAttributes Sad Path": {
+			remove: []string{"title"},
+			input: &dynamodb.UpdateItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+				UpdateExpression:         aws.String("REMOVE #a0"),
+				ConditionExpression:      aws.String("attribute_exists(original_url)"),
+				ExpressionAttributeNames: map[string]string{"#a0": "title"},
+			},
+			updateError: errors.New("error"),
+			checkError:  true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			if tc.input != nil {
+				m.On("UpdateItem", context.Background(), tc.input).Return(&dynamodb.UpdateItemOutput{}, tc.updateError)
+			}
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			err := db.UpdateAttributes(context.Background(), "b", tc.set, tc.remove)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_QueryLinks(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	startKey := map[string]types.AttributeValue{ShortURL: &types.AttributeValueMemberS{Value: "b"}}
+
+	tests := map[string]struct {
+		query      LinkQuery
+		input      *dynamodb.QueryInput
+		queryError error
+		checkError bool
+	}{
+		"QueryLinks newest first": {
+			query: LinkQuery{Limit: 25},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(CreatedIndex),
+				ScanIndexForward:       aws.Bool(false),
+				Limit:                  aws.Int32(25),
+				KeyConditionExpression: aws.String("#pk = :pk"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk":      Entity,
+					"#created": CreatedAt,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk": &types.AttributeValueMemberS{Value: EntityLink},
+				},
+			},
+		},
+		"QueryLinks by owner with every filter": {
+			query: LinkQuery{
+				Owner:         "growth",
+				Tag:           "q3",
+				Domain:        "example.com",
+				CreatedAfter:  &after,
+				CreatedBefore: &before,
+				Ascending:     true,
+				StartKey:      startKey,
+			},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(OwnerIndex),
+				ScanIndexForward:       aws.Bool(true),
+				ExclusiveStartKey:      startKey,
+				KeyConditionExpression: aws.String("#pk = :pk AND #created BETWEEN :after AND :before"),
+				FilterExpression:       aws.String("#created < :before AND contains(#tags, :tag) AND #host = :host"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk":      Owner,
+					"#created": CreatedAt,
+					"#tags":    Tags,
+					"#host":    DestinationHost,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk":     &types.AttributeValueMemberS{Value: "growth"},
+					":after":  &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00.000Z"},
+					":before": &types.AttributeValueMemberS{Value: "2025-02-01T00:00:00.000Z"},
+					":tag":    &types.AttributeValueMemberS{Value: "q3"},
+					":host":   &types.AttributeValueMemberS{Value: "example.com"},
+				},
+			},
+		},
+		"QueryLinks created before": {
+			query: LinkQuery{CreatedBefore: &before},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(CreatedIndex),
+				ScanIndexForward:       aws.Bool(false),
+				KeyConditionExpression: aws.String("#pk = :pk AND #created < :before"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk":      Entity,
+					"#created": CreatedAt,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk":     &types.AttributeValueMemberS{Value: EntityLink},
+					":before": &types.AttributeValueMemberS{Value: "2025-02-01T00:00:00.000Z"},
+				},
+			},
+			queryError: errors.New("error"),
+			checkError: true,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("Query", context.Background(), tc.input).Return(&dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{startKey},
+				LastEvaluatedKey: startKey,
+			}, tc.queryError)
+
+			db := &UrlDB{
+				Logger:    logger,
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			items, next, err := db.QueryLinks(context.Background(), tc.query)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, items, 1)
+			assert.Equal(t, startKey, next)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_PutEntity(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		attributes map[string]types.AttributeValue
+		item       map[string]types.AttributeValue
+		putError   error
+		checkError bool
+	}{
+		"PutEntity Happy Path": {
+			attributes: map[string]types.AttributeValue{
+				"url":        &types.AttributeValueMemberS{Value: "https://hooks.example.com"},
+				ShortURL:     &types.AttributeValueMemberS{Value: "ignored"},
+				Entity:       &types.AttributeValueMemberS{Value: "ignored"},
+				"expires_at": &types.AttributeValueMemberN{Value: "1740826800"},
+			},
+			item: map[string]types.AttributeValue{
+				"url":        &types.AttributeValueMemberS{Value: "https://hooks.example.com"},
+				ShortURL:     &types.AttributeValueMemberS{Value: "webhook#1"},
+				Entity:       &types.AttributeValueMemberS{Value: EntityWebhook},
+				CreatedAt:    &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00.000Z"},
+				"expires_at": &types.AttributeValueMemberN{Value: "1740826800"},
+			},
+		},
+		"PutEntity keeps created_at": {
+			attributes: map[string]types.AttributeValue{
+				CreatedAt: &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00.000Z"},
+			},
+			item: map[string]types.AttributeValue{
+				ShortURL:  &types.AttributeValueMemberS{Value: "webhook#1"},
+				Entity:    &types.AttributeValueMemberS{Value: EntityWebhook},
+				CreatedAt: &types.AttributeValueMemberS{Value: "2025-01-01T00:00:00.000Z"},
+			},
+		},
+		"PutEntity Sad Path": {
+			item: map[string]types.AttributeValue{
+				ShortURL:  &types.AttributeValueMemberS{Value: "webhook#1"},
+				Entity:    &types.AttributeValueMemberS{Value: EntityWebhook},
+				CreatedAt: &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00.000Z"},
+			},
+			putError:   errors.New("error"),
+			checkError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("PutItem", context.Background(), &dynamodb.PutItemInput{
+				TableName: &tableName,
+				Item:      tc.item,
+			}).Return(&dynamodb.PutItemOutput{}, tc.putError)
+
+			db := &UrlDB{
+				Logger:    zap.NewNop(),
+				DBClient:  m,
+				TableName: URLTable,
+				Now:       func() time.Time { return now },
+			}
+
+			err := db.PutEntity(context.Background(), "webhook#1", EntityWebhook, tc.attributes)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_QueryEntities(t *testing.T) {
+	startKey := map[string]types.AttributeValue{ShortURL: &types.AttributeValueMemberS{Value: "delivery#1"}}
+
+	tests := map[string]struct {
+		query      EntityQuery
+		input      *dynamodb.QueryInput
+		queryError error
+		checkError bool
+	}{
+		"QueryEntities newest first": {
+			query: EntityQuery{Entity: EntityWebhook, Limit: 25},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(CreatedIndex),
+				ScanIndexForward:       aws.Bool(false),
+				Limit:                  aws.Int32(25),
+				KeyConditionExpression: aws.String("#pk = :pk"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk": Entity,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk": &types.AttributeValueMemberS{Value: EntityWebhook},
+				},
+			},
+		},
+		"QueryEntities with filter": {
+			query: EntityQuery{Entity: EntityDelivery, Attribute: "webhook_id", Value: "1", Ascending: true, StartKey: startKey},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(CreatedIndex),
+				ScanIndexForward:       aws.Bool(true),
+				ExclusiveStartKey:      startKey,
+				KeyConditionExpression: aws.String("#pk = :pk"),
+				FilterExpression:       aws.String("#attr = :value"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk":   Entity,
+					"#attr": "webhook_id",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk":    &types.AttributeValueMemberS{Value: EntityDelivery},
+					":value": &types.AttributeValueMemberS{Value: "1"},
+				},
+			},
+		},
+		"QueryEntities Sad Path": {
+			query: EntityQuery{Entity: EntityWebhook},
+			input: &dynamodb.QueryInput{
+				TableName:              &tableName,
+				IndexName:              aws.String(CreatedIndex),
+				ScanIndexForward:       aws.Bool(false),
+				KeyConditionExpression: aws.String("#pk = :pk"),
+				ExpressionAttributeNames: map[string]string{
+					"#pk": Entity,
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":pk": &types.AttributeValueMemberS{Value: EntityWebhook},
+				},
+			},
+			queryError: errors.New("error"),
+			checkError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("Query", context.Background(), tc.input).Return(&dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{startKey},
+				LastEvaluatedKey: startKey,
+			}, tc.queryError)
+
+			db := &UrlDB{
+				Logger:    zap.NewNop(),
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			items, next, err := db.QueryEntities(context.Background(), tc.query)
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, items, 1)
+			assert.Equal(t, startKey, next)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DeleteItem(t *testing.T) {
+	tests := map[string]struct {
+		deleteError error
+		checkError  bool
+	}{
+		"DeleteItem Happy Path": {},
+		"DeleteItem Sad Path": {
+			deleteError: errors.New("error"),
+			checkError:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &MockDynamoDBClient{}
+			m.On("DeleteItem", context.Background(), &dynamodb.DeleteItemInput{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					ShortURL: &types.AttributeValueMemberS{Value: "b"},
+				},
+			}).Return(&dynamodb.DeleteItemOutput{}, tc.deleteError)
+
+			db := &UrlDB{
+				Logger:    zap.NewNop(),
+				DBClient:  m,
+				TableName: URLTable,
+			}
+
+			err := db.DeleteItem(context.Background(), "b")
+
+			if tc.checkError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DestinationHostOf(t *testing.T) {
+	assert.Equal(t, "www.example.com", DestinationHostOf("https://WWW.Example.com:8443/path?q=1"))
+	assert.Equal(t, "", DestinationHostOf("not a url"))
+	assert.Equal(t, "", DestinationHostOf("%zz"))
+}
@@ -0,0 +1,96 @@
+package reputation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// FileChecker matches URLs against a local list, for tests and offline use.
+type FileChecker struct {
+	entries []entry
+}
+
+type entry struct {
+	// pattern is a domain, matching it and its subdomains, or a URL prefix when it has a scheme.
+	pattern string
+	match   Match
+}
+
+// LoadFile reads the list at path, see ParseList.
+func LoadFile(path string) (*FileChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseList(f)
+}
+
+// ParseList reads one entry per line as "<verdict> <domain or URL prefix> [threat]", e.g.
+// "malicious login.evil.example SOCIAL_ENGINEERING". Blank lines and lines starting with # are
+// ignored.
+func ParseList(r io.Reader) (*FileChecker, error) {
+	var c FileChecker
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: want <verdict> <domain or URL prefix> [threat]", line)
+		}
+		verdict := Verdict(fields[0])
+		if verdict != Suspicious && verdict != Malicious {
+			return nil, fmt.Errorf("line %d: verdict must be %s or %s", line, Suspicious, Malicious)
+		}
+		e := entry{pattern: fields[1], match: Match{Verdict: verdict}}
+		if !strings.Contains(e.pattern, "://") {
+			e.pattern = strings.ToLower(strings.Trim(e.pattern, "."))
+		}
+		if len(fields) == 3 {
+			e.match.Threat = fields[2]
+		}
+		c.entries = append(c.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Check returns the most severe entry matching each URL.
+func (c *FileChecker) Check(_ context.Context, urls []string) ([]Match, error) {
+	var matches []Match
+	for _, u := range urls {
+		var found []Match
+		for _, e := range c.entries {
+			if e.matches(u) {
+				m := e.match
+				m.URL = u
+				found = append(found, m)
+			}
+		}
+		if worst := Worst(found); worst != nil {
+			matches = append(matches, *worst)
+		}
+	}
+	return matches, nil
+}
+
+func (e entry) matches(rawURL string) bool {
+	if strings.Contains(e.pattern, "://") {
+		return strings.HasPrefix(rawURL, e.pattern)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	return host == e.pattern || strings.HasSuffix(host, "."+e.pattern)
+}
@@ -0,0 +1,72 @@
+package reputation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const list = `# local threat list
+suspicious evil.example
+malicious login.evil.example SOCIAL_ENGINEERING
+
+malicious https://www.example.com/download/ MALWARE
+`
+
+func Test_FileChecker(t *testing.T) {
+	c, err := ParseList(strings.NewReader(list))
+	assert.NoError(t, err)
+
+	matches, err := c.Check(context.Background(), []string{
+		"https://www.example.com",
+		"https://EVIL.example/offer",
+		"https://login.evil.example/",
+		"https://www.example.com/download/setup.exe",
+		"https://notevil.example",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Match{
+		{URL: "https://EVIL.example/offer", Verdict: Suspicious},
+		{URL: "https://login.evil.example/", Verdict: Malicious, Threat: "SOCIAL_ENGINEERING"},
+		{URL: "https://www.example.com/download/setup.exe", Verdict: Malicious, Threat: "MALWARE"},
+	}, matches)
+}
+
+func Test_ParseList(t *testing.T) {
+	tests := map[string]string{
+		"missing pattern": "malicious",
+		"unknown verdict": "bad evil.example",
+		"extra fields":    "malicious evil.example PHISHING today",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseList(strings.NewReader(input))
+			assert.ErrorContains(t, err, "line 1")
+		})
+	}
+}
+
+func Test_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "threats.txt")
+	assert.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+	c, err := LoadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, c.entries, 3)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func Test_Worst(t *testing.T) {
+	assert.Nil(t, Worst(nil))
+	assert.Equal(t, &Match{URL: "b", Verdict: Malicious}, Worst([]Match{
+		{URL: "a", Verdict: Suspicious},
+		{URL: "b", Verdict: Malicious},
+		{URL: "c", Verdict: Suspicious},
+	}))
+}
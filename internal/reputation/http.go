@@ -0,0 +1,143 @@
+package reputation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// HTTPChecker uses a lookup API speaking the Safe Browsing v4 threatMatches:find protocol.
+type HTTPChecker struct {
+	// Endpoint is the lookup URL, e.g. https://safebrowsing.googleapis.com/v4/threatMatches:find.
+	Endpoint string
+	APIKey   string
+	ClientID string
+	Client   *http.Client
+}
+
+const (
+	DefaultClientID = "url-shortener"
+	// MaxLookupEntries is the most URLs the lookup API accepts in one request.
+	MaxLookupEntries = 500
+	maxResponseBytes = 1 << 20
+)
+
+// threatVerdicts are the threat types checked for and how each is treated.
+var threatVerdicts = map[string]Verdict{
+	"MALWARE":                         Malicious,
+	"SOCIAL_ENGINEERING":              Malicious,
+	"UNWANTED_SOFTWARE":               Suspicious,
+	"POTENTIALLY_HARMFUL_APPLICATION": Suspicious,
+}
+
+type (
+	lookupRequest struct {
+		Client     lookupClient `json:"client"`
+		ThreatInfo threatInfo   `json:"threatInfo"`
+	}
+
+	lookupClient struct {
+		ClientID string `json:"clientId"`
+	}
+
+	threatInfo struct {
+		ThreatTypes      []string      `json:"threatTypes"`
+		PlatformTypes    []string      `json:"platformTypes"`
+		ThreatEntryTypes []string      `json:"threatEntryTypes"`
+		ThreatEntries    []threatEntry `json:"threatEntries"`
+	}
+
+	threatEntry struct {
+		URL string `json:"url"`
+	}
+
+	lookupResponse struct {
+		Matches []struct {
+			ThreatType string      `json:"threatType"`
+			Threat     threatEntry `json:"threat"`
+		} `json:"matches"`
+	}
+)
+
+// Check looks urls up MaxLookupEntries at a time and fails when any of the requests fails.
+func (c *HTTPChecker) Check(ctx context.Context, urls []string) ([]Match, error) {
+	matches := []Match{}
+	for chunk := range slices.Chunk(urls, MaxLookupEntries) {
+		chunkMatches, err := c.lookup(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, chunkMatches...)
+	}
+	return matches, nil
+}
+
+// lookup looks urls up in a single request.
+func (c *HTTPChecker) lookup(ctx context.Context, urls []string) ([]Match, error) {
+	req := lookupRequest{
+		Client: lookupClient{ClientID: c.ClientID},
+		ThreatInfo: threatInfo{
+			PlatformTypes:    []string{"ANY_PLATFORM"},
+			ThreatEntryTypes: []string{"URL"},
+		},
+	}
+	if req.Client.ClientID == "" {
+		req.Client.ClientID = DefaultClientID
+	}
+	req.ThreatInfo.ThreatTypes = slices.Sorted(maps.Keys(threatVerdicts))
+	for _, u := range urls {
+		req.ThreatInfo.ThreatEntries = append(req.ThreatInfo.ThreatEntries, threatEntry{URL: u})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKey != "" {
+		query := endpoint.Query()
+		query.Set("key", c.APIKey)
+		endpoint.RawQuery = query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reputation lookup returned %s", resp.Status)
+	}
+
+	var lookup lookupResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&lookup); err != nil {
+		return nil, fmt.Errorf("invalid reputation lookup response: %w", err)
+	}
+	matches := make([]Match, 0, len(lookup.Matches))
+	for _, m := range lookup.Matches {
+		verdict, ok := threatVerdicts[m.ThreatType]
+		if !ok {
+			verdict = Suspicious
+		}
+		matches = append(matches, Match{URL: m.Threat.URL, Verdict: verdict, Threat: m.ThreatType})
+	}
+	return matches, nil
+}
@@ -0,0 +1,116 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTTPChecker(t *testing.T) {
+	tests := map[string]struct {
+		status          int
+		response        string
+		expectedMatches []Match
+		expectError     bool
+	}{
+		"Happy Path no matches": {
+			status:          http.StatusOK,
+			response:        `{}`,
+			expectedMatches: []Match{},
+		},
+		"Happy Path matches": {
+			status: http.StatusOK,
+			response: `{"matches": [
+				{"threatType": "SOCIAL_ENGINEERING", "threat": {"url": "https://login.evil.example"}},
+				{"threatType": "UNWANTED_SOFTWARE", "threat": {"url": "https://www.example.com"}},
+				{"threatType": "SOMETHING_NEW", "threat": {"url": "https://www.example.com"}}
+			]}`,
+			expectedMatches: []Match{
+				{URL: "https://login.evil.example", Verdict: Malicious, Threat: "SOCIAL_ENGINEERING"},
+				{URL: "https://www.example.com", Verdict: Suspicious, Threat: "UNWANTED_SOFTWARE"},
+				{URL: "https://www.example.com", Verdict: Suspicious, Threat: "SOMETHING_NEW"},
+			},
+		},
+		"Sad Path error status": {
+			status:      http.StatusForbidden,
+			response:    `{"error": {"code": 403}}`,
+			expectError: true,
+		},
+		"Sad Path invalid response": {
+			status:      http.StatusOK,
+			response:    `not json`,
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "secret", r.URL.Query().Get("key"))
+				var req lookupRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, DefaultClientID, req.Client.ClientID)
+				assert.Equal(t, []string{"MALWARE", "POTENTIALLY_HARMFUL_APPLICATION", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE"}, req.ThreatInfo.ThreatTypes)
+				assert.Equal(t, []threatEntry{{URL: "https://www.example.com"}, {URL: "https://login.evil.example"}}, req.ThreatInfo.ThreatEntries)
+
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer srv.Close()
+
+			c := &HTTPChecker{Endpoint: srv.URL + "/v4/threatMatches:find", APIKey: "secret", Client: srv.Client()}
+			matches, err := c.Check(context.Background(), []string{"https://www.example.com", "https://login.evil.example"})
+
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMatches, matches)
+		})
+	}
+}
+
+func Test_HTTPChecker_Chunks(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req lookupRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		sizes = append(sizes, len(req.ThreatInfo.ThreatEntries))
+		last := req.ThreatInfo.ThreatEntries[len(req.ThreatInfo.ThreatEntries)-1].URL
+		_, _ = fmt.Fprintf(w, `{"matches": [{"threatType": "MALWARE", "threat": {"url": %q}}]}`, last)
+	}))
+	defer srv.Close()
+
+	urls := make([]string, 2*MaxLookupEntries+1)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+	c := &HTTPChecker{Endpoint: srv.URL, Client: srv.Client()}
+	matches, err := c.Check(context.Background(), urls)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{MaxLookupEntries, MaxLookupEntries, 1}, sizes)
+	assert.Equal(t, []Match{
+		{URL: "https://example.com/499", Verdict: Malicious, Threat: "MALWARE"},
+		{URL: "https://example.com/999", Verdict: Malicious, Threat: "MALWARE"},
+		{URL: "https://example.com/1000", Verdict: Malicious, Threat: "MALWARE"},
+	}, matches)
+}
+
+func Test_HTTPChecker_Canceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &HTTPChecker{Endpoint: srv.URL}
+	_, err := c.Check(ctx, []string{"https://www.example.com"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,44 @@
+// Package reputation looks link destinations up in threat-intel sources.
+package reputation
+
+import "slices"
+
+type (
+	// Verdict is how bad a source considers a URL.
+	Verdict string
+
+	// Match is a URL a source flagged.
+	Match struct {
+		URL     string  `dynamodbav:"url" json:"url,omitempty"`
+		Verdict Verdict `dynamodbav:"verdict" json:"verdict"`
+		// Threat is the source's own name for what it found, e.g. SOCIAL_ENGINEERING.
+		Threat string `dynamodbav:"threat,omitempty" json:"threat,omitempty"`
+	}
+)
+
+const (
+	// Suspicious URLs stay reachable behind a warning.
+	Suspicious Verdict = "suspicious"
+	// Malicious URLs are blocked.
+	Malicious Verdict = "malicious"
+)
+
+// Worst returns the match with the most severe verdict, nil when there are none.
+func Worst(matches []Match) *Match {
+	if len(matches) == 0 {
+		return nil
+	}
+	worst := slices.MaxFunc(matches, func(a, b Match) int { return a.Verdict.severity() - b.Verdict.severity() })
+	return &worst
+}
+
+func (v Verdict) severity() int {
+	switch v {
+	case Malicious:
+		return 2
+	case Suspicious:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -2,19 +2,35 @@ package router
 
 import (
 	"github.com/connorpalermo/url-shortener/internal/endpoint"
-	"github.com/go-chi/chi/middleware"
+	"github.com/connorpalermo/url-shortener/internal/logging"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
 )
 
-func New(h endpoint.Provider) *chi.Mux {
+func New(logger *zap.Logger, h endpoint.Provider) *chi.Mux {
 	m := chi.NewRouter()
 
-	m.Use(middleware.Logger)
+	m.Use(logging.RequestID(logger))
+	m.Use(logging.AccessLog(logger))
 	m.Use(middleware.Recoverer)
 
 	m.Get(endpoint.HealthCheckEndpoint, h.HealthCheckHandler())
+	m.Get(endpoint.LivenessEndpoint, h.HealthCheckHandler())
+	m.Get(endpoint.ReadinessEndpoint, h.ReadinessHandler())
 	m.Get(endpoint.RedirectEndpoint, h.RedirectHandler())
+	m.Get(endpoint.PrefixEndpoint, h.RedirectHandler())
+	m.Head(endpoint.RedirectEndpoint, h.RedirectHandler())
+	m.Head(endpoint.PrefixEndpoint, h.RedirectHandler())
+	m.Post(endpoint.RedirectEndpoint, h.RedirectHandler())
+	m.Post(endpoint.PrefixEndpoint, h.RedirectHandler())
 	m.Post(endpoint.ShortenURLEndpoint, h.ShortenHandler())
+	m.Get(endpoint.OpenAPIEndpoint, h.OpenAPIHandler())
+	m.Get(endpoint.LinksEndpoint, h.ListLinksHandler())
+	m.Get(endpoint.LinkDetailEndpoint, h.LinkHandler())
+	m.Patch(endpoint.LinkDetailEndpoint, h.UpdateLinkHandler())
+	m.Get(endpoint.LinkVariantsEndpoint, h.LinkVariantsHandler())
+	m.Get(endpoint.AnalyticsEndpoint, h.LinkAnalyticsHandler())
 	m.Get("/", h.RedirectHandler())
 
 	return m
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/connorpalermo/url-shortener/internal/endpoint"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func Test_OpenAPICoversRoutes(t *testing.T) {
+	var doc openAPIDocument
+	assert.NoError(t, json.Unmarshal(endpoint.OpenAPISpec, &doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	logger := zaptest.NewLogger(t)
+	m := New(logger, &endpoint.Handler{Logger: logger})
+
+	err := chi.Walk(m, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = openAPIPath(route)
+		operations, ok := doc.Paths[route]
+		if assert.True(t, ok, "route %s is missing from openapi.json", route) {
+			_, ok = operations[strings.ToLower(method)]
+			assert.True(t, ok, "%s %s is missing from openapi.json", method, route)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+// openAPIPath documents chi catch-all routes as a trailing {path} parameter.
+func openAPIPath(route string) string {
+	if strings.HasSuffix(route, "/*") {
+		return strings.TrimSuffix(route, "*") + "{path}"
+	}
+	return route
+}
+
+func Test_OpenAPIEndpoint(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	m := New(logger, &endpoint.Handler{Logger: logger})
+
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, endpoint.OpenAPIEndpoint, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(endpoint.OpenAPISpec), rr.Body.String())
+}
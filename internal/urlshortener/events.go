@@ -0,0 +1,38 @@
+package urlshortener
+
+import (
+	"context"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/webhook"
+)
+
+type (
+	// Emitter is told about link lifecycle and click events, see webhook.Dispatcher. Emit must
+	// return without waiting for the event to be delivered.
+	Emitter interface {
+		Emit(ctx context.Context, eventType webhook.EventType, data any)
+	}
+
+	// Click is the payload of link.clicked events and the record kept by the click pipeline.
+	Click struct {
+		ShortURL    string `dynamodbav:"short_url" json:"short_url"`
+		Destination string `dynamodbav:"destination" json:"destination"`
+		Referrer    string `dynamodbav:"referrer,omitempty" json:"referrer,omitempty"`
+		UserAgent   string `dynamodbav:"user_agent,omitempty" json:"user_agent,omitempty"`
+		// Country is the ISO 3166 code CloudFront located the client in.
+		Country string `dynamodbav:"country,omitempty" json:"country,omitempty"`
+		// Bot says why the click looks automated, see botdetect.Reason. It is empty for people.
+		Bot string `dynamodbav:"bot,omitempty" json:"bot,omitempty"`
+		// IPHash is the salted hash of the client's address, only set on the click pipeline.
+		IPHash    string    `dynamodbav:"ip_hash,omitempty" json:"ip_hash,omitempty"`
+		ClickedAt time.Time `dynamodbav:"clicked_at" json:"clicked_at"`
+	}
+)
+
+// emit sends link as the payload of an event when an Emitter is configured.
+func (u *UrlShortener) emit(ctx context.Context, eventType webhook.EventType, link *Link) {
+	if u.Events != nil {
+		u.Events.Emit(ctx, eventType, link)
+	}
+}
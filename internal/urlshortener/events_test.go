@@ -0,0 +1,138 @@
+package urlshortener
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockEmitter struct {
+	mock.Mock
+}
+
+func (m *MockEmitter) Emit(_ context.Context, eventType webhook.EventType, data any) {
+	m.Called(eventType, data)
+}
+
+func Test_Events(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	item := &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberN{Value: "1"},
+		"short_url":    &types.AttributeValueMemberS{Value: "b"},
+		"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+	}}
+	title := "Launch"
+
+	tests := map[string]struct {
+		setup         func(m *MockDBProvider)
+		call          func(u *UrlShortener) error
+		expectedEvent webhook.EventType
+		expectedLink  *Link
+	}{
+		"ShortenURL": {
+			setup: func(m *MockDBProvider) {
+				m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{}, nil)
+				m.On("IncrementCounter").Return(int64(1), nil)
+				m.On("WriteItem", int64(1), "b", "http://www.example.com", mock.Anything).Return(nil)
+			},
+			call: func(u *UrlShortener) error {
+				_, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{Owner: "growth"})
+				return err
+			},
+			expectedEvent: webhook.LinkCreated,
+			expectedLink: &Link{
+				ShortURL:    "b",
+				ID:          1,
+				OriginalURL: "http://www.example.com",
+				CreatedAt:   &now,
+				Status:      StatusActive,
+				LinkOptions: LinkOptions{Owner: "growth"},
+			},
+		},
+		"UpdateMetadata": {
+			setup: func(m *MockDBProvider) {
+				m.On("GetItemByPK", "b").Return(item, nil)
+				m.On("UpdateAttributes", "b", mock.Anything, mock.Anything).Return(nil)
+			},
+			call: func(u *UrlShortener) error {
+				_, err := u.UpdateMetadata(context.Background(), "b", MetadataUpdate{Title: &title})
+				return err
+			},
+			expectedEvent: webhook.LinkUpdated,
+			expectedLink: &Link{
+				ShortURL:    "b",
+				ID:          1,
+				OriginalURL: "http://www.example.com",
+				Status:      StatusActive,
+				LinkOptions: LinkOptions{Metadata: Metadata{Title: "Launch"}},
+			},
+		},
+		"SetDisabled": {
+			setup: func(m *MockDBProvider) {
+				m.On("GetItemByPK", "b").Return(item, nil)
+				m.On("SetDisabled", "b", true).Return(nil)
+			},
+			call: func(u *UrlShortener) error {
+				return u.SetDisabled(context.Background(), "b", true)
+			},
+			expectedEvent: webhook.LinkUpdated,
+			expectedLink:  &Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", Disabled: true, Status: StatusDisabled},
+		},
+		"DeleteLink": {
+			setup: func(m *MockDBProvider) {
+				m.On("GetItemByPK", "b").Return(item, nil)
+				m.On("DeleteItem", "b").Return(nil)
+			},
+			call: func(u *UrlShortener) error {
+				return u.DeleteLink(context.Background(), "b")
+			},
+			expectedEvent: webhook.LinkDeleted,
+			expectedLink:  &Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", Status: StatusActive},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := zap.NewProduction()
+			m := new(MockDBProvider)
+			tc.setup(m)
+			events := new(MockEmitter)
+			events.On("Emit", tc.expectedEvent, tc.expectedLink).Return()
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+				Now:      func() time.Time { return now },
+				Events:   events,
+			}
+
+			assert.NoError(t, tc.call(u))
+			events.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Events_ReusedLink(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{{
+			"short_url":    &types.AttributeValueMemberS{Value: "b"},
+			"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+		}},
+	}, nil)
+	events := new(MockEmitter)
+	u := &UrlShortener{Logger: logger, DBClient: m, Events: events}
+
+	shortened, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "b", shortened)
+	events.AssertNotCalled(t, "Emit", mock.Anything, mock.Anything)
+}
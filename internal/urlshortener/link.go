@@ -0,0 +1,276 @@
+package urlshortener
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// Link is a url-mapping item as stored by the persistence layer.
+	Link struct {
+		ShortURL    string `dynamodbav:"short_url" json:"short_url"`
+		ID          int64  `dynamodbav:"id" json:"id"`
+		OriginalURL string `dynamodbav:"original_url" json:"original_url"`
+		Disabled    bool   `dynamodbav:"disabled" json:"disabled"`
+		// VariantClicks counts redirects per index into Destinations.
+		VariantClicks map[string]int64 `dynamodbav:"variant_clicks,omitempty" json:"variant_clicks,omitempty"`
+		// ClicksRemaining is what is left of MaxClicks.
+		ClicksRemaining int64 `dynamodbav:"clicks_remaining,omitempty" json:"clicks_remaining,omitempty"`
+		// Flag is set when a reputation check flagged one of the destinations.
+		Flag *Flag `dynamodbav:"flag,omitempty" json:"flag,omitempty"`
+		// Health is the outcome of the last link rot check, missing until the link was checked.
+		Health *LinkHealth `dynamodbav:"health,omitempty" json:"health,omitempty"`
+		// CreatedAt is set by the persistence layer and missing on links created before it was.
+		CreatedAt *time.Time `dynamodbav:"created_at,omitempty" json:"created_at,omitempty"`
+		// Status is derived when the link is read and never stored.
+		Status LinkStatus `dynamodbav:"-" json:"status,omitempty"`
+		LinkOptions
+	}
+
+	// LinkOptions are the optional per-link settings chosen when a URL is shortened.
+	// The zero value behaves like a link created before the option existed.
+	LinkOptions struct {
+		RedirectType int         `dynamodbav:"redirect_type,omitempty" json:"redirect_type,omitempty"`
+		QueryPolicy  QueryPolicy `dynamodbav:"query_policy,omitempty" json:"query_policy,omitempty"`
+		// Prefix forwards any path after the short code, so /docs/getting-started redirects to
+		// <original_url>/getting-started.
+		Prefix bool `dynamodbav:"prefix,omitempty" json:"prefix,omitempty"`
+		// Destinations splits traffic across several URLs by weight instead of always using the
+		// original URL. Sticky keeps a visitor on the variant they were first sent to.
+		Destinations []Destination `dynamodbav:"destinations,omitempty" json:"destinations,omitempty"`
+		Sticky       bool          `dynamodbav:"sticky,omitempty" json:"sticky,omitempty"`
+		// Rules route matching visitors to their own URL before any other destination is used.
+		Rules []Rule `dynamodbav:"rules,omitempty" json:"rules,omitempty"`
+		// Password protects the link when it is created and is never stored, only PasswordHash is.
+		Password     string `dynamodbav:"-" json:"-"`
+		PasswordHash string `dynamodbav:"password_hash,omitempty" json:"-"`
+		// MaxClicks stops the link redirecting after that many redirects; 1 makes it a one-time link.
+		MaxClicks int64 `dynamodbav:"max_clicks,omitempty" json:"max_clicks,omitempty"`
+		// NotBefore and NotAfter bound when the link redirects. Before NotBefore visitors are sent
+		// to PrelaunchURL if there is one.
+		NotBefore    *time.Time `dynamodbav:"not_before,omitempty" json:"not_before,omitempty"`
+		NotAfter     *time.Time `dynamodbav:"not_after,omitempty" json:"not_after,omitempty"`
+		PrelaunchURL string     `dynamodbav:"prelaunch_url,omitempty" json:"prelaunch_url,omitempty"`
+		// Interstitial shows a warning page with the destination before every redirect.
+		Interstitial bool `dynamodbav:"interstitial,omitempty" json:"interstitial,omitempty"`
+		// Owner is the team or person links are listed under.
+		Owner string `dynamodbav:"owner,omitempty" json:"owner,omitempty"`
+		Metadata
+	}
+
+	Destination struct {
+		URL    string `dynamodbav:"url" json:"url,omitempty"`
+		Weight int    `dynamodbav:"weight" json:"weight"`
+	}
+
+	// QueryPolicy decides what happens to the query string of the incoming short URL request.
+	QueryPolicy string
+)
+
+const (
+	DefaultRedirectType = http.StatusFound
+	MaxDestinations     = 10
+	// MaxWeight bounds destination weights so their total always fits an int.
+	MaxWeight = 10000
+
+	// QueryDrop ignores the incoming query, the behavior of links without a policy.
+	QueryDrop QueryPolicy = "drop"
+	// QueryAppend adds incoming parameters alongside the ones already on the destination.
+	QueryAppend QueryPolicy = "append"
+	// QueryOverride replaces destination parameters that are also present on the incoming request.
+	QueryOverride QueryPolicy = "override"
+)
+
+var (
+	ErrLinkNotFound  = errors.New("short URL not found")
+	ErrLinkDisabled  = errors.New("short URL is disabled")
+	ErrLinkBlocked   = errors.New("short URL was blocked as unsafe")
+	ErrLinkExhausted = errors.New("short URL has no clicks left")
+	ErrLinkExpired   = errors.New("short URL has expired")
+	ErrLinkNotActive = errors.New("short URL is not active yet")
+	ErrInvalidLink   = errors.New("invalid link options")
+	ErrInvalidPath   = errors.New("invalid path suffix")
+)
+
+// Validate reports options that cannot be stored, wrapping ErrInvalidLink.
+func (o LinkOptions) Validate() error {
+	switch o.RedirectType {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("%w: unsupported redirect type %d", ErrInvalidLink, o.RedirectType)
+	}
+	switch o.QueryPolicy {
+	case "", QueryDrop, QueryAppend, QueryOverride:
+	default:
+		return fmt.Errorf("%w: unsupported query policy %q", ErrInvalidLink, o.QueryPolicy)
+	}
+	if len(o.Destinations) > MaxDestinations {
+		return fmt.Errorf("%w: at most %d destinations are supported", ErrInvalidLink, MaxDestinations)
+	}
+	for i, d := range o.Destinations {
+		if d.URL == "" || d.Weight <= 0 {
+			return fmt.Errorf("%w: destination %d needs a URL and a positive weight", ErrInvalidLink, i)
+		}
+		if d.Weight > MaxWeight {
+			return fmt.Errorf("%w: destination %d has a weight above %d", ErrInvalidLink, i, MaxWeight)
+		}
+	}
+	if o.Sticky && len(o.Destinations) == 0 {
+		return fmt.Errorf("%w: sticky requires destinations", ErrInvalidLink)
+	}
+	if len(o.Rules) > MaxRules {
+		return fmt.Errorf("%w: at most %d rules are supported", ErrInvalidLink, MaxRules)
+	}
+	for i, r := range o.Rules {
+		if err := r.validate(i); err != nil {
+			return err
+		}
+	}
+	if o.MaxClicks < 0 {
+		return fmt.Errorf("%w: max clicks cannot be negative", ErrInvalidLink)
+	}
+	if err := o.validateSchedule(); err != nil {
+		return err
+	}
+	if err := o.Metadata.validate(); err != nil {
+		return err
+	}
+	if len(o.Password) > MaxPasswordLength {
+		return fmt.Errorf("%w: passwords are limited to %d bytes", ErrInvalidLink, MaxPasswordLength)
+	}
+	return nil
+}
+
+// RedirectStatus is the status code to redirect with, defaulting to 302 Found.
+func (l *Link) RedirectStatus() int {
+	if l.RedirectType == 0 {
+		return DefaultRedirectType
+	}
+	return l.RedirectType
+}
+
+// Exhausted reports whether a link with a click budget has used it up.
+func (l *Link) Exhausted() bool {
+	return l.MaxClicks > 0 && l.ClicksRemaining <= 0
+}
+
+// PickVariant returns the index into Destinations a redirect should use, or -1 when the link has
+// a single destination. A valid sticky variant wins, otherwise intn (e.g. rand.IntN) draws one
+// in proportion to the weights.
+func (l *Link) PickVariant(sticky int, intn func(int) int) int {
+	if len(l.Destinations) == 0 {
+		return -1
+	}
+	if l.Sticky && sticky >= 0 && sticky < len(l.Destinations) {
+		return sticky
+	}
+
+	total := 0
+	for _, d := range l.Destinations {
+		total += d.Weight
+	}
+	n := intn(total)
+	for i, d := range l.Destinations {
+		if n < d.Weight {
+			return i
+		}
+		n -= d.Weight
+	}
+	return len(l.Destinations) - 1
+}
+
+// VariantURL returns the destination for a variant picked by PickVariant.
+func (l *Link) VariantURL(variant int) string {
+	if variant < 0 || variant >= len(l.Destinations) {
+		return l.OriginalURL
+	}
+	return l.Destinations[variant].URL
+}
+
+// MergeQuery applies policy to the incoming query parameters and the query already present on
+// destination. The destination query is kept as written, in its own order and with parameters
+// url.Values cannot represent, and the incoming parameters are encoded after it. Override only
+// removes the destination parameters whose key is also incoming. The destination fragment is
+// preserved, and destination is returned untouched when there is nothing to merge.
+func MergeQuery(destination string, incoming url.Values, policy QueryPolicy) (string, error) {
+	if len(incoming) == 0 || policy == "" || policy == QueryDrop {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	var params []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		if policy == QueryOverride && overridden(param, incoming) {
+			continue
+		}
+		params = append(params, param)
+	}
+	u.RawQuery = strings.Join(append(params, incoming.Encode()), "&")
+
+	return u.String(), nil
+}
+
+// overridden reports whether the raw destination parameter has a key present in incoming.
+// Parameters whose key cannot be unescaped never match and are kept.
+func overridden(param string, incoming url.Values) bool {
+	key, _, _ := strings.Cut(param, "=")
+	key, err := url.QueryUnescape(key)
+	if err != nil {
+		return false
+	}
+	_, ok := incoming[key]
+	return ok
+}
+
+// JoinPath appends an escaped path suffix to destination. Every segment is decoded and
+// re-escaped so encoded slashes stay inside their segment, empty segments are dropped and
+// dot segments are rejected with ErrInvalidPath so the suffix can never climb above the
+// destination path.
+func JoinPath(destination, escapedSuffix string) (string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(escapedSuffix, "/") {
+		if segment == "" {
+			continue
+		}
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidPath, err)
+		}
+		if decoded == "." || decoded == ".." || strings.ContainsRune(decoded, '\\') {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPath, decoded)
+		}
+		segments = append(segments, url.PathEscape(decoded))
+	}
+	if len(segments) == 0 {
+		return destination, nil
+	}
+
+	escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(escapedSuffix, "/") {
+		escaped += "/"
+	}
+
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", err
+	}
+	u.Path, u.RawPath = path, escaped
+
+	return u.String(), nil
+}
@@ -0,0 +1,298 @@
+package urlshortener
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	tests := map[string]struct {
+		opts        LinkOptions
+		expectError bool
+	}{
+		"Defaults":                 {},
+		"Permanent redirect":       {opts: LinkOptions{RedirectType: http.StatusPermanentRedirect}},
+		"Append query":             {opts: LinkOptions{QueryPolicy: QueryAppend}},
+		"Unsupported redirect":     {opts: LinkOptions{RedirectType: http.StatusOK}, expectError: true},
+		"Unsupported query policy": {opts: LinkOptions{QueryPolicy: "merge"}, expectError: true},
+		"Weighted destinations": {
+			opts: LinkOptions{Destinations: []Destination{{URL: "http://a.com", Weight: 1}, {URL: "http://b.com", Weight: 2}}, Sticky: true},
+		},
+		"Destination without weight": {
+			opts:        LinkOptions{Destinations: []Destination{{URL: "http://a.com"}}},
+			expectError: true,
+		},
+		"Destination with the highest weight": {
+			opts: LinkOptions{Destinations: []Destination{{URL: "http://a.com", Weight: MaxWeight}, {URL: "http://b.com", Weight: MaxWeight}}},
+		},
+		"Destination weight too high": {
+			opts:        LinkOptions{Destinations: []Destination{{URL: "http://a.com", Weight: 1}, {URL: "http://b.com", Weight: math.MaxInt64}}},
+			expectError: true,
+		},
+		"Destination without URL": {
+			opts:        LinkOptions{Destinations: []Destination{{Weight: 1}}},
+			expectError: true,
+		},
+		"Sticky without destinations": {
+			opts:        LinkOptions{Sticky: true},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.opts.Validate()
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidLink)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_MergeQuery(t *testing.T) {
+	tests := map[string]struct {
+		destination string
+		incoming    string
+		policy      QueryPolicy
+		expected    string
+	}{
+		"Default policy drops incoming query": {
+			destination: "https://example.com/page?a=1",
+			incoming:    "ref=twitter",
+			expected:    "https://example.com/page?a=1",
+		},
+		"Drop keeps destination verbatim": {
+			destination: "https://example.com/page?z=1&a=2#top",
+			incoming:    "ref=twitter",
+			policy:      QueryDrop,
+			expected:    "https://example.com/page?z=1&a=2#top",
+		},
+		"Append without destination query": {
+			destination: "https://example.com/page",
+			incoming:    "ref=twitter",
+			policy:      QueryAppend,
+			expected:    "https://example.com/page?ref=twitter",
+		},
+		"Append keeps destination values": {
+			destination: "https://example.com/page?ref=newsletter&a=1",
+			incoming:    "ref=twitter&b=2",
+			policy:      QueryAppend,
+			expected:    "https://example.com/page?ref=newsletter&a=1&b=2&ref=twitter",
+		},
+		"Override replaces destination values": {
+			destination: "https://example.com/page?ref=newsletter&a=1",
+			incoming:    "ref=twitter",
+			policy:      QueryOverride,
+			expected:    "https://example.com/page?a=1&ref=twitter",
+		},
+		"Override keeps the order of other destination values": {
+			destination: "https://example.com/page?z=1&ref=newsletter&a=2&ref=mail",
+			incoming:    "ref=twitter",
+			policy:      QueryOverride,
+			expected:    "https://example.com/page?z=1&a=2&ref=twitter",
+		},
+		"Append keeps unparseable and valueless destination params": {
+			destination: "https://example.com/page?flag&a=1;b=2&c=%zz",
+			incoming:    "ref=twitter",
+			policy:      QueryAppend,
+			expected:    "https://example.com/page?flag&a=1;b=2&c=%zz&ref=twitter",
+		},
+		"Override matches escaped destination keys": {
+			destination: "https://example.com/page?utm%5Fsource=mail&flag",
+			incoming:    "utm_source=twitter",
+			policy:      QueryOverride,
+			expected:    "https://example.com/page?flag&utm_source=twitter",
+		},
+		"Fragment preserved": {
+			destination: "https://example.com/page?a=1#section-2",
+			incoming:    "b=2",
+			policy:      QueryAppend,
+			expected:    "https://example.com/page?a=1&b=2#section-2",
+		},
+		"Encoded values": {
+			destination: "https://example.com/search",
+			incoming:    "q=a%26b+c",
+			policy:      QueryOverride,
+			expected:    "https://example.com/search?q=a%26b+c",
+		},
+		"No incoming query": {
+			destination: "https://example.com/page?z=1&a=2",
+			policy:      QueryAppend,
+			expected:    "https://example.com/page?z=1&a=2",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			incoming, err := url.ParseQuery(tc.incoming)
+			assert.NoError(t, err)
+
+			got, err := MergeQuery(tc.destination, incoming, tc.policy)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_JoinPath(t *testing.T) {
+	tests := map[string]struct {
+		destination string
+		suffix      string
+		expected    string
+		expectError bool
+	}{
+		"Simple suffix": {
+			destination: "https://example.com/docs",
+			suffix:      "getting-started",
+			expected:    "https://example.com/docs/getting-started",
+		},
+		"Destination with trailing slash": {
+			destination: "https://example.com/docs/",
+			suffix:      "guides/install",
+			expected:    "https://example.com/docs/guides/install",
+		},
+		"Destination without path": {
+			destination: "https://example.com",
+			suffix:      "a",
+			expected:    "https://example.com/a",
+		},
+		"Trailing slash kept": {
+			destination: "https://example.com/docs",
+			suffix:      "guides/",
+			expected:    "https://example.com/docs/guides/",
+		},
+		"Empty segments dropped": {
+			destination: "https://example.com/docs",
+			suffix:      "a//b",
+			expected:    "https://example.com/docs/a/b",
+		},
+		"Query and fragment preserved": {
+			destination: "https://example.com/docs?v=2#top",
+			suffix:      "a",
+			expected:    "https://example.com/docs/a?v=2#top",
+		},
+		"Encoded slash stays in segment": {
+			destination: "https://example.com/files",
+			suffix:      "a%2Fb",
+			expected:    "https://example.com/files/a%2Fb",
+		},
+		"Encoded space": {
+			destination: "https://example.com/files",
+			suffix:      "a%20b",
+			expected:    "https://example.com/files/a%20b",
+		},
+		"Encoded unicode": {
+			destination: "https://example.com/menu",
+			suffix:      "caf%C3%A9",
+			expected:    "https://example.com/menu/caf%C3%A9",
+		},
+		"Encoded percent": {
+			destination: "https://example.com/deals",
+			suffix:      "100%25",
+			expected:    "https://example.com/deals/100%25",
+		},
+		"Destination already encoded": {
+			destination: "https://example.com/a%2Fb",
+			suffix:      "c",
+			expected:    "https://example.com/a%2Fb/c",
+		},
+		"Empty suffix": {
+			destination: "https://example.com/docs?v=1",
+			suffix:      "/",
+			expected:    "https://example.com/docs?v=1",
+		},
+		"Dot dot rejected": {
+			destination: "https://example.com/docs",
+			suffix:      "../admin",
+			expectError: true,
+		},
+		"Encoded dot dot rejected": {
+			destination: "https://example.com/docs",
+			suffix:      "a/%2e%2E/admin",
+			expectError: true,
+		},
+		"Backslash rejected": {
+			destination: "https://example.com/docs",
+			suffix:      "a%5C..%5Cadmin",
+			expectError: true,
+		},
+		"Invalid escape rejected": {
+			destination: "https://example.com/docs",
+			suffix:      "a%zz",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := JoinPath(tc.destination, tc.suffix)
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidPath)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_PickVariant(t *testing.T) {
+	link := &Link{
+		OriginalURL: "http://default.com",
+		LinkOptions: LinkOptions{
+			Destinations: []Destination{
+				{URL: "http://a.com", Weight: 1},
+				{URL: "http://b.com", Weight: 3},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		link     *Link
+		sticky   int
+		draw     int
+		expected int
+	}{
+		"No destinations":      {link: &Link{OriginalURL: "http://default.com"}, sticky: -1, expected: -1},
+		"First weight bucket":  {link: link, sticky: -1, draw: 0, expected: 0},
+		"Second weight bucket": {link: link, sticky: -1, draw: 1, expected: 1},
+		"Last draw":            {link: link, sticky: -1, draw: 3, expected: 1},
+		"Sticky ignored when link is not sticky": {
+			link: link, sticky: 0, draw: 2, expected: 1,
+		},
+		"Sticky variant": {
+			link:     &Link{LinkOptions: LinkOptions{Destinations: link.Destinations, Sticky: true}},
+			sticky:   0,
+			draw:     3,
+			expected: 0,
+		},
+		"Sticky variant out of range": {
+			link:     &Link{LinkOptions: LinkOptions{Destinations: link.Destinations, Sticky: true}},
+			sticky:   5,
+			draw:     0,
+			expected: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.link.PickVariant(tc.sticky, func(n int) int {
+				assert.Equal(t, 4, n)
+				return tc.draw
+			})
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	assert.Equal(t, "http://b.com", link.VariantURL(1))
+	assert.Equal(t, "http://default.com", link.VariantURL(-1))
+}
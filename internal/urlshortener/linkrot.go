@@ -0,0 +1,169 @@
+package urlshortener
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/linkcheck"
+	"github.com/connorpalermo/url-shortener/internal/logging"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"go.uber.org/zap"
+)
+
+type (
+	// LinkChecker probes destinations, see linkcheck.Checker.
+	LinkChecker interface {
+		CheckAll(ctx context.Context, urls []string) map[string]linkcheck.Result
+	}
+
+	// LinkHealth records the last link rot check. URL, StatusCode and Error describe the first
+	// broken destination, or the original URL when every destination works.
+	LinkHealth struct {
+		Broken     bool      `dynamodbav:"broken" json:"broken"`
+		URL        string    `dynamodbav:"url" json:"url,omitempty"`
+		StatusCode int       `dynamodbav:"status_code,omitempty" json:"status_code,omitempty"`
+		Error      string    `dynamodbav:"error,omitempty" json:"error,omitempty"`
+		CheckedAt  time.Time `dynamodbav:"checked_at" json:"checked_at"`
+	}
+
+	// LinkCheckReport summarizes a CheckLinks run. Failed counts links whose health or flag could
+	// not be stored. Flagged lists the links the reputation recheck flagged. More is set when the
+	// run stopped before the last link and the next one continues where it ended.
+	LinkCheckReport struct {
+		Checked int     `json:"checked"`
+		Failed  int     `json:"failed,omitempty"`
+		Broken  []*Link `json:"broken"`
+		Flagged []*Link `json:"flagged,omitempty"`
+		More    bool    `json:"more,omitempty"`
+	}
+)
+
+// DefaultLinkCheckPages keeps a run of MaxPageSize links per page well within the 15 minutes a
+// Lambda invocation may take.
+const DefaultLinkCheckPages = 10
+
+// CheckLinks goes through the links that can still redirect, checks their destinations and stores
+// the outcome on the link. With a reputation checker configured the destinations of those links
+// and of blocked ones are also vetted again, so destinations that turned malicious stop
+// redirecting and cleared ones redirect again. Links are checked a page at a time and a run stops
+// after LinkCheckPages pages. The key of the last finished page is stored, so the next run, and
+// one after an interrupted run, continues from there and starts over once every link was checked.
+func (u *UrlShortener) CheckLinks(ctx context.Context, checker LinkChecker) (*LinkCheckReport, error) {
+	logger := logging.FromContext(ctx, u.Logger)
+	report := &LinkCheckReport{Broken: []*Link{}}
+
+	startKey, err := u.DBClient.GetLinkCheckCursor(ctx)
+	if err != nil {
+		return report, err
+	}
+	for pages := 1; ; pages++ {
+		items, lastKey, err := u.DBClient.QueryLinks(ctx, urlDB.LinkQuery{
+			Limit:     MaxPageSize,
+			Ascending: true,
+			StartKey:  startKey,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		now := u.now()
+		var links, rechecks []*Link
+		var urls []string
+		for _, item := range items {
+			var link Link
+			if err = attributevalue.UnmarshalMap(item, &link); err != nil {
+				return report, err
+			}
+			link.Status = link.StatusAt(now)
+			if link.Status == StatusBlocked {
+				rechecks = append(rechecks, &link)
+			}
+			if link.Status != StatusActive && link.Status != StatusScheduled {
+				continue
+			}
+			links = append(links, &link)
+			rechecks = append(rechecks, &link)
+			urls = append(urls, linkURLs(link.OriginalURL, link.LinkOptions)...)
+		}
+
+		results := checker.CheckAll(ctx, urls)
+		if err = ctx.Err(); err != nil {
+			return report, err
+		}
+		checkedAt := u.now().UTC()
+		for _, link := range links {
+			link.Health = linkHealth(linkURLs(link.OriginalURL, link.LinkOptions), results, checkedAt)
+			report.Checked++
+			if link.Health.Broken {
+				report.Broken = append(report.Broken, link)
+				logger.Warn("broken link", zap.String(logkey.ShortenedURL, link.ShortURL), zap.String(logkey.OriginalURL, link.Health.URL),
+					zap.Int(logkey.StatusCode, link.Health.StatusCode), zap.String(logkey.Error, link.Health.Error))
+			}
+			if err = u.storeHealth(ctx, link); err != nil {
+				report.Failed++
+				logger.Error("failed to store link health", zap.String(logkey.ShortenedURL, link.ShortURL), zap.Error(err))
+			}
+		}
+		if u.Reputation.Checker != nil && len(rechecks) > 0 {
+			u.recheckReputation(ctx, rechecks, report)
+		}
+
+		if err = u.DBClient.SetLinkCheckCursor(ctx, lastKey); err != nil {
+			return report, err
+		}
+		if len(lastKey) == 0 {
+			break
+		}
+		if pages >= u.linkCheckPages() {
+			report.More = true
+			break
+		}
+		startKey = lastKey
+	}
+
+	logger.Info("link check finished", zap.Int(logkey.Checked, report.Checked), zap.Int(logkey.Broken, len(report.Broken)),
+		zap.Int(logkey.Flagged, len(report.Flagged)), zap.Int(logkey.Failed, report.Failed))
+	return report, nil
+}
+
+func (u *UrlShortener) linkCheckPages() int {
+	if u.LinkCheckPages > 0 {
+		return u.LinkCheckPages
+	}
+	return DefaultLinkCheckPages
+}
+
+func (u *UrlShortener) storeHealth(ctx context.Context, link *Link) error {
+	health, err := attributevalue.Marshal(link.Health)
+	if err != nil {
+		return err
+	}
+	return u.DBClient.UpdateAttributes(ctx, link.ShortURL, map[string]types.AttributeValue{urlDB.Health: health}, nil)
+}
+
+// linkHealth reports the first broken URL of a link, or its first URL when none is broken.
+func linkHealth(urls []string, results map[string]linkcheck.Result, checkedAt time.Time) *LinkHealth {
+	result := results[urls[0]]
+	for _, u := range urls {
+		if results[u].Broken() {
+			result = results[u]
+			break
+		}
+	}
+	health := &LinkHealth{
+		Broken:     result.Broken(),
+		URL:        urls[0],
+		StatusCode: result.StatusCode,
+		CheckedAt:  checkedAt,
+	}
+	if result.URL != "" {
+		health.URL = result.URL
+	}
+	if result.Err != nil {
+		health.Error = result.Err.Error()
+	}
+	return health
+}
@@ -0,0 +1,245 @@
+package urlshortener
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/connorpalermo/url-shortener/internal/linkcheck"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_CheckLinks(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	item := func(code, url string, extra map[string]types.AttributeValue) map[string]types.AttributeValue {
+		item := map[string]types.AttributeValue{
+			"id":           &types.AttributeValueMemberN{Value: "1"},
+			"short_url":    &types.AttributeValueMemberS{Value: code},
+			"original_url": &types.AttributeValueMemberS{Value: url},
+		}
+		for k, v := range extra {
+			item[k] = v
+		}
+		return item
+	}
+	health := func(broken bool, url string, status int) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"health": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"broken":      &types.AttributeValueMemberBOOL{Value: broken},
+				"url":         &types.AttributeValueMemberS{Value: url},
+				"status_code": &types.AttributeValueMemberN{Value: strconv.Itoa(status)},
+				"checked_at":  &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00Z"},
+			}},
+		}
+	}
+	lastKey := map[string]types.AttributeValue{"short_url": &types.AttributeValueMemberS{Value: "c"}}
+
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetLinkCheckCursor").Return(nil, nil)
+	m.On("SetLinkCheckCursor", lastKey).Return(nil)
+	m.On("SetLinkCheckCursor", map[string]types.AttributeValue(nil)).Return(nil)
+	m.On("QueryLinks", urlDB.LinkQuery{Limit: MaxPageSize, Ascending: true}).Return([]map[string]types.AttributeValue{
+		item("b", srv.URL+"/ok", nil),
+		item("c", srv.URL+"/ok", map[string]types.AttributeValue{
+			"destinations": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"url":    &types.AttributeValueMemberS{Value: srv.URL + "/variant"},
+					"weight": &types.AttributeValueMemberN{Value: "1"},
+				}},
+			}},
+		}),
+	}, lastKey, nil)
+	m.On("QueryLinks", urlDB.LinkQuery{Limit: MaxPageSize, Ascending: true, StartKey: lastKey}).Return([]map[string]types.AttributeValue{
+		item("d", srv.URL+"/missing", nil),
+		item("e", srv.URL+"/missing", map[string]types.AttributeValue{"disabled": &types.AttributeValueMemberBOOL{Value: true}}),
+	}, nil, nil)
+	m.On("UpdateAttributes", "b", health(false, srv.URL+"/ok", http.StatusOK), []string(nil)).Return(nil)
+	m.On("UpdateAttributes", "c", health(true, srv.URL+"/variant", http.StatusNotFound), []string(nil)).Return(nil)
+	m.On("UpdateAttributes", "d", health(true, srv.URL+"/missing", http.StatusNotFound), []string(nil)).Return(errors.New("error"))
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+		Now:      func() time.Time { return now },
+	}
+
+	report, err := u.CheckLinks(context.Background(), &linkcheck.Checker{Client: srv.Client(), HostDelay: time.Millisecond})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, 1, report.Failed)
+	assert.False(t, report.More)
+	if assert.Len(t, report.Broken, 2) {
+		assert.Equal(t, "c", report.Broken[0].ShortURL)
+		assert.Equal(t, "d", report.Broken[1].ShortURL)
+		assert.Equal(t, http.StatusNotFound, report.Broken[1].Health.StatusCode)
+	}
+	m.AssertExpectations(t)
+	m.AssertNotCalled(t, "UpdateAttributes", "e", mock.Anything, mock.Anything)
+}
+
+func Test_CheckLinks_Resume(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	cursor := map[string]types.AttributeValue{"short_url": &types.AttributeValueMemberS{Value: "b"}}
+	lastKey := map[string]types.AttributeValue{"short_url": &types.AttributeValueMemberS{Value: "c"}}
+	m := new(MockDBProvider)
+	m.On("GetLinkCheckCursor").Return(cursor, nil)
+	m.On("QueryLinks", urlDB.LinkQuery{Limit: MaxPageSize, Ascending: true, StartKey: cursor}).Return([]map[string]types.AttributeValue{{
+		"id":           &types.AttributeValueMemberN{Value: "2"},
+		"short_url":    &types.AttributeValueMemberS{Value: "c"},
+		"original_url": &types.AttributeValueMemberS{Value: srv.URL},
+	}}, lastKey, nil)
+	m.On("UpdateAttributes", "c", mock.Anything, []string(nil)).Return(nil)
+	m.On("SetLinkCheckCursor", lastKey).Return(nil)
+	u := &UrlShortener{Logger: zap.NewNop(), DBClient: m, LinkCheckPages: 1}
+
+	report, err := u.CheckLinks(context.Background(), &linkcheck.Checker{Client: srv.Client(), HostDelay: time.Millisecond})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Checked)
+	assert.True(t, report.More, "the next run continues after c")
+	m.AssertExpectations(t)
+	m.AssertNumberOfCalls(t, "QueryLinks", 1)
+}
+
+func Test_CheckLinks_Error(t *testing.T) {
+	tests := map[string]struct {
+		cursorError error
+		queryError  error
+	}{
+		"Sad Path cursor error": {
+			cursorError: errors.New("error"),
+		},
+		"Sad Path query error": {
+			queryError: errors.New("error"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockDBProvider)
+			m.On("GetLinkCheckCursor").Return(nil, tc.cursorError)
+			m.On("QueryLinks", mock.Anything).Return(nil, nil, tc.queryError)
+			u := &UrlShortener{Logger: zap.NewNop(), DBClient: m}
+
+			_, err := u.CheckLinks(context.Background(), &linkcheck.Checker{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_CheckLinks_Reputation(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	flag := func(url string, verdict reputation.Verdict, checkedAt string) *types.AttributeValueMemberM {
+		return &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"url":        &types.AttributeValueMemberS{Value: url},
+			"verdict":    &types.AttributeValueMemberS{Value: string(verdict)},
+			"checked_at": &types.AttributeValueMemberS{Value: checkedAt},
+		}}
+	}
+	health := func(url string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"health": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"broken":      &types.AttributeValueMemberBOOL{Value: false},
+				"url":         &types.AttributeValueMemberS{Value: url},
+				"status_code": &types.AttributeValueMemberN{Value: "200"},
+				"checked_at":  &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00Z"},
+			}},
+		}
+	}
+	items := []map[string]types.AttributeValue{
+		{
+			"id":           &types.AttributeValueMemberN{Value: "1"},
+			"short_url":    &types.AttributeValueMemberS{Value: "b"},
+			"original_url": &types.AttributeValueMemberS{Value: srv.URL + "/b"},
+		},
+		{
+			"id":           &types.AttributeValueMemberN{Value: "2"},
+			"short_url":    &types.AttributeValueMemberS{Value: "c"},
+			"original_url": &types.AttributeValueMemberS{Value: srv.URL + "/c"},
+			"flag":         flag(srv.URL+"/c", reputation.Malicious, "2025-02-01T09:00:00Z"),
+		},
+		{
+			"id":           &types.AttributeValueMemberN{Value: "3"},
+			"short_url":    &types.AttributeValueMemberS{Value: "d"},
+			"original_url": &types.AttributeValueMemberS{Value: srv.URL + "/d"},
+		},
+	}
+
+	tests := map[string]struct {
+		checkError      error
+		expectedFlagged []string
+		expectedUpdates int
+	}{
+		"Happy Path": {
+			expectedFlagged: []string{"b"},
+			expectedUpdates: 4,
+		},
+		"Sad Path checker error keeps flags": {
+			checkError:      errors.New("error"),
+			expectedUpdates: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := new(MockReputationChecker)
+			// the blocked link c is vetted again but its destination is not probed
+			checker.On("Check", []string{srv.URL + "/b", srv.URL + "/c", srv.URL + "/d"}).
+				Return([]reputation.Match{{URL: srv.URL + "/b", Verdict: reputation.Malicious}}, tc.checkError)
+			m := new(MockDBProvider)
+			m.On("GetLinkCheckCursor").Return(nil, nil)
+			m.On("SetLinkCheckCursor", map[string]types.AttributeValue(nil)).Return(nil)
+			m.On("QueryLinks", urlDB.LinkQuery{Limit: MaxPageSize, Ascending: true}).Return(items, nil, nil)
+			m.On("UpdateAttributes", "b", health(srv.URL+"/b"), []string(nil)).Return(nil)
+			m.On("UpdateAttributes", "d", health(srv.URL+"/d"), []string(nil)).Return(nil)
+			if tc.checkError == nil {
+				m.On("UpdateAttributes", "b", map[string]types.AttributeValue{
+					"flag": flag(srv.URL+"/b", reputation.Malicious, "2025-03-01T09:00:00Z"),
+				}, []string(nil)).Return(nil)
+				m.On("UpdateAttributes", "c", map[string]types.AttributeValue{}, []string{"flag"}).Return(nil)
+			}
+			u := &UrlShortener{
+				Logger:     zap.NewNop(),
+				DBClient:   m,
+				Now:        func() time.Time { return now },
+				Reputation: ReputationCheck{Checker: checker},
+			}
+
+			report, err := u.CheckLinks(context.Background(), &linkcheck.Checker{Client: srv.Client(), HostDelay: time.Millisecond})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 2, report.Checked)
+			assert.Zero(t, report.Failed)
+			var flagged []string
+			for _, link := range report.Flagged {
+				flagged = append(flagged, link.ShortURL)
+			}
+			assert.Equal(t, tc.expectedFlagged, flagged)
+			checker.AssertExpectations(t)
+			m.AssertExpectations(t)
+			m.AssertNumberOfCalls(t, "UpdateAttributes", tc.expectedUpdates)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package urlshortener
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+)
+
+type (
+	// LinkFilter selects the links ListLinks returns. Empty fields match every link.
+	LinkFilter struct {
+		Owner  string
+		Tag    string
+		Domain string
+		// CreatedAfter is inclusive, CreatedBefore exclusive.
+		CreatedAfter  *time.Time
+		CreatedBefore *time.Time
+		// Limit caps the links read for the page, DefaultPageSize when zero.
+		Limit int
+		// Cursor continues a previous listing with the same filter.
+		Cursor string
+		// Ascending lists the oldest links first instead of the newest.
+		Ascending bool
+	}
+
+	// LinkPage is one page of a listing. NextCursor is empty on the last page.
+	LinkPage struct {
+		Links      []*Link `json:"links"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+var ErrInvalidFilter = errors.New("invalid link filter")
+
+// ListLinks returns one page of links ordered by creation time. Links created before creation
+// times were recorded are not listed.
+func (u *UrlShortener) ListLinks(ctx context.Context, filter LinkFilter) (*LinkPage, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxPageSize)
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedBefore.After(*filter.CreatedAfter) {
+		return nil, fmt.Errorf("%w: created_before must be later than created_after", ErrInvalidFilter)
+	}
+	owner := strings.TrimSpace(filter.Owner)
+	startKey, err := decodeCursor(filter.Cursor, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	items, lastKey, err := u.DBClient.QueryLinks(ctx, urlDB.LinkQuery{
+		Owner:         owner,
+		Tag:           strings.ToLower(strings.TrimSpace(filter.Tag)),
+		Domain:        strings.ToLower(strings.TrimSpace(filter.Domain)),
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Limit:         int32(filter.Limit),
+		Ascending:     filter.Ascending,
+		StartKey:      startKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.now()
+	page := &LinkPage{Links: make([]*Link, 0, len(items))}
+	for _, item := range items {
+		var link Link
+		if err = attributevalue.UnmarshalMap(item, &link); err != nil {
+			return nil, err
+		}
+		link.Status = link.StatusAt(now)
+		page.Links = append(page.Links, &link)
+	}
+	if page.NextCursor, err = encodeCursor(lastKey); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// encodeCursor hides the index key DynamoDB continues a query from. Every attribute of the keys
+// of the table and its indexes is a string, so that is all a cursor holds.
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	values := make(map[string]string, len(key))
+	for name, value := range key {
+		s, ok := value.(*types.AttributeValueMemberS)
+		if !ok {
+			return "", fmt.Errorf("unexpected type for key attribute %s", name)
+		}
+		values[name] = s.Value
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor only accepts the key of a link in the index a listing of owner queries, so a cursor
+// cannot continue a listing of other items or of another owner.
+func decodeCursor(cursor, owner string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	var values map[string]string
+	if err = json.Unmarshal(b, &values); err != nil || len(values) == 0 {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidFilter)
+	}
+	partition, value := urlDB.Entity, urlDB.EntityLink
+	if owner != "" {
+		partition, value = urlDB.Owner, owner
+	}
+	_, hasShortURL := values[urlDB.ShortURL]
+	_, hasCreatedAt := values[urlDB.CreatedAt]
+	if len(values) != 3 || !hasShortURL || !hasCreatedAt || values[partition] != value {
+		return nil, fmt.Errorf("%w: cursor does not belong to this listing", ErrInvalidFilter)
+	}
+	key := make(map[string]types.AttributeValue, len(values))
+	for name, value := range values {
+		key[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	return key, nil
+}
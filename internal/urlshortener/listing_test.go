@@ -0,0 +1,119 @@
+package urlshortener
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_ListLinks(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastKey := map[string]types.AttributeValue{
+		"short_url":  &types.AttributeValueMemberS{Value: "b"},
+		"entity":     &types.AttributeValueMemberS{Value: "link"},
+		"created_at": &types.AttributeValueMemberS{Value: "2025-01-02T00:00:00.000Z"},
+	}
+	items := []map[string]types.AttributeValue{
+		{
+			"id":           &types.AttributeValueMemberN{Value: "2"},
+			"short_url":    &types.AttributeValueMemberS{Value: "c"},
+			"original_url": &types.AttributeValueMemberS{Value: "http://c.example.com"},
+			"disabled":     &types.AttributeValueMemberBOOL{Value: true},
+			"created_at":   &types.AttributeValueMemberS{Value: "2025-01-03T00:00:00.000Z"},
+		},
+		{
+			"id":           &types.AttributeValueMemberN{Value: "1"},
+			"short_url":    &types.AttributeValueMemberS{Value: "b"},
+			"original_url": &types.AttributeValueMemberS{Value: "http://b.example.com"},
+			"owner":        &types.AttributeValueMemberS{Value: "growth"},
+			"created_at":   &types.AttributeValueMemberS{Value: "2025-01-02T00:00:00.000Z"},
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	t.Run("Happy Path", func(t *testing.T) {
+		m := new(MockDBProvider)
+		m.On("QueryLinks", urlDB.LinkQuery{
+			Owner:        "growth",
+			Tag:          "launch",
+			Domain:       "example.com",
+			CreatedAfter: &after,
+			Limit:        DefaultPageSize,
+		}).Return(items, lastKey, nil)
+		u := &UrlShortener{Logger: logger, DBClient: m}
+
+		page, err := u.ListLinks(context.Background(), LinkFilter{
+			Owner:        " growth ",
+			Tag:          " Launch ",
+			Domain:       "Example.com",
+			CreatedAfter: &after,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Links, 2) {
+			assert.Equal(t, "c", page.Links[0].ShortURL)
+			assert.Equal(t, StatusDisabled, page.Links[0].Status)
+			assert.Equal(t, "b", page.Links[1].ShortURL)
+			assert.Equal(t, StatusActive, page.Links[1].Status)
+			assert.Equal(t, "growth", page.Links[1].Owner)
+			assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), *page.Links[1].CreatedAt)
+		}
+		assert.NotEmpty(t, page.NextCursor)
+		m.AssertExpectations(t)
+
+		// the cursor continues the query where the page ended
+		m.On("QueryLinks", urlDB.LinkQuery{Limit: 10, Ascending: true, StartKey: lastKey}).Return(nil, nil, nil)
+		page, err = u.ListLinks(context.Background(), LinkFilter{Limit: 10, Ascending: true, Cursor: page.NextCursor})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Links)
+		assert.NotNil(t, page.Links)
+		assert.Empty(t, page.NextCursor)
+		m.AssertExpectations(t)
+	})
+
+	cursor := func(key map[string]string) string {
+		values := make(map[string]types.AttributeValue, len(key))
+		for name, value := range key {
+			values[name] = &types.AttributeValueMemberS{Value: value}
+		}
+		c, err := encodeCursor(values)
+		assert.NoError(t, err)
+		return c
+	}
+	webhookCursor := cursor(map[string]string{"short_url": "_webhook", "entity": "webhook", "created_at": "2025-01-02T00:00:00.000Z"})
+	ownerCursor := cursor(map[string]string{"short_url": "b", "owner": "growth", "created_at": "2025-01-02T00:00:00.000Z"})
+	extraCursor := cursor(map[string]string{"short_url": "b", "entity": "link", "created_at": "2025-01-02T00:00:00.000Z", "id": "1"})
+
+	invalid := map[string]LinkFilter{
+		"Sad Path negative limit":               {Limit: -1},
+		"Sad Path limit too large":              {Limit: MaxPageSize + 1},
+		"Sad Path empty range":                  {CreatedAfter: &after, CreatedBefore: &after},
+		"Sad Path cursor encoding":              {Cursor: "not base64!"},
+		"Sad Path cursor content":               {Cursor: "bm90IGpzb24"},
+		"Sad Path cursor of other items":        {Cursor: webhookCursor},
+		"Sad Path cursor of another owner":      {Owner: "sales", Cursor: ownerCursor},
+		"Sad Path owner cursor without owner":   {Cursor: ownerCursor},
+		"Sad Path cursor with extra attributes": {Cursor: extraCursor},
+	}
+	for name, filter := range invalid {
+		t.Run(name, func(t *testing.T) {
+			u := &UrlShortener{Logger: logger, DBClient: new(MockDBProvider)}
+			_, err := u.ListLinks(context.Background(), filter)
+			assert.ErrorIs(t, err, ErrInvalidFilter)
+		})
+	}
+
+	t.Run("Sad Path query error", func(t *testing.T) {
+		m := new(MockDBProvider)
+		m.On("QueryLinks", urlDB.LinkQuery{Limit: DefaultPageSize}).Return(nil, nil, errors.New("error"))
+		u := &UrlShortener{Logger: logger, DBClient: m}
+		_, err := u.ListLinks(context.Background(), LinkFilter{})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidFilter)
+	})
+}
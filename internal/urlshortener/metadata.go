@@ -0,0 +1,86 @@
+package urlshortener
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	// Metadata describes a link for the people managing it. It has no effect on redirects.
+	Metadata struct {
+		Title string   `dynamodbav:"title,omitempty" json:"title,omitempty"`
+		Tags  []string `dynamodbav:"tags,omitempty" json:"tags,omitempty"`
+		Notes string   `dynamodbav:"notes,omitempty" json:"notes,omitempty"`
+	}
+
+	// MetadataUpdate changes the fields that are not nil. An empty value clears a field.
+	MetadataUpdate struct {
+		Title *string   `json:"title,omitempty"`
+		Tags  *[]string `json:"tags,omitempty"`
+		Notes *string   `json:"notes,omitempty"`
+	}
+)
+
+const (
+	MaxTitleLength = 200
+	MaxNotesLength = 2000
+	MaxTags        = 20
+	MaxTagLength   = 50
+)
+
+// metadataAttributes are the item attributes Metadata is stored in.
+var metadataAttributes = []string{"title", "tags", "notes"}
+
+// NormalizeTags lower cases and trims tags, dropping empty and duplicate ones, and sorts them so
+// the same set of tags is always stored the same way.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// HasTag reports whether the link is tagged with tag, ignoring case.
+func (m Metadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, strings.ToLower(strings.TrimSpace(tag)))
+}
+
+func (m Metadata) validate() error {
+	if utf8.RuneCountInString(m.Title) > MaxTitleLength {
+		return fmt.Errorf("%w: titles are limited to %d characters", ErrInvalidLink, MaxTitleLength)
+	}
+	if utf8.RuneCountInString(m.Notes) > MaxNotesLength {
+		return fmt.Errorf("%w: notes are limited to %d characters", ErrInvalidLink, MaxNotesLength)
+	}
+	if len(m.Tags) > MaxTags {
+		return fmt.Errorf("%w: at most %d tags are supported", ErrInvalidLink, MaxTags)
+	}
+	for _, tag := range m.Tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength || strings.ContainsRune(tag, ',') {
+			return fmt.Errorf("%w: tag %q must be at most %d characters without commas", ErrInvalidLink, tag, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// apply returns m with the update applied and its tags normalized.
+func (u MetadataUpdate) apply(m Metadata) Metadata {
+	if u.Title != nil {
+		m.Title = strings.TrimSpace(*u.Title)
+	}
+	if u.Tags != nil {
+		m.Tags = *u.Tags
+	}
+	if u.Notes != nil {
+		m.Notes = *u.Notes
+	}
+	m.Tags = NormalizeTags(m.Tags)
+	return m
+}
@@ -0,0 +1,47 @@
+package urlshortener
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"launch", "q3"}, NormalizeTags([]string{" Q3", "launch", "", "q3 "}))
+	assert.Nil(t, NormalizeTags(nil))
+	assert.Nil(t, NormalizeTags([]string{" "}))
+}
+
+func Test_HasTag(t *testing.T) {
+	m := Metadata{Tags: []string{"launch", "q3"}}
+
+	assert.True(t, m.HasTag("Q3"))
+	assert.False(t, m.HasTag("q4"))
+}
+
+func Test_ValidateMetadata(t *testing.T) {
+	tests := map[string]struct {
+		metadata    Metadata
+		expectError bool
+	}{
+		"Valid":          {metadata: Metadata{Title: "Launch", Tags: []string{"q3"}, Notes: "for the blog"}},
+		"Long title":     {metadata: Metadata{Title: strings.Repeat("a", MaxTitleLength+1)}, expectError: true},
+		"Long notes":     {metadata: Metadata{Notes: strings.Repeat("a", MaxNotesLength+1)}, expectError: true},
+		"Too many tags":  {metadata: Metadata{Tags: make([]string, MaxTags+1)}, expectError: true},
+		"Long tag":       {metadata: Metadata{Tags: []string{strings.Repeat("a", MaxTagLength+1)}}, expectError: true},
+		"Tag with comma": {metadata: Metadata{Tags: []string{"a,b"}}, expectError: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := LinkOptions{Metadata: tc.metadata}.Validate()
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidLink)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package urlshortener
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxPasswordLength is the longest password bcrypt hashes without truncating it.
+const MaxPasswordLength = 72
+
+// HashPassword returns a salted bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Protected reports whether visitors need a password before being redirected.
+func (l *Link) Protected() bool {
+	return l.PasswordHash != ""
+}
+
+// CheckPassword reports whether password matches the one the link was created with.
+func (l *Link) CheckPassword(password string) bool {
+	if !l.Protected() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)) == nil
+}
+
+// Confidential reports whether the destinations of the link are kept from callers who only know
+// the short URL at now: those of password protected links, of links with a click budget, which
+// reading them would spend for free, and of links that are not live yet.
+func (l *Link) Confidential(now time.Time) bool {
+	return l.Protected() || l.MaxClicks > 0 || (l.NotBefore != nil && now.Before(*l.NotBefore))
+}
+
+// Redacted returns a copy of a confidential link without its URLs and notes, for callers who only
+// know the short URL. Other links are returned as they are.
+func (l *Link) Redacted(now time.Time) *Link {
+	if !l.Confidential(now) {
+		return l
+	}
+	redacted := *l
+	redacted.OriginalURL = ""
+	redacted.Notes = ""
+	redacted.PrelaunchURL = ""
+	redacted.Destinations = make([]Destination, len(l.Destinations))
+	for i, d := range l.Destinations {
+		redacted.Destinations[i] = Destination{Weight: d.Weight}
+	}
+	redacted.Rules = nil
+	if l.Health != nil {
+		health := *l.Health
+		health.URL = ""
+		redacted.Health = &health
+	}
+	if l.Flag != nil {
+		flag := *l.Flag
+		flag.URL = ""
+		redacted.Flag = &flag
+	}
+	return &redacted
+}
@@ -0,0 +1,106 @@
+package urlshortener
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	assert.NoError(t, err)
+	assert.NotContains(t, hash, "correct horse")
+
+	other, err := HashPassword("correct horse")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "hashes should be salted")
+
+	link := &Link{LinkOptions: LinkOptions{PasswordHash: hash}}
+	assert.True(t, link.Protected())
+	assert.True(t, link.CheckPassword("correct horse"))
+	assert.False(t, link.CheckPassword("Correct horse"))
+	assert.False(t, link.CheckPassword(""))
+
+	open := &Link{}
+	assert.False(t, open.Protected())
+	assert.True(t, open.CheckPassword(""))
+}
+
+func Test_ValidatePassword(t *testing.T) {
+	assert.NoError(t, LinkOptions{Password: strings.Repeat("a", MaxPasswordLength)}.Validate())
+	assert.ErrorIs(t, LinkOptions{Password: strings.Repeat("a", MaxPasswordLength+1)}.Validate(), ErrInvalidLink)
+}
+
+func Test_Confidential(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	launch := now.Add(time.Hour)
+	started := now.Add(-time.Hour)
+
+	tests := map[string]struct {
+		options  LinkOptions
+		expected bool
+	}{
+		"Happy Path open link": {},
+		"Happy Path launched link": {
+			options: LinkOptions{NotBefore: &started},
+		},
+		"Password protected": {
+			options:  LinkOptions{PasswordHash: "hash"},
+			expected: true,
+		},
+		"One-time link": {
+			options:  LinkOptions{MaxClicks: 1},
+			expected: true,
+		},
+		"Not live yet": {
+			options:  LinkOptions{NotBefore: &launch, PrelaunchURL: "https://example.com/soon"},
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			link := &Link{ShortURL: "b", OriginalURL: "https://example.com", LinkOptions: tc.options}
+			assert.Equal(t, tc.expected, link.Confidential(now))
+		})
+	}
+}
+
+func Test_Redacted(t *testing.T) {
+	now := time.Now()
+	open := &Link{ShortURL: "a", OriginalURL: "https://example.com"}
+	assert.Same(t, open, open.Redacted(now))
+
+	link := &Link{
+		ShortURL:    "b",
+		OriginalURL: "https://example.com/secret",
+		Health:      &LinkHealth{URL: "https://example.com/secret", StatusCode: 200},
+		Flag:        &Flag{Match: reputation.Match{URL: "https://example.com/secret", Verdict: reputation.Malicious}},
+		LinkOptions: LinkOptions{
+			PasswordHash: "hash",
+			Destinations: []Destination{{URL: "https://example.com/a", Weight: 1}, {URL: "https://example.com/b", Weight: 3}},
+			Rules:        []Rule{{Country: "DE", URL: "https://example.de/secret"}},
+			PrelaunchURL: "https://example.com/soon",
+			Metadata:     Metadata{Title: "Launch", Notes: "internal"},
+		},
+	}
+
+	redacted := link.Redacted(now)
+
+	assert.Equal(t, &Link{
+		ShortURL: "b",
+		Health:   &LinkHealth{StatusCode: 200},
+		Flag:     &Flag{Match: reputation.Match{Verdict: reputation.Malicious}},
+		LinkOptions: LinkOptions{
+			PasswordHash: "hash",
+			Destinations: []Destination{{Weight: 1}, {Weight: 3}},
+			Metadata:     Metadata{Title: "Launch"},
+		},
+	}, redacted)
+	assert.Equal(t, "https://example.com/secret", link.OriginalURL, "the stored link is left alone")
+	assert.Equal(t, "https://example.com/secret", link.Health.URL)
+	assert.Equal(t, "https://example.com/a", link.Destinations[0].URL)
+}
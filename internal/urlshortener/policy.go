@@ -0,0 +1,131 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/connorpalermo/url-shortener/internal/netaddr"
+)
+
+// DestinationPolicy decides which URLs links may redirect to, so the short domain cannot be used
+// to disguise phishing links or reach internal addresses. The zero value still rejects private,
+// loopback and link-local IP literals.
+type DestinationPolicy struct {
+	// DeniedDomains rejects these domains and all of their subdomains.
+	DeniedDomains []string
+	// ShortDomains are the hosts the service answers on. Links to them would redirect in a loop.
+	ShortDomains []string
+}
+
+const (
+	DeniedDomainsEnv = "DENIED_DOMAINS"
+	ShortDomainsEnv  = "SHORT_DOMAINS"
+)
+
+// ErrDestinationNotAllowed wraps ErrInvalidLink, so rejected destinations are reported as invalid
+// input.
+var ErrDestinationNotAllowed = fmt.Errorf("%w: destination not allowed", ErrInvalidLink)
+
+// PolicyFromEnv reads comma separated domain lists from DENIED_DOMAINS and SHORT_DOMAINS.
+func PolicyFromEnv() DestinationPolicy {
+	return DestinationPolicy{
+		DeniedDomains: splitDomains(os.Getenv(DeniedDomainsEnv)),
+		ShortDomains:  splitDomains(os.Getenv(ShortDomainsEnv)),
+	}
+}
+
+// Check reports why rawURL may not be used as a destination, wrapping ErrDestinationNotAllowed.
+// Only absolute http and https URLs with a host are allowed. Host names are not resolved, so a
+// name pointing at a private address is not caught here.
+func (p DestinationPolicy) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not a valid URL", ErrDestinationNotAllowed, rawURL)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("%w: %q is not an absolute http or https URL", ErrDestinationNotAllowed, rawURL)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrDestinationNotAllowed, rawURL)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !netaddr.Public(addr) {
+			return fmt.Errorf("%w: %s is not a public address", ErrDestinationNotAllowed, host)
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s is not a public address", ErrDestinationNotAllowed, host)
+	}
+	// browsers read hosts like 2852039166 or 0xa9.0xfe.0xa9.0xfe as IPv4 addresses netip rejects
+	if numericLabel(host[strings.LastIndexByte(host, '.')+1:]) {
+		return fmt.Errorf("%w: %s is an IP address in a non-standard notation", ErrDestinationNotAllowed, host)
+	}
+	if matchDomain(host, p.ShortDomains) {
+		return fmt.Errorf("%w: %s would redirect back to this service", ErrDestinationNotAllowed, host)
+	}
+	if matchDomain(host, p.DeniedDomains) {
+		return fmt.Errorf("%w: %s is on the deny list", ErrDestinationNotAllowed, host)
+	}
+	return nil
+}
+
+// checkLink checks every URL a link created with opts can redirect to.
+func (p DestinationPolicy) checkLink(originalURL string, opts LinkOptions) error {
+	for _, u := range linkURLs(originalURL, opts) {
+		if err := p.Check(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkURLs lists every URL a link can redirect to.
+func linkURLs(originalURL string, opts LinkOptions) []string {
+	urls := []string{originalURL}
+	for _, d := range opts.Destinations {
+		urls = append(urls, d.URL)
+	}
+	for _, r := range opts.Rules {
+		urls = append(urls, r.URL)
+	}
+	if opts.PrelaunchURL != "" {
+		urls = append(urls, opts.PrelaunchURL)
+	}
+	return urls
+}
+
+func numericLabel(label string) bool {
+	if hex, ok := strings.CutPrefix(label, "0x"); ok {
+		return strings.Trim(hex, "0123456789abcdef") == ""
+	}
+	return label != "" && strings.Trim(label, "0123456789") == ""
+}
+
+// matchDomain reports whether host is one of domains or a subdomain of one.
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(domain), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitDomains(v string) []string {
+	var domains []string
+	for _, domain := range strings.Split(v, ",") {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*.")
+		domain = strings.TrimSuffix(strings.TrimPrefix(domain, "."), ".")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,74 @@
+package urlshortener
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DestinationPolicy_Check(t *testing.T) {
+	policy := DestinationPolicy{
+		DeniedDomains: []string{"Evil.example"},
+		ShortDomains:  []string{"sho.rt"},
+	}
+
+	tests := map[string]struct {
+		url     string
+		allowed bool
+	}{
+		"public host":                {url: "https://www.example.com/path", allowed: true},
+		"public IPv4":                {url: "http://93.184.216.34", allowed: true},
+		"public IPv6":                {url: "http://[2606:2800:220:1:248:1893:25c8:1946]/", allowed: true},
+		"upper case scheme":          {url: "HTTPS://www.example.com", allowed: true},
+		"relative URL":               {url: "/path", allowed: false},
+		"scheme-less URL":            {url: "www.example.com/path", allowed: false},
+		"protocol relative URL":      {url: "//www.example.com/path", allowed: false},
+		"javascript URL":             {url: "javascript:alert(document.cookie)", allowed: false},
+		"data URL":                   {url: "data:text/html;base64,PHNjcmlwdD4=", allowed: false},
+		"file URL":                   {url: "file:///etc/passwd", allowed: false},
+		"ftp URL":                    {url: "ftp://ftp.example.com/file", allowed: false},
+		"http without host":          {url: "http:///path", allowed: false},
+		"similar domain":             {url: "https://notevil.example", allowed: true},
+		"numeric label inside host":  {url: "https://123.example.com", allowed: true},
+		"loopback":                   {url: "http://127.0.0.1:8080", allowed: false},
+		"private":                    {url: "http://10.1.2.3", allowed: false},
+		"link local metadata":        {url: "http://169.254.169.254/latest/meta-data", allowed: false},
+		"trailing dot":               {url: "http://169.254.169.254./", allowed: false},
+		"shared address space":       {url: "http://100.64.0.1", allowed: false},
+		"unspecified":                {url: "http://0.0.0.0", allowed: false},
+		"IPv6 loopback":              {url: "http://[::1]/", allowed: false},
+		"IPv6 unique local":          {url: "http://[fd00::1]/", allowed: false},
+		"IPv4 mapped IPv6":           {url: "http://[::ffff:10.0.0.1]/", allowed: false},
+		"localhost":                  {url: "http://localhost:3000", allowed: false},
+		"localhost subdomain":        {url: "http://app.localhost", allowed: false},
+		"decimal IPv4":               {url: "http://2852039166/", allowed: false},
+		"hex IPv4":                   {url: "http://0xa9.0xfe.0xa9.0xfe/", allowed: false},
+		"octal IPv4":                 {url: "http://0177.0.0.1/", allowed: false},
+		"denied domain":              {url: "https://EVIL.example/login", allowed: false},
+		"denied subdomain":           {url: "https://login.evil.example", allowed: false},
+		"short domain":               {url: "https://sho.rt/b", allowed: false},
+		"short domain with userinfo": {url: "https://user@sho.rt/b", allowed: false},
+		"invalid URL":                {url: "http://[::1", allowed: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := policy.Check(tc.url)
+			if tc.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+		})
+	}
+}
+
+func Test_PolicyFromEnv(t *testing.T) {
+	t.Setenv(DeniedDomainsEnv, " Evil.example, *.phish.example,,")
+	t.Setenv(ShortDomainsEnv, "sho.rt.")
+
+	assert.Equal(t, DestinationPolicy{
+		DeniedDomains: []string{"evil.example", "phish.example"},
+		ShortDomains:  []string{"sho.rt"},
+	}, PolicyFromEnv())
+}
@@ -0,0 +1,228 @@
+package urlshortener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/logging"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"go.uber.org/zap"
+)
+
+type (
+	// ReputationChecker looks destinations up in a threat-intel source and returns the flagged ones.
+	ReputationChecker interface {
+		Check(ctx context.Context, urls []string) ([]reputation.Match, error)
+	}
+
+	// ReputationCheck configures how links are vetted. The zero value does not vet them.
+	ReputationCheck struct {
+		Checker ReputationChecker
+		// Timeout bounds a check, DefaultReputationTimeout when zero.
+		Timeout time.Duration
+		// FailClosed rejects new links while the checker fails instead of creating them unchecked.
+		FailClosed bool
+	}
+
+	// Flag records the most severe verdict a reputation check found for the link's destinations.
+	Flag struct {
+		reputation.Match
+		CheckedAt time.Time `dynamodbav:"checked_at" json:"checked_at"`
+	}
+)
+
+const (
+	DefaultReputationTimeout = 2 * time.Second
+
+	ReputationURLEnv        = "REPUTATION_URL"
+	ReputationAPIKeyEnv     = "REPUTATION_API_KEY"
+	ReputationFileEnv       = "REPUTATION_FILE"
+	ReputationTimeoutEnv    = "REPUTATION_TIMEOUT"
+	ReputationFailClosedEnv = "REPUTATION_FAIL_CLOSED"
+)
+
+var (
+	// ErrDestinationFlagged wraps ErrInvalidLink, so malicious destinations are reported as invalid
+	// input.
+	ErrDestinationFlagged    = fmt.Errorf("%w: destination flagged as malicious", ErrInvalidLink)
+	ErrReputationUnavailable = errors.New("reputation check unavailable")
+)
+
+// ReputationCheckFromEnv configures a FileChecker when REPUTATION_FILE is set, otherwise an
+// HTTPChecker when REPUTATION_URL is. REPUTATION_TIMEOUT takes a duration such as 500ms.
+func ReputationCheckFromEnv() (ReputationCheck, error) {
+	var check ReputationCheck
+	if path := os.Getenv(ReputationFileEnv); path != "" {
+		checker, err := reputation.LoadFile(path)
+		if err != nil {
+			return check, err
+		}
+		check.Checker = checker
+	} else if endpoint := os.Getenv(ReputationURLEnv); endpoint != "" {
+		check.Checker = &reputation.HTTPChecker{
+			Endpoint: endpoint,
+			APIKey:   os.Getenv(ReputationAPIKeyEnv),
+			Client:   &http.Client{},
+		}
+	}
+
+	var err error
+	if v := os.Getenv(ReputationTimeoutEnv); v != "" {
+		if check.Timeout, err = time.ParseDuration(v); err != nil {
+			return check, fmt.Errorf("%s: %w", ReputationTimeoutEnv, err)
+		}
+	}
+	if v := os.Getenv(ReputationFailClosedEnv); v != "" {
+		if check.FailClosed, err = strconv.ParseBool(v); err != nil {
+			return check, fmt.Errorf("%s: %w", ReputationFailClosedEnv, err)
+		}
+	}
+	return check, nil
+}
+
+// RecheckLink vets the destinations of an existing link again, storing the new flag or clearing
+// the old one. Links flagged as malicious stop redirecting. CheckLinks does the same for every
+// link on its scheduled runs.
+func (u *UrlShortener) RecheckLink(ctx context.Context, shortened string) (*Link, error) {
+	if u.Reputation.Checker == nil {
+		return nil, fmt.Errorf("%w: no checker configured", ErrReputationUnavailable)
+	}
+
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	link, err := u.getLink(ctx, shortened)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := u.lookupReputation(ctx, linkURLs(link.OriginalURL, link.LinkOptions))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReputationUnavailable, err)
+	}
+	if err = u.storeFlag(ctx, link, u.flag(matches)); err != nil {
+		return nil, err
+	}
+
+	link.Status = link.StatusAt(u.now())
+	return link, nil
+}
+
+// recheckReputation vets the destinations of a page of links again, like RecheckLink does for
+// one, and adds the links now flagged to report. The destinations are looked up
+// reputation.MaxLookupEntries at a time, each lookup with its own timeout. A failing checker
+// leaves every flag of the page as it is until the next run.
+func (u *UrlShortener) recheckReputation(ctx context.Context, links []*Link, report *LinkCheckReport) {
+	logger := logging.FromContext(ctx, u.Logger)
+
+	var urls []string
+	for _, link := range links {
+		urls = append(urls, linkURLs(link.OriginalURL, link.LinkOptions)...)
+	}
+	var matches []reputation.Match
+	for chunk := range slices.Chunk(urls, reputation.MaxLookupEntries) {
+		chunkMatches, err := u.lookupReputation(ctx, chunk)
+		if err != nil {
+			logger.Warn("reputation recheck failed, keeping the current flags", zap.Error(err))
+			return
+		}
+		matches = append(matches, chunkMatches...)
+	}
+	byURL := map[string][]reputation.Match{}
+	for _, match := range matches {
+		byURL[match.URL] = append(byURL[match.URL], match)
+	}
+
+	for _, link := range links {
+		var linkMatches []reputation.Match
+		for _, dest := range linkURLs(link.OriginalURL, link.LinkOptions) {
+			linkMatches = append(linkMatches, byURL[dest]...)
+		}
+		flag := u.flag(linkMatches)
+		if flag == nil && link.Flag == nil {
+			continue
+		}
+
+		u.Mu.Lock()
+		err := u.storeFlag(ctx, link, flag)
+		u.Mu.Unlock()
+		if err != nil {
+			report.Failed++
+			logger.Error("failed to store link flag", zap.String(logkey.ShortenedURL, link.ShortURL), zap.Error(err))
+			continue
+		}
+		if flag != nil {
+			report.Flagged = append(report.Flagged, link)
+		}
+	}
+}
+
+// storeFlag stores flag on link, or clears the flag it had when flag is nil.
+func (u *UrlShortener) storeFlag(ctx context.Context, link *Link, flag *Flag) error {
+	set := map[string]types.AttributeValue{}
+	var remove []string
+	var err error
+	if flag == nil {
+		remove = append(remove, urlDB.Flag)
+	} else if set[urlDB.Flag], err = attributevalue.Marshal(flag); err != nil {
+		return err
+	}
+	if err = u.DBClient.UpdateAttributes(ctx, link.ShortURL, set, remove); err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx, u.Logger).With(zap.String(logkey.ShortenedURL, link.ShortURL))
+	if flag != nil {
+		logger.Warn("link destination flagged", zap.String(logkey.OriginalURL, flag.URL), zap.String(logkey.Verdict, string(flag.Verdict)), zap.String(logkey.Threat, flag.Threat))
+	} else if link.Flag != nil {
+		logger.Info("link flag cleared")
+	}
+
+	link.Flag = flag
+	return nil
+}
+
+// checkReputation vets the destinations of a new link. A failing checker only fails the link
+// when FailClosed is set.
+func (u *UrlShortener) checkReputation(ctx context.Context, urls []string) (*Flag, error) {
+	if u.Reputation.Checker == nil {
+		return nil, nil
+	}
+	matches, err := u.lookupReputation(ctx, urls)
+	if err != nil {
+		if u.Reputation.FailClosed {
+			return nil, fmt.Errorf("%w: %w", ErrReputationUnavailable, err)
+		}
+		logging.FromContext(ctx, u.Logger).Warn("reputation check failed, creating link unchecked", zap.Error(err))
+		return nil, nil
+	}
+	return u.flag(matches), nil
+}
+
+func (u *UrlShortener) lookupReputation(ctx context.Context, urls []string) ([]reputation.Match, error) {
+	timeout := u.Reputation.Timeout
+	if timeout == 0 {
+		timeout = DefaultReputationTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return u.Reputation.Checker.Check(ctx, urls)
+}
+
+func (u *UrlShortener) flag(matches []reputation.Match) *Flag {
+	worst := reputation.Worst(matches)
+	if worst == nil {
+		return nil
+	}
+	return &Flag{Match: *worst, CheckedAt: u.now().UTC()}
+}
@@ -0,0 +1,261 @@
+package urlshortener
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockReputationChecker struct {
+	mock.Mock
+}
+
+func (m *MockReputationChecker) Check(ctx context.Context, urls []string) ([]reputation.Match, error) {
+	args := m.Called(urls)
+	if wait, ok := args.Get(0).(time.Duration); ok {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	}
+	matches, _ := args.Get(0).([]reputation.Match)
+	return matches, args.Error(1)
+}
+
+func Test_ShortenURL_Reputation(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	suspicious := reputation.Match{URL: "http://www.example.com", Verdict: reputation.Suspicious, Threat: "UNWANTED_SOFTWARE"}
+	malicious := reputation.Match{URL: "http://www.example.com/b", Verdict: reputation.Malicious, Threat: "MALWARE"}
+
+	tests := map[string]struct {
+		matches       any
+		checkError    error
+		failClosed    bool
+		expectedFlag  types.AttributeValue
+		expectedError error
+	}{
+		"Clean": {},
+		"Suspicious destination is flagged": {
+			matches: []reputation.Match{suspicious},
+			expectedFlag: &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"url":        &types.AttributeValueMemberS{Value: "http://www.example.com"},
+				"verdict":    &types.AttributeValueMemberS{Value: "suspicious"},
+				"threat":     &types.AttributeValueMemberS{Value: "UNWANTED_SOFTWARE"},
+				"checked_at": &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00Z"},
+			}},
+		},
+		"Malicious destination is rejected": {
+			matches:       []reputation.Match{suspicious, malicious},
+			expectedError: ErrDestinationFlagged,
+		},
+		"Fail open": {
+			checkError: errors.New("error"),
+		},
+		"Fail closed": {
+			checkError:    errors.New("error"),
+			failClosed:    true,
+			expectedError: ErrReputationUnavailable,
+		},
+		"Fail closed on timeout": {
+			matches:       time.Second,
+			failClosed:    true,
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := zap.NewProduction()
+			checker := new(MockReputationChecker)
+			checker.On("Check", []string{"http://www.example.com", "http://www.example.com/b"}).Return(tc.matches, tc.checkError)
+			m := new(MockDBProvider)
+			attributes := map[string]types.AttributeValue{
+				"destinations": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+					&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+						"url":    &types.AttributeValueMemberS{Value: "http://www.example.com/b"},
+						"weight": &types.AttributeValueMemberN{Value: "1"},
+					}},
+				}},
+				"variant_clicks": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"0": &types.AttributeValueMemberN{Value: "0"},
+				}},
+			}
+			if tc.expectedFlag != nil {
+				attributes["flag"] = tc.expectedFlag
+			} else {
+				m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{}, nil)
+			}
+			m.On("IncrementCounter").Return(int64(1), nil)
+			m.On("WriteItem", int64(1), "b", "http://www.example.com", attributes).Return(nil)
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+				Now:      func() time.Time { return now },
+				Reputation: ReputationCheck{
+					Checker:    checker,
+					Timeout:    10 * time.Millisecond,
+					FailClosed: tc.failClosed,
+				},
+			}
+
+			shortened, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{
+				Destinations: []Destination{{URL: "http://www.example.com/b", Weight: 1}},
+			})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				m.AssertNotCalled(t, "IncrementCounter")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "b", shortened)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RecheckLink(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	item := map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberN{Value: "1"},
+		"short_url":    &types.AttributeValueMemberS{Value: "b"},
+		"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+		"flag": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"url":        &types.AttributeValueMemberS{Value: "http://www.example.com"},
+			"verdict":    &types.AttributeValueMemberS{Value: "suspicious"},
+			"checked_at": &types.AttributeValueMemberS{Value: "2025-02-01T09:00:00Z"},
+		}},
+	}
+
+	tests := map[string]struct {
+		matches        []reputation.Match
+		checkError     error
+		set            map[string]types.AttributeValue
+		remove         []string
+		expectedStatus LinkStatus
+		expectedError  error
+	}{
+		"Blocked": {
+			matches: []reputation.Match{{URL: "http://www.example.com", Verdict: reputation.Malicious}},
+			set: map[string]types.AttributeValue{
+				"flag": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"url":        &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					"verdict":    &types.AttributeValueMemberS{Value: "malicious"},
+					"checked_at": &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00Z"},
+				}},
+			},
+			expectedStatus: StatusBlocked,
+		},
+		"Cleared": {
+			set:            map[string]types.AttributeValue{},
+			remove:         []string{"flag"},
+			expectedStatus: StatusActive,
+		},
+		"Checker error": {
+			checkError:    errors.New("error"),
+			expectedError: ErrReputationUnavailable,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := zap.NewProduction()
+			checker := new(MockReputationChecker)
+			checker.On("Check", []string{"http://www.example.com"}).Return(tc.matches, tc.checkError)
+			m := new(MockDBProvider)
+			m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{Item: item}, nil)
+			if tc.expectedError == nil {
+				m.On("UpdateAttributes", "b", tc.set, tc.remove).Return(nil)
+			}
+			u := &UrlShortener{
+				Logger:     logger,
+				DBClient:   m,
+				Now:        func() time.Time { return now },
+				Reputation: ReputationCheck{Checker: checker},
+			}
+
+			link, err := u.RecheckLink(context.Background(), "b")
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				m.AssertNotCalled(t, "UpdateAttributes", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, link.Status)
+			m.AssertExpectations(t)
+		})
+	}
+
+	t.Run("No checker", func(t *testing.T) {
+		u := &UrlShortener{DBClient: new(MockDBProvider)}
+		_, err := u.RecheckLink(context.Background(), "b")
+		assert.ErrorIs(t, err, ErrReputationUnavailable)
+	})
+}
+
+func Test_ResolveLink_Blocked(t *testing.T) {
+	m := new(MockDBProvider)
+	m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+		"short_url":    &types.AttributeValueMemberS{Value: "b"},
+		"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+		"flag": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"verdict": &types.AttributeValueMemberS{Value: "malicious"},
+		}},
+	}}, nil)
+	logger, _ := zap.NewProduction()
+	u := &UrlShortener{Logger: logger, DBClient: m}
+
+	_, err := u.ResolveLink(context.Background(), "b")
+	assert.ErrorIs(t, err, ErrLinkBlocked)
+}
+
+func Test_ReputationCheckFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "threats.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("malicious evil.example\n"), 0o600))
+
+	t.Run("None", func(t *testing.T) {
+		check, err := ReputationCheckFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, ReputationCheck{}, check)
+	})
+	t.Run("File", func(t *testing.T) {
+		t.Setenv(ReputationFileEnv, path)
+		t.Setenv(ReputationURLEnv, "https://lookup.example/v4/threatMatches:find")
+		t.Setenv(ReputationTimeoutEnv, "500ms")
+		t.Setenv(ReputationFailClosedEnv, "true")
+
+		check, err := ReputationCheckFromEnv()
+		assert.NoError(t, err)
+		assert.IsType(t, &reputation.FileChecker{}, check.Checker)
+		assert.Equal(t, 500*time.Millisecond, check.Timeout)
+		assert.True(t, check.FailClosed)
+	})
+	t.Run("HTTP", func(t *testing.T) {
+		t.Setenv(ReputationURLEnv, "https://lookup.example/v4/threatMatches:find")
+		t.Setenv(ReputationAPIKeyEnv, "secret")
+
+		check, err := ReputationCheckFromEnv()
+		assert.NoError(t, err)
+		if assert.IsType(t, &reputation.HTTPChecker{}, check.Checker) {
+			assert.Equal(t, "secret", check.Checker.(*reputation.HTTPChecker).APIKey)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv(ReputationTimeoutEnv, "soon")
+		_, err := ReputationCheckFromEnv()
+		assert.Error(t, err)
+	})
+}
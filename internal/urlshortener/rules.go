@@ -0,0 +1,182 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Rule sends visitors matching every non-empty condition to URL instead of the default
+	// destination. Rules are evaluated in order and the first match wins.
+	Rule struct {
+		Device   Device `dynamodbav:"device,omitempty" json:"device,omitempty"`
+		Language string `dynamodbav:"language,omitempty" json:"language,omitempty"`
+		Country  string `dynamodbav:"country,omitempty" json:"country,omitempty"`
+		// Header and Value match an arbitrary request header, case-insensitively.
+		Header string `dynamodbav:"header,omitempty" json:"header,omitempty"`
+		Value  string `dynamodbav:"value,omitempty" json:"value,omitempty"`
+		URL    string `dynamodbav:"url" json:"url"`
+	}
+
+	Device string
+
+	// Visitor is what rules can be matched against for a single redirect.
+	Visitor struct {
+		Device   Device
+		Language string
+		Country  string
+		Header   http.Header
+	}
+)
+
+const (
+	DeviceIOS     Device = "ios"
+	DeviceAndroid Device = "android"
+	// DeviceMobile matches any phone or tablet, including iOS and Android.
+	DeviceMobile  Device = "mobile"
+	DeviceDesktop Device = "desktop"
+
+	// CountryHeader is set by CloudFront in front of edge optimized API Gateway endpoints.
+	CountryHeader = "CloudFront-Viewer-Country"
+	MaxRules      = 20
+)
+
+// NewVisitor classifies the request headers of a redirect.
+func NewVisitor(header http.Header) Visitor {
+	return Visitor{
+		Device:   DeviceFromUserAgent(header.Get("User-Agent")),
+		Language: PreferredLanguage(header.Get("Accept-Language")),
+		Country:  strings.ToUpper(header.Get(CountryHeader)),
+		Header:   header,
+	}
+}
+
+// DeviceFromUserAgent returns ios, android, mobile or desktop.
+func DeviceFromUserAgent(ua string) Device {
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		return DeviceIOS
+	case strings.Contains(ua, "Android"):
+		return DeviceAndroid
+	case strings.Contains(ua, "Mobile"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// PreferredLanguage returns the highest weighted language tag of an Accept-Language header, lower cased.
+func PreferredLanguage(acceptLanguage string) string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{lang: lang, q: q})
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	return tags[0].lang
+}
+
+// MatchRule returns the URL of the first rule v satisfies.
+func (l *Link) MatchRule(v Visitor) (string, bool) {
+	for _, rule := range l.Rules {
+		if rule.matches(v) {
+			return rule.URL, true
+		}
+	}
+	return "", false
+}
+
+// Vary lists the request headers the link's rules depend on, for the Vary header of redirects.
+// It is empty for links without rules.
+func (l *Link) Vary() string {
+	var headers []string
+	seen := map[string]bool{}
+	add := func(header string) {
+		if key := http.CanonicalHeaderKey(header); !seen[key] {
+			seen[key] = true
+			headers = append(headers, header)
+		}
+	}
+	for _, rule := range l.Rules {
+		if rule.Device != "" {
+			add("User-Agent")
+		}
+		if rule.Language != "" {
+			add("Accept-Language")
+		}
+		if rule.Country != "" {
+			add(CountryHeader)
+		}
+		if rule.Header != "" {
+			add(http.CanonicalHeaderKey(rule.Header))
+		}
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (r Rule) matches(v Visitor) bool {
+	if r.Device != "" && !r.Device.matches(v.Device) {
+		return false
+	}
+	if r.Language != "" && !languageMatches(strings.ToLower(r.Language), v.Language) {
+		return false
+	}
+	if r.Country != "" && !strings.EqualFold(r.Country, v.Country) {
+		return false
+	}
+	if r.Header != "" && !strings.EqualFold(r.Value, v.Header.Get(r.Header)) {
+		return false
+	}
+	return true
+}
+
+func (d Device) matches(visitor Device) bool {
+	if d == DeviceMobile {
+		return visitor == DeviceIOS || visitor == DeviceAndroid || visitor == DeviceMobile
+	}
+	return d == visitor
+}
+
+// languageMatches lets a primary tag such as pt match pt-br.
+func languageMatches(rule, visitor string) bool {
+	return visitor == rule || strings.HasPrefix(visitor, rule+"-")
+}
+
+func (r Rule) validate(i int) error {
+	if r.URL == "" {
+		return fmt.Errorf("%w: rule %d needs a URL", ErrInvalidLink, i)
+	}
+	if r.Device == "" && r.Language == "" && r.Country == "" && r.Header == "" {
+		return fmt.Errorf("%w: rule %d needs at least one condition", ErrInvalidLink, i)
+	}
+	switch r.Device {
+	case "", DeviceIOS, DeviceAndroid, DeviceMobile, DeviceDesktop:
+	default:
+		return fmt.Errorf("%w: rule %d has unsupported device %q", ErrInvalidLink, i, r.Device)
+	}
+	return nil
+}
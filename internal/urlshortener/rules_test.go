@@ -0,0 +1,169 @@
+package urlshortener
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+func Test_DeviceFromUserAgent(t *testing.T) {
+	assert.Equal(t, DeviceIOS, DeviceFromUserAgent(iPhoneUA))
+	assert.Equal(t, DeviceAndroid, DeviceFromUserAgent(androidUA))
+	assert.Equal(t, DeviceDesktop, DeviceFromUserAgent(desktopUA))
+	assert.Equal(t, DeviceMobile, DeviceFromUserAgent("Opera/9.80 (J2ME/MIDP; Opera Mini) Mobile"))
+	assert.Equal(t, DeviceDesktop, DeviceFromUserAgent(""))
+}
+
+func Test_PreferredLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                                       "",
+		"fr-CA,fr;q=0.9,en;q=0.8":                "fr-ca",
+		"en;q=0.5, de":                           "de",
+		"*;q=1, es;q=0.2":                        "es",
+		"ja;q=0, pt-BR;q=0.7":                    "pt-br",
+		"  EN-us  ":                              "en-us",
+		"da, en-gb;q=0.8, en;q=0.7":              "da",
+		"zh-Hant;q=0.9, zh;q=invalid, ko;q=0.95": "zh",
+	}
+
+	for header, expected := range tests {
+		assert.Equal(t, expected, PreferredLanguage(header), header)
+	}
+}
+
+func Test_MatchRule(t *testing.T) {
+	link := &Link{
+		OriginalURL: "https://example.com",
+		LinkOptions: LinkOptions{
+			Rules: []Rule{
+				{Device: DeviceIOS, URL: "https://apps.apple.com/app"},
+				{Device: DeviceAndroid, URL: "https://play.google.com/app"},
+				{Device: DeviceMobile, Language: "fr", URL: "https://m.example.fr"},
+				{Country: "de", URL: "https://example.de"},
+				{Header: "X-Partner", Value: "acme", URL: "https://example.com/acme"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		header   http.Header
+		expected string
+		matched  bool
+	}{
+		"iOS": {
+			header:   http.Header{"User-Agent": {iPhoneUA}},
+			expected: "https://apps.apple.com/app",
+			matched:  true,
+		},
+		"Android": {
+			header:   http.Header{"User-Agent": {androidUA}},
+			expected: "https://play.google.com/app",
+			matched:  true,
+		},
+		"Other mobile in French": {
+			header:   http.Header{"User-Agent": {"Mobile"}, "Accept-Language": {"fr-FR"}},
+			expected: "https://m.example.fr",
+			matched:  true,
+		},
+		"Desktop in French falls through": {
+			header: http.Header{"User-Agent": {desktopUA}, "Accept-Language": {"fr-FR"}},
+		},
+		"Viewer country": {
+			header:   http.Header{"User-Agent": {desktopUA}, http.CanonicalHeaderKey(CountryHeader): {"DE"}},
+			expected: "https://example.de",
+			matched:  true,
+		},
+		"Custom header": {
+			header:   http.Header{"User-Agent": {desktopUA}, "X-Partner": {"ACME"}},
+			expected: "https://example.com/acme",
+			matched:  true,
+		},
+		"First rule wins": {
+			header:   http.Header{"User-Agent": {iPhoneUA}, http.CanonicalHeaderKey(CountryHeader): {"DE"}},
+			expected: "https://apps.apple.com/app",
+			matched:  true,
+		},
+		"No match": {
+			header: http.Header{"User-Agent": {desktopUA}, http.CanonicalHeaderKey(CountryHeader): {"US"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := link.MatchRule(NewVisitor(tc.header))
+
+			assert.Equal(t, tc.matched, ok)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_Vary(t *testing.T) {
+	tests := map[string]struct {
+		rules    []Rule
+		expected string
+	}{
+		"No rules": {},
+		"Language only": {
+			rules:    []Rule{{Language: "de", URL: "http://de.example.com"}},
+			expected: "Accept-Language",
+		},
+		"Every kind of rule": {
+			rules: []Rule{
+				{Country: "DE", URL: "http://de.example.com"},
+				{Device: DeviceIOS, Language: "fr", URL: "http://fr.example.com"},
+				{Header: "x-beta", Value: "1", URL: "http://beta.example.com"},
+			},
+			expected: "CloudFront-Viewer-Country, User-Agent, Accept-Language, X-Beta",
+		},
+		"Repeated headers listed once": {
+			rules: []Rule{
+				{Header: "X-Beta", Value: "1", URL: "http://a.example.com"},
+				{Header: "x-beta", Value: "2", URL: "http://b.example.com"},
+				{Header: "user-agent", Value: "curl", URL: "http://c.example.com"},
+				{Device: DeviceMobile, URL: "http://d.example.com"},
+			},
+			expected: "X-Beta, User-Agent",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			link := &Link{LinkOptions: LinkOptions{Rules: tc.rules}}
+
+			assert.Equal(t, tc.expected, link.Vary())
+		})
+	}
+}
+
+func Test_ValidateRules(t *testing.T) {
+	tests := map[string]struct {
+		rules       []Rule
+		expectError bool
+	}{
+		"Valid":              {rules: []Rule{{Device: DeviceIOS, URL: "https://apps.apple.com"}}},
+		"Missing URL":        {rules: []Rule{{Device: DeviceIOS}}, expectError: true},
+		"Missing condition":  {rules: []Rule{{URL: "https://example.com"}}, expectError: true},
+		"Unsupported device": {rules: []Rule{{Device: "watch", URL: "https://example.com"}}, expectError: true},
+		"Too many rules":     {rules: make([]Rule, MaxRules+1), expectError: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := LinkOptions{Rules: tc.rules}.Validate()
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidLink)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+)
+
+// LinkStatus summarizes whether a link redirects right now.
+type LinkStatus string
+
+const (
+	StatusActive LinkStatus = "active"
+	// StatusScheduled links are not live before NotBefore. They redirect to PrelaunchURL if set.
+	StatusScheduled LinkStatus = "scheduled"
+	StatusExpired   LinkStatus = "expired"
+	StatusDisabled  LinkStatus = "disabled"
+	// StatusBlocked links were flagged as malicious by a reputation check.
+	StatusBlocked   LinkStatus = "blocked"
+	StatusExhausted LinkStatus = "exhausted"
+)
+
+// StatusAt returns the status of the link at now. Disabling a link wins over its schedule.
+func (l *Link) StatusAt(now time.Time) LinkStatus {
+	switch {
+	case l.Disabled:
+		return StatusDisabled
+	case l.Flag != nil && l.Flag.Verdict == reputation.Malicious:
+		return StatusBlocked
+	case l.Exhausted():
+		return StatusExhausted
+	case l.NotAfter != nil && !now.Before(*l.NotAfter):
+		return StatusExpired
+	case l.NotBefore != nil && now.Before(*l.NotBefore):
+		return StatusScheduled
+	default:
+		return StatusActive
+	}
+}
+
+// Cacheable reports whether clients may cache a redirect, which is not the case for links whose
+// status can change without anyone editing them.
+func (l *Link) Cacheable() bool {
+	return l.MaxClicks == 0 && l.NotBefore == nil && l.NotAfter == nil
+}
+
+// prelaunch is the link served while a scheduled link is not live yet. It always redirects
+// temporarily, so nothing remembers the fallback once the link launches, and asks for the
+// password of the link like the link itself.
+func (l *Link) prelaunch() *Link {
+	return &Link{
+		ShortURL:    l.ShortURL,
+		ID:          l.ID,
+		OriginalURL: l.PrelaunchURL,
+		Flag:        l.Flag,
+		Status:      StatusScheduled,
+		LinkOptions: LinkOptions{
+			RedirectType: http.StatusFound,
+			QueryPolicy:  l.QueryPolicy,
+			NotBefore:    l.NotBefore,
+			NotAfter:     l.NotAfter,
+			Interstitial: l.Interstitial,
+			PasswordHash: l.PasswordHash,
+		},
+	}
+}
+
+// normalizeSchedule stores the window in UTC, so a link read back from the table compares equal to
+// the options it was created with.
+func (o *LinkOptions) normalizeSchedule() {
+	for _, t := range []**time.Time{&o.NotBefore, &o.NotAfter} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+}
+
+func (o LinkOptions) validateSchedule() error {
+	if o.NotBefore != nil && o.NotAfter != nil && !o.NotAfter.After(*o.NotBefore) {
+		return fmt.Errorf("%w: not_after must be later than not_before", ErrInvalidLink)
+	}
+	if o.PrelaunchURL != "" && o.NotBefore == nil {
+		return fmt.Errorf("%w: prelaunch_url requires not_before", ErrInvalidLink)
+	}
+	return nil
+}
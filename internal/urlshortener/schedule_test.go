@@ -0,0 +1,66 @@
+package urlshortener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StatusAt(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := map[string]struct {
+		link     Link
+		expected LinkStatus
+	}{
+		"No schedule":        {expected: StatusActive},
+		"Before launch":      {link: Link{LinkOptions: LinkOptions{NotBefore: &after}}, expected: StatusScheduled},
+		"At launch":          {link: Link{LinkOptions: LinkOptions{NotBefore: &now}}, expected: StatusActive},
+		"Before expiry":      {link: Link{LinkOptions: LinkOptions{NotAfter: &after}}, expected: StatusActive},
+		"At expiry":          {link: Link{LinkOptions: LinkOptions{NotAfter: &now}}, expected: StatusExpired},
+		"Disabled":           {link: Link{Disabled: true, LinkOptions: LinkOptions{NotBefore: &before}}, expected: StatusDisabled},
+		"Exhausted":          {link: Link{LinkOptions: LinkOptions{MaxClicks: 1}}, expected: StatusExhausted},
+		"Clicks left":        {link: Link{ClicksRemaining: 1, LinkOptions: LinkOptions{MaxClicks: 1}}, expected: StatusActive},
+		"Expired and unused": {link: Link{ClicksRemaining: 1, LinkOptions: LinkOptions{MaxClicks: 1, NotAfter: &before}}, expected: StatusExpired},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.link.StatusAt(now))
+		})
+	}
+}
+
+func Test_Cacheable(t *testing.T) {
+	end := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, (&Link{}).Cacheable())
+	assert.False(t, (&Link{LinkOptions: LinkOptions{NotAfter: &end}}).Cacheable())
+	assert.False(t, (&Link{LinkOptions: LinkOptions{MaxClicks: 1}}).Cacheable())
+}
+
+func Test_ValidateSchedule(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	assert.NoError(t, LinkOptions{NotBefore: &start, NotAfter: &end, PrelaunchURL: "https://example.com/soon"}.Validate())
+	assert.ErrorIs(t, LinkOptions{NotBefore: &end, NotAfter: &start}.Validate(), ErrInvalidLink)
+	assert.ErrorIs(t, LinkOptions{PrelaunchURL: "https://example.com/soon"}.Validate(), ErrInvalidLink)
+}
+
+func Test_NormalizeSchedule(t *testing.T) {
+	local := time.Date(2025, 3, 1, 14, 0, 0, 0, time.FixedZone("CET", 2*60*60))
+	opts := LinkOptions{NotBefore: &local}
+	opts.normalizeSchedule()
+
+	item, err := attributevalue.MarshalMap(opts)
+	assert.NoError(t, err)
+	var stored LinkOptions
+	assert.NoError(t, attributevalue.UnmarshalMap(item, &stored))
+
+	assert.Equal(t, opts, stored)
+	assert.True(t, local.Equal(*stored.NotBefore))
+}
@@ -1,13 +1,23 @@
 package urlshortener
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/logging"
 	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/connorpalermo/url-shortener/internal/reputation"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
 	"go.uber.org/zap"
 )
 
@@ -16,18 +26,44 @@ type (
 		Logger   *zap.Logger
 		Mu       sync.Mutex
 		DBClient URLDBProvider
+		// Now is the clock activation windows are checked against, time.Now when nil.
+		Now func() time.Time
+		// Policy decides which destinations links may be created for.
+		Policy DestinationPolicy
+		// Reputation vets destinations in a threat-intel source when links are created.
+		Reputation ReputationCheck
+		// Events is told when links are created, updated or deleted. Nothing is sent when nil.
+		Events Emitter
+		// LinkCheckPages bounds the pages of links a CheckLinks run goes through,
+		// DefaultLinkCheckPages when zero.
+		LinkCheckPages int
 	}
 
 	UrlShortenerProvider interface {
-		ShortenURL(url string) (string, error)
-		GetOriginalURL(shortened string) (string, error)
+		ShortenURL(ctx context.Context, url string, opts LinkOptions) (string, error)
+		GetOriginalURL(ctx context.Context, shortened string) (string, error)
+		ResolveLink(ctx context.Context, shortened string) (*Link, error)
+		GetLink(ctx context.Context, shortened string) (*Link, error)
+		RecordVariantClick(ctx context.Context, shortened string, variant int) error
+		ConsumeClick(ctx context.Context, shortened string) error
+		UpdateMetadata(ctx context.Context, shortened string, update MetadataUpdate) (*Link, error)
+		ListLinks(ctx context.Context, filter LinkFilter) (*LinkPage, error)
 	}
 
 	URLDBProvider interface {
-		GetItemByPK(shortUrl string) (*dynamodb.GetItemOutput, error)
-		WriteItem(id int64, shortUrl, originalUrl string) error
-		IncrementCounter() (int64, error)
-		GetItemByNonPK(attributeName, attributeValue string) (*dynamodb.ScanOutput, error)
+		GetItemByPK(ctx context.Context, shortUrl string) (*dynamodb.GetItemOutput, error)
+		WriteItem(ctx context.Context, id int64, shortUrl, originalUrl string, attributes map[string]types.AttributeValue) error
+		IncrementCounter(ctx context.Context) (int64, error)
+		GetItemByNonPK(ctx context.Context, attributeName, attributeValue string) (*dynamodb.ScanOutput, error)
+		SetDisabled(ctx context.Context, shortUrl string, disabled bool) error
+		GetCounter(ctx context.Context) (int64, error)
+		IncrementVariantClicks(ctx context.Context, shortUrl string, variant int) error
+		ConsumeClick(ctx context.Context, shortUrl string) (int64, error)
+		UpdateAttributes(ctx context.Context, shortUrl string, set map[string]types.AttributeValue, remove []string) error
+		QueryLinks(ctx context.Context, q urlDB.LinkQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error)
+		DeleteItem(ctx context.Context, shortUrl string) error
+		GetLinkCheckCursor(ctx context.Context) (map[string]types.AttributeValue, error)
+		SetLinkCheckCursor(ctx context.Context, key map[string]types.AttributeValue) error
 	}
 )
 
@@ -42,56 +78,137 @@ func New(logger *zap.Logger) (*UrlShortener, error) {
 	if err != nil {
 		return nil, err
 	}
+	check, err := ReputationCheckFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
 	return &UrlShortener{
-		Logger:   logger,
-		DBClient: db,
+		Logger:     logger,
+		DBClient:   db,
+		Policy:     PolicyFromEnv(),
+		Reputation: check,
 	}, nil
 }
 
-func (u *UrlShortener) ShortenURL(url string) (string, error) {
-	u.Mu.Lock()
-	defer u.Mu.Unlock()
+func (u *UrlShortener) ShortenURL(ctx context.Context, url string, opts LinkOptions) (string, error) {
+	opts.Owner = strings.TrimSpace(opts.Owner)
+	opts.Tags = NormalizeTags(opts.Tags)
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	if err := u.Policy.checkLink(url, opts); err != nil {
+		return "", err
+	}
+	logger := logging.FromContext(ctx, u.Logger)
 
-	scan, err := u.DBClient.GetItemByNonPK(OriginalURL, url)
+	flag, err := u.checkReputation(ctx, linkURLs(url, opts))
 	if err != nil {
 		return "", err
 	}
-
-	if scan.Count > 0 {
-		// we have already seen this URL
-		item := scan.Items[0]
-		var attributes map[string]string
-		err = attributevalue.UnmarshalMap(item, &attributes)
+	if flag != nil && flag.Verdict == reputation.Malicious {
+		logger.Warn("rejected flagged destination", zap.String(logkey.OriginalURL, flag.URL), zap.String(logkey.Threat, flag.Threat))
+		return "", fmt.Errorf("%w: %s", ErrDestinationFlagged, flag.URL)
+	}
+	opts.normalizeSchedule()
+	if opts.Password != "" {
+		// a fresh salt means protected links are never reused
+		hash, err := HashPassword(opts.Password)
 		if err != nil {
 			return "", err
 		}
+		opts.PasswordHash, opts.Password = hash, ""
+	}
 
-		shortURL, ok := attributes[ShortURL]
-		if !ok {
-			return "", errors.New("ShortURL attribute missing")
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	// a click budget belongs to a single link and an older link would miss the flag, so neither is
+	// reused
+	if opts.MaxClicks == 0 && flag == nil {
+		existing, err := u.findLink(ctx, url, opts)
+		if err != nil || existing != "" {
+			return existing, err
 		}
+	}
 
-		return shortURL, nil
+	attributes, err := attributevalue.MarshalMap(opts)
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Destinations) > 0 {
+		// the counters need to exist before a redirect can increment them
+		clicks := make(map[string]types.AttributeValue, len(opts.Destinations))
+		for i := range opts.Destinations {
+			clicks[strconv.Itoa(i)] = &types.AttributeValueMemberN{Value: "0"}
+		}
+		attributes[urlDB.VariantClicks] = &types.AttributeValueMemberM{Value: clicks}
+	}
+	if flag != nil {
+		if attributes[urlDB.Flag], err = attributevalue.Marshal(flag); err != nil {
+			return "", err
+		}
+	}
+	if opts.MaxClicks > 0 {
+		attributes[urlDB.ClicksRemaining] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.MaxClicks, 10)}
 	}
 
-	u.Logger.Info("shortening original URL: ", zap.String(logkey.OriginalURL, url))
+	logger.Info("shortening original URL: ", zap.String(logkey.OriginalURL, url))
 
-	id, err := u.DBClient.IncrementCounter()
+	id, err := u.DBClient.IncrementCounter(ctx)
 	if err != nil {
 		return "", err
 	}
 	shortened := encodeBase62(id)
 
-	err = u.DBClient.WriteItem(id, shortened, url)
+	err = u.DBClient.WriteItem(ctx, id, shortened, url, attributes)
 	if err != nil {
 		return "", err
 	}
-	u.Logger.Info("generated shortened URL: ", zap.String(logkey.ShortenedURL, shortened))
+	logger.Info("generated shortened URL: ", zap.String(logkey.ShortenedURL, shortened))
+
+	now := u.now().UTC()
+	link := &Link{ShortURL: shortened, ID: id, OriginalURL: url, Flag: flag, CreatedAt: &now, LinkOptions: opts}
+	link.Status = link.StatusAt(now)
+	u.emit(ctx, webhook.LinkCreated, link)
 
 	return shortened, nil
 }
 
+// findLink returns the short URL url was already shortened to with the same options, if any.
+func (u *UrlShortener) findLink(ctx context.Context, url string, opts LinkOptions) (string, error) {
+	scan, err := u.DBClient.GetItemByNonPK(ctx, OriginalURL, url)
+	if err != nil {
+		return "", err
+	}
+
+	for _, item := range scan.Items {
+		var link Link
+		err = attributevalue.UnmarshalMap(item, &link)
+		if err != nil {
+			return "", err
+		}
+
+		if !reflect.DeepEqual(link.LinkOptions, opts) {
+			continue
+		}
+
+		if link.ShortURL == "" {
+			return "", errors.New("ShortURL attribute missing")
+		}
+
+		return link.ShortURL, nil
+	}
+	return "", nil
+}
+
+func (u *UrlShortener) now() time.Time {
+	if u.Now != nil {
+		return u.Now()
+	}
+	return time.Now()
+}
+
 func encodeBase62(id int64) string {
 	result := ""
 	for id > 0 {
@@ -102,29 +219,185 @@ func encodeBase62(id int64) string {
 	return result
 }
 
-func (u *UrlShortener) GetOriginalURL(shortened string) (string, error) {
+func (u *UrlShortener) GetOriginalURL(ctx context.Context, shortened string) (string, error) {
 	u.Mu.Lock()
 	defer u.Mu.Unlock()
 
-	u.Logger.Info("getting original URL from shortened URL: ", zap.String(logkey.ShortenedURL, shortened))
+	logger := logging.FromContext(ctx, u.Logger)
+
+	logger.Info("getting original URL from shortened URL: ", zap.String(logkey.ShortenedURL, shortened))
 
-	original, err := u.DBClient.GetItemByPK(shortened)
+	link, err := u.resolveLink(ctx, shortened)
 	if err != nil {
 		return "", err
 	}
 
-	var attributes map[string]string
-	err = attributevalue.UnmarshalMap(original.Item, &attributes)
+	logger.Info("retrieved original URL: ", zap.String(logkey.OriginalURL, link.OriginalURL))
+
+	return link.OriginalURL, nil
+}
+
+// ResolveLink returns the record a redirect for shortened should follow, rejecting disabled links.
+func (u *UrlShortener) ResolveLink(ctx context.Context, shortened string) (*Link, error) {
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	logging.FromContext(ctx, u.Logger).Info("resolving shortened URL", zap.String(logkey.ShortenedURL, shortened))
+
+	return u.resolveLink(ctx, shortened)
+}
+
+func (u *UrlShortener) resolveLink(ctx context.Context, shortened string) (*Link, error) {
+	link, err := u.getLink(ctx, shortened)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	originalURL, ok := attributes[OriginalURL]
-	if !ok {
-		return "", errors.New("OriginalURL attribute missing")
+	switch link.Status {
+	case StatusDisabled:
+		return nil, ErrLinkDisabled
+	case StatusBlocked:
+		return nil, ErrLinkBlocked
+	case StatusExhausted:
+		return nil, ErrLinkExhausted
+	case StatusExpired:
+		return nil, ErrLinkExpired
+	case StatusScheduled:
+		if link.PrelaunchURL == "" {
+			return nil, ErrLinkNotActive
+		}
+		return link.prelaunch(), nil
 	}
 
-	u.Logger.Info("retrieved original URL: ", zap.String(logkey.OriginalURL, originalURL))
+	return link, nil
+}
+
+// GetLink returns the full record for shortened, including disabled links.
+func (u *UrlShortener) GetLink(ctx context.Context, shortened string) (*Link, error) {
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	return u.getLink(ctx, shortened)
+}
+
+// SetDisabled stops shortened from redirecting, or re-enables it.
+func (u *UrlShortener) SetDisabled(ctx context.Context, shortened string, disabled bool) error {
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	link, err := u.getLink(ctx, shortened)
+	if err != nil {
+		return err
+	}
+	if err = u.DBClient.SetDisabled(ctx, shortened, disabled); err != nil {
+		return err
+	}
+
+	link.Disabled = disabled
+	link.Status = link.StatusAt(u.now())
+	u.emit(ctx, webhook.LinkUpdated, link)
+	return nil
+}
+
+// DeleteLink removes shortened for good. Its code is not handed out again.
+func (u *UrlShortener) DeleteLink(ctx context.Context, shortened string) error {
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	link, err := u.getLink(ctx, shortened)
+	if err != nil {
+		return err
+	}
+	if err = u.DBClient.DeleteItem(ctx, shortened); err != nil {
+		return err
+	}
+	logging.FromContext(ctx, u.Logger).Info("deleted link", zap.String(logkey.ShortenedURL, shortened))
+
+	u.emit(ctx, webhook.LinkDeleted, link)
+	return nil
+}
+
+// UpdateMetadata changes the title, tags or notes of shortened and returns the updated link.
+func (u *UrlShortener) UpdateMetadata(ctx context.Context, shortened string, update MetadataUpdate) (*Link, error) {
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+
+	link, err := u.getLink(ctx, shortened)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := update.apply(link.Metadata)
+	if err = metadata.validate(); err != nil {
+		return nil, err
+	}
+
+	set, err := attributevalue.MarshalMap(metadata)
+	if err != nil {
+		return nil, err
+	}
+	var remove []string
+	for _, name := range metadataAttributes {
+		if _, ok := set[name]; !ok {
+			remove = append(remove, name)
+		}
+	}
+
+	err = u.DBClient.UpdateAttributes(ctx, shortened, set, remove)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx, u.Logger).Info("updated link metadata", zap.String(logkey.ShortenedURL, shortened))
+
+	link.Metadata = metadata
+	u.emit(ctx, webhook.LinkUpdated, link)
+	return link, nil
+}
+
+// RecordVariantClick counts a redirect to one of the weighted destinations of shortened.
+func (u *UrlShortener) RecordVariantClick(ctx context.Context, shortened string, variant int) error {
+	return u.DBClient.IncrementVariantClicks(ctx, shortened, variant)
+}
+
+// ConsumeClick spends one click of a link's budget, returning ErrLinkExhausted when none is left.
+func (u *UrlShortener) ConsumeClick(ctx context.Context, shortened string) error {
+	remaining, err := u.DBClient.ConsumeClick(ctx, shortened)
+	if errors.Is(err, urlDB.ErrNoClicksLeft) {
+		return ErrLinkExhausted
+	}
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx, u.Logger).Info("consumed link click", zap.String(logkey.ShortenedURL, shortened),
+		zap.Int64(logkey.ClicksRemaining, remaining))
+	return nil
+}
+
+// Counter returns the ID that was assigned to the most recently shortened URL.
+func (u *UrlShortener) Counter(ctx context.Context) (int64, error) {
+	return u.DBClient.GetCounter(ctx)
+}
+
+func (u *UrlShortener) getLink(ctx context.Context, shortened string) (*Link, error) {
+	original, err := u.DBClient.GetItemByPK(ctx, shortened)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(original.Item) == 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	var link Link
+	err = attributevalue.UnmarshalMap(original.Item, &link)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.OriginalURL == "" {
+		return nil, errors.New("OriginalURL attribute missing")
+	}
+	link.Status = link.StatusAt(u.now())
 
-	return originalURL, nil
+	return &link, nil
 }
@@ -1,12 +1,16 @@
 package urlshortener
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
@@ -16,29 +20,78 @@ type MockDBProvider struct {
 	mock.Mock
 }
 
-func (m *MockDBProvider) GetItemByPK(shortUrl string) (*dynamodb.GetItemOutput, error) {
+func (m *MockDBProvider) GetItemByPK(_ context.Context, shortUrl string) (*dynamodb.GetItemOutput, error) {
 	args := m.Called(shortUrl)
 	return args.Get(0).(*dynamodb.GetItemOutput), args.Error(1)
 }
 
-func (m *MockDBProvider) WriteItem(id int64, shortUrl, originalUrl string) error {
-	args := m.Called(id, shortUrl, originalUrl)
+func (m *MockDBProvider) WriteItem(_ context.Context, id int64, shortUrl, originalUrl string, attributes map[string]types.AttributeValue) error {
+	args := m.Called(id, shortUrl, originalUrl, attributes)
 	return args.Error(0)
 }
 
-func (m *MockDBProvider) IncrementCounter() (int64, error) {
+func (m *MockDBProvider) IncrementCounter(_ context.Context) (int64, error) {
 	args := m.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockDBProvider) GetItemByNonPK(attributeName, attributeValue string) (*dynamodb.ScanOutput, error) {
+func (m *MockDBProvider) GetItemByNonPK(_ context.Context, attributeName, attributeValue string) (*dynamodb.ScanOutput, error) {
 	args := m.Called(attributeName, attributeValue)
 	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
 }
 
+func (m *MockDBProvider) SetDisabled(_ context.Context, shortUrl string, disabled bool) error {
+	args := m.Called(shortUrl, disabled)
+	return args.Error(0)
+}
+
+func (m *MockDBProvider) GetCounter(_ context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDBProvider) IncrementVariantClicks(_ context.Context, shortUrl string, variant int) error {
+	args := m.Called(shortUrl, variant)
+	return args.Error(0)
+}
+
+func (m *MockDBProvider) ConsumeClick(_ context.Context, shortUrl string) (int64, error) {
+	args := m.Called(shortUrl)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDBProvider) UpdateAttributes(_ context.Context, shortUrl string, set map[string]types.AttributeValue, remove []string) error {
+	args := m.Called(shortUrl, set, remove)
+	return args.Error(0)
+}
+
+func (m *MockDBProvider) QueryLinks(_ context.Context, q urlDB.LinkQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	args := m.Called(q)
+	items, _ := args.Get(0).([]map[string]types.AttributeValue)
+	lastKey, _ := args.Get(1).(map[string]types.AttributeValue)
+	return items, lastKey, args.Error(2)
+}
+
+func (m *MockDBProvider) DeleteItem(_ context.Context, shortUrl string) error {
+	args := m.Called(shortUrl)
+	return args.Error(0)
+}
+
+func (m *MockDBProvider) GetLinkCheckCursor(_ context.Context) (map[string]types.AttributeValue, error) {
+	args := m.Called()
+	key, _ := args.Get(0).(map[string]types.AttributeValue)
+	return key, args.Error(1)
+}
+
+func (m *MockDBProvider) SetLinkCheckCursor(_ context.Context, key map[string]types.AttributeValue) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
 func Test_ShortenURL(t *testing.T) {
 	tests := map[string]struct {
 		orignalURL  string
+		opts        LinkOptions
 		scanOutput  *dynamodb.ScanOutput
 		countValue  int64
 		scanError   error
@@ -69,6 +122,45 @@ func Test_ShortenURL(t *testing.T) {
 			countValue: int64(1),
 			shortURL:   "b",
 		},
+		"Happy Path URL seen before with other options": {
+			orignalURL: "http://www.example.com",
+			opts:       LinkOptions{RedirectType: http.StatusMovedPermanently},
+			scanOutput: &dynamodb.ScanOutput{
+				Count: 1,
+				Items: []map[string]types.AttributeValue{
+					{
+						"original_url": &types.AttributeValueMemberS{Value: "https://example.com"},
+						"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					},
+				},
+			},
+			countValue: int64(2),
+			shortURL:   "c",
+		},
+		"Happy Path URL seen before with same options": {
+			orignalURL: "http://www.example.com",
+			opts:       LinkOptions{RedirectType: http.StatusMovedPermanently},
+			scanOutput: &dynamodb.ScanOutput{
+				Count: 2,
+				Items: []map[string]types.AttributeValue{
+					{
+						"original_url": &types.AttributeValueMemberS{Value: "https://example.com"},
+						"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					},
+					{
+						"original_url":  &types.AttributeValueMemberS{Value: "https://example.com"},
+						"short_url":     &types.AttributeValueMemberS{Value: "c"},
+						"redirect_type": &types.AttributeValueMemberN{Value: "301"},
+					},
+				},
+			},
+			shortURL: "c",
+		},
+		"Sad Path invalid redirect type": {
+			orignalURL:  "http://www.example.com",
+			opts:        LinkOptions{RedirectType: http.StatusOK},
+			expectError: true,
+		},
 		"Sad Path Scan Error": {
 			orignalURL:  "http://www.example.com",
 			scanError:   errors.New("error"),
@@ -120,11 +212,11 @@ func Test_ShortenURL(t *testing.T) {
 				DBClient: m,
 			}
 
-			m.On("WriteItem", mock.Anything, mock.Anything, mock.Anything).Return(tc.writeError)
+			m.On("WriteItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.writeError)
 			m.On("IncrementCounter").Return(tc.countValue, tc.countError)
 
 			m.On("GetItemByNonPK", mock.Anything, tc.orignalURL).Return(tc.scanOutput, tc.scanError)
-			shortened, err := u.ShortenURL(tc.orignalURL)
+			shortened, err := u.ShortenURL(context.Background(), tc.orignalURL, tc.opts)
 
 			if tc.expectError {
 				assert.Error(t, err)
@@ -160,6 +252,23 @@ func Test_GetOriginalURL(t *testing.T) {
 			dbError:     errors.New("error"),
 			expectError: true,
 		},
+		"Sad Path Not Found": {
+			shortURL:    "b",
+			itemOutput:  &dynamodb.GetItemOutput{},
+			expectError: true,
+		},
+		"Sad Path Disabled": {
+			shortURL: "b",
+			itemOutput: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"id":           &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", int64(1))},
+					"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+					"disabled":     &types.AttributeValueMemberBOOL{Value: true},
+				},
+			},
+			expectError: true,
+		},
 		"Sad Path No OriginalURL Attribute": {
 			orignalURL: "http://www.example.com",
 			shortURL:   "b",
@@ -186,7 +295,7 @@ func Test_GetOriginalURL(t *testing.T) {
 				DBClient: m,
 			}
 
-			original, err := u.GetOriginalURL(tc.shortURL)
+			original, err := u.GetOriginalURL(context.Background(), tc.shortURL)
 
 			if tc.expectError {
 				assert.Error(t, err)
@@ -197,6 +306,539 @@ func Test_GetOriginalURL(t *testing.T) {
 	}
 }
 
+func Test_GetLink(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"id":           &types.AttributeValueMemberN{Value: "1"},
+			"short_url":    &types.AttributeValueMemberS{Value: "b"},
+			"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+			"disabled":     &types.AttributeValueMemberBOOL{Value: true},
+		},
+	}, nil)
+	m.On("GetItemByPK", "c").Return(&dynamodb.GetItemOutput{}, nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	link, err := u.GetLink(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.Equal(t, &Link{ShortURL: "b", ID: 1, OriginalURL: "http://www.example.com", Disabled: true, Status: StatusDisabled}, link)
+
+	_, err = u.GetLink(context.Background(), "c")
+	assert.ErrorIs(t, err, ErrLinkNotFound)
+
+	_, err = u.GetOriginalURL(context.Background(), "b")
+	assert.ErrorIs(t, err, ErrLinkDisabled)
+}
+
+func Test_ShortenURL_StoresOptions(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{}, nil)
+	m.On("IncrementCounter").Return(int64(1), nil)
+	m.On("WriteItem", int64(1), "b", "http://www.example.com", map[string]types.AttributeValue{
+		"redirect_type": &types.AttributeValueMemberN{Value: "308"},
+	}).Return(nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	shortened, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{RedirectType: http.StatusPermanentRedirect})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "b", shortened)
+	m.AssertExpectations(t)
+}
+
+func Test_ShortenURL_InitializesVariantClicks(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{}, nil)
+	m.On("IncrementCounter").Return(int64(1), nil)
+	m.On("WriteItem", int64(1), "b", "http://www.example.com", mock.MatchedBy(func(attributes map[string]types.AttributeValue) bool {
+		clicks, ok := attributes["variant_clicks"].(*types.AttributeValueMemberM)
+		if !ok || len(clicks.Value) != 2 {
+			return false
+		}
+		destinations, ok := attributes["destinations"].(*types.AttributeValueMemberL)
+		return ok && len(destinations.Value) == 2
+	})).Return(nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	_, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{
+		Destinations: []Destination{{URL: "http://a.example.com", Weight: 1}, {URL: "http://b.example.com", Weight: 3}},
+	})
+
+	assert.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func Test_ShortenURL_HashesPassword(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByNonPK", OriginalURL, "http://www.example.com").Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{
+			{
+				"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+				"short_url":    &types.AttributeValueMemberS{Value: "b"},
+			},
+		},
+	}, nil)
+	m.On("IncrementCounter").Return(int64(2), nil)
+	m.On("WriteItem", int64(2), "c", "http://www.example.com", mock.MatchedBy(func(attributes map[string]types.AttributeValue) bool {
+		hash, ok := attributes["password_hash"].(*types.AttributeValueMemberS)
+		if !ok || hash.Value == "hunter2" {
+			return false
+		}
+		link := &Link{LinkOptions: LinkOptions{PasswordHash: hash.Value}}
+		_, stored := attributes["Password"]
+		return !stored && link.CheckPassword("hunter2")
+	})).Return(nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	shortened, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{Password: "hunter2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "c", shortened)
+	m.AssertExpectations(t)
+}
+
+func Test_ShortenURL_ClickBudget(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("IncrementCounter").Return(int64(1), nil)
+	m.On("WriteItem", int64(1), "b", "http://www.example.com", map[string]types.AttributeValue{
+		"max_clicks":       &types.AttributeValueMemberN{Value: "1"},
+		"clicks_remaining": &types.AttributeValueMemberN{Value: "1"},
+	}).Return(nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	shortened, err := u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{MaxClicks: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "b", shortened)
+	// budgeted links are never looked up for reuse
+	m.AssertNotCalled(t, "GetItemByNonPK", OriginalURL, "http://www.example.com")
+	m.AssertExpectations(t)
+}
+
+func Test_ShortenURL_DestinationPolicy(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+		Policy:   DestinationPolicy{DeniedDomains: []string{"evil.example"}, ShortDomains: []string{"sho.rt"}},
+	}
+
+	_, err := u.ShortenURL(context.Background(), "http://169.254.169.254/latest/meta-data", LinkOptions{})
+	assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+	assert.ErrorIs(t, err, ErrInvalidLink)
+
+	_, err = u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{
+		Rules: []Rule{{Device: DeviceIOS, URL: "https://login.evil.example"}},
+	})
+	assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+
+	_, err = u.ShortenURL(context.Background(), "http://www.example.com", LinkOptions{
+		Destinations: []Destination{{URL: "http://www.example.com", Weight: 1}, {URL: "https://sho.rt/b", Weight: 1}},
+	})
+	assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+	m.AssertNotCalled(t, "IncrementCounter")
+}
+
+func Test_ConsumeClick(t *testing.T) {
+	tests := map[string]struct {
+		dbError       error
+		expectedError error
+	}{
+		"Click consumed": {},
+		"Budget used up": {
+			dbError:       urlDB.ErrNoClicksLeft,
+			expectedError: ErrLinkExhausted,
+		},
+		"Database error": {
+			dbError:       errors.New("error"),
+			expectedError: errors.New("error"),
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockDBProvider)
+			m.On("ConsumeClick", "b").Return(int64(2), tc.dbError)
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+			}
+
+			err := u.ConsumeClick(context.Background(), "b")
+
+			switch {
+			case tc.expectedError == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.expectedError, ErrLinkExhausted):
+				assert.ErrorIs(t, err, ErrLinkExhausted)
+			default:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrLinkExhausted)
+			}
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_UpdateMetadata(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberN{Value: "1"},
+		"short_url":    &types.AttributeValueMemberS{Value: "b"},
+		"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+		"title":        &types.AttributeValueMemberS{Value: "Old"},
+		"notes":        &types.AttributeValueMemberS{Value: "keep me"},
+	}
+	title := " Launch "
+	tags := []string{"Q3", "launch", "q3"}
+	empty := ""
+
+	tests := map[string]struct {
+		update        MetadataUpdate
+		item          map[string]types.AttributeValue
+		set           map[string]types.AttributeValue
+		remove        []string
+		updateError   error
+		expected      Metadata
+		expectedError error
+	}{
+		"Set title and tags": {
+			update: MetadataUpdate{Title: &title, Tags: &tags},
+			item:   item,
+			set: map[string]types.AttributeValue{
+				"title": &types.AttributeValueMemberS{Value: "Launch"},
+				"tags": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+					&types.AttributeValueMemberS{Value: "launch"},
+					&types.AttributeValueMemberS{Value: "q3"},
+				}},
+				"notes": &types.AttributeValueMemberS{Value: "keep me"},
+			},
+			expected: Metadata{Title: "Launch", Tags: []string{"launch", "q3"}, Notes: "keep me"},
+		},
+		"Clear notes": {
+			update: MetadataUpdate{Notes: &empty},
+			item:   item,
+			set: map[string]types.AttributeValue{
+				"title": &types.AttributeValueMemberS{Value: "Old"},
+			},
+			remove:   []string{"tags", "notes"},
+			expected: Metadata{Title: "Old"},
+		},
+		"Not found": {
+			item:          map[string]types.AttributeValue{},
+			expectedError: ErrLinkNotFound,
+		},
+		"Invalid": {
+			update:        MetadataUpdate{Tags: &[]string{"a,b"}},
+			item:          item,
+			expectedError: ErrInvalidLink,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockDBProvider)
+			m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{Item: tc.item}, nil)
+			if tc.set != nil {
+				m.On("UpdateAttributes", "b", tc.set, tc.remove).Return(tc.updateError)
+			}
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+			}
+
+			link, err := u.UpdateMetadata(context.Background(), "b", tc.update)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, link.Metadata)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RecordVariantClick(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("IncrementVariantClicks", "b", 1).Return(nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	assert.NoError(t, u.RecordVariantClick(context.Background(), "b", 1))
+	m.AssertExpectations(t)
+}
+
+func Test_ResolveLink(t *testing.T) {
+	logger, _ := zap.NewProduction()
+	m := new(MockDBProvider)
+	m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"id":            &types.AttributeValueMemberN{Value: "1"},
+			"short_url":     &types.AttributeValueMemberS{Value: "b"},
+			"original_url":  &types.AttributeValueMemberS{Value: "http://www.example.com"},
+			"redirect_type": &types.AttributeValueMemberN{Value: "307"},
+		},
+	}, nil)
+	m.On("GetItemByPK", "c").Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"id":           &types.AttributeValueMemberN{Value: "2"},
+			"short_url":    &types.AttributeValueMemberS{Value: "c"},
+			"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+		},
+	}, nil)
+	u := &UrlShortener{
+		Logger:   logger,
+		DBClient: m,
+	}
+
+	link, err := u.ResolveLink(context.Background(), "b")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, link.RedirectStatus())
+
+	link, err = u.ResolveLink(context.Background(), "c")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, link.RedirectStatus())
+
+	m.On("GetItemByPK", "d").Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			"id":               &types.AttributeValueMemberN{Value: "3"},
+			"short_url":        &types.AttributeValueMemberS{Value: "d"},
+			"original_url":     &types.AttributeValueMemberS{Value: "http://www.example.com"},
+			"max_clicks":       &types.AttributeValueMemberN{Value: "1"},
+			"clicks_remaining": &types.AttributeValueMemberN{Value: "0"},
+		},
+	}, nil)
+	_, err = u.ResolveLink(context.Background(), "d")
+	assert.ErrorIs(t, err, ErrLinkExhausted)
+}
+
+func Test_ResolveLink_Schedule(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	launch := now.Add(time.Hour)
+	end := now.Add(-time.Hour)
+
+	tests := map[string]struct {
+		item             map[string]types.AttributeValue
+		expectedURL      string
+		expectedStatus   LinkStatus
+		expectedRedirect int
+		expectProtected  bool
+		expectError      error
+	}{
+		"Active inside the window": {
+			item: map[string]types.AttributeValue{
+				"not_before":    &types.AttributeValueMemberS{Value: end.Format(time.RFC3339)},
+				"not_after":     &types.AttributeValueMemberS{Value: launch.Format(time.RFC3339)},
+				"redirect_type": &types.AttributeValueMemberN{Value: "301"},
+			},
+			expectedURL:      "http://www.example.com",
+			expectedStatus:   StatusActive,
+			expectedRedirect: http.StatusMovedPermanently,
+		},
+		"Scheduled without fallback": {
+			item: map[string]types.AttributeValue{
+				"not_before": &types.AttributeValueMemberS{Value: launch.Format(time.RFC3339)},
+			},
+			expectError: ErrLinkNotActive,
+		},
+		"Scheduled with fallback": {
+			item: map[string]types.AttributeValue{
+				"not_before":       &types.AttributeValueMemberS{Value: launch.Format(time.RFC3339)},
+				"prelaunch_url":    &types.AttributeValueMemberS{Value: "http://www.example.com/soon"},
+				"redirect_type":    &types.AttributeValueMemberN{Value: "301"},
+				"max_clicks":       &types.AttributeValueMemberN{Value: "1"},
+				"clicks_remaining": &types.AttributeValueMemberN{Value: "1"},
+			},
+			expectedURL:      "http://www.example.com/soon",
+			expectedStatus:   StatusScheduled,
+			expectedRedirect: http.StatusFound,
+		},
+		"Scheduled with fallback keeps the password": {
+			item: map[string]types.AttributeValue{
+				"not_before":    &types.AttributeValueMemberS{Value: launch.Format(time.RFC3339)},
+				"prelaunch_url": &types.AttributeValueMemberS{Value: "http://www.example.com/soon"},
+				"password_hash": &types.AttributeValueMemberS{Value: "$2a$10$hash"},
+			},
+			expectedURL:      "http://www.example.com/soon",
+			expectedStatus:   StatusScheduled,
+			expectedRedirect: http.StatusFound,
+			expectProtected:  true,
+		},
+		"Expired": {
+			item: map[string]types.AttributeValue{
+				"not_after": &types.AttributeValueMemberS{Value: end.Format(time.RFC3339)},
+			},
+			expectError: ErrLinkExpired,
+		},
+		"Disabled wins over the schedule": {
+			item: map[string]types.AttributeValue{
+				"not_after": &types.AttributeValueMemberS{Value: end.Format(time.RFC3339)},
+				"disabled":  &types.AttributeValueMemberBOOL{Value: true},
+			},
+			expectError: ErrLinkDisabled,
+		},
+	}
+	logger, _ := zap.NewProduction()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.item["short_url"] = &types.AttributeValueMemberS{Value: "b"}
+			tc.item["original_url"] = &types.AttributeValueMemberS{Value: "http://www.example.com"}
+			m := new(MockDBProvider)
+			m.On("GetItemByPK", "b").Return(&dynamodb.GetItemOutput{Item: tc.item}, nil)
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+				Now:      func() time.Time { return now },
+			}
+
+			link, err := u.ResolveLink(context.Background(), "b")
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedURL, link.OriginalURL)
+			assert.Equal(t, tc.expectedStatus, link.Status)
+			assert.Equal(t, tc.expectedRedirect, link.RedirectStatus())
+			assert.Equal(t, tc.expectProtected, link.Protected())
+			assert.Zero(t, link.MaxClicks, "the prelaunch fallback does not spend clicks")
+		})
+	}
+}
+
+func Test_SetDisabled(t *testing.T) {
+	tests := map[string]struct {
+		shortURL    string
+		itemOutput  *dynamodb.GetItemOutput
+		updateError error
+		expectError error
+	}{
+		"Happy Path": {
+			shortURL: "b",
+			itemOutput: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+				},
+			},
+		},
+		"Sad Path Not Found": {
+			shortURL:    "b",
+			itemOutput:  &dynamodb.GetItemOutput{},
+			expectError: ErrLinkNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := zap.NewProduction()
+			m := new(MockDBProvider)
+			m.On("GetItemByPK", tc.shortURL).Return(tc.itemOutput, nil)
+			m.On("SetDisabled", tc.shortURL, true).Return(tc.updateError)
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+			}
+
+			err := u.SetDisabled(context.Background(), tc.shortURL, true)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				m.AssertNotCalled(t, "SetDisabled", tc.shortURL, true)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DeleteLink(t *testing.T) {
+	tests := map[string]struct {
+		itemOutput  *dynamodb.GetItemOutput
+		deleteError error
+		expectError error
+	}{
+		"Happy Path": {
+			itemOutput: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+				},
+			},
+		},
+		"Sad Path Not Found": {
+			itemOutput:  &dynamodb.GetItemOutput{},
+			expectError: ErrLinkNotFound,
+		},
+		"Sad Path delete error": {
+			itemOutput: &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"short_url":    &types.AttributeValueMemberS{Value: "b"},
+					"original_url": &types.AttributeValueMemberS{Value: "http://www.example.com"},
+				},
+			},
+			deleteError: errors.New("error"),
+			expectError: errors.New("error"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, _ := zap.NewProduction()
+			m := new(MockDBProvider)
+			m.On("GetItemByPK", "b").Return(tc.itemOutput, nil)
+			m.On("DeleteItem", "b").Return(tc.deleteError)
+			u := &UrlShortener{
+				Logger:   logger,
+				DBClient: m,
+			}
+
+			err := u.DeleteLink(context.Background(), "b")
+
+			if tc.expectError != nil {
+				assert.Error(t, err)
+				if errors.Is(tc.expectError, ErrLinkNotFound) {
+					assert.ErrorIs(t, err, ErrLinkNotFound)
+					m.AssertNotCalled(t, "DeleteItem", "b")
+				}
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
 func Test_NewClient(t *testing.T) {
 	logger, _ := zap.NewProduction()
 	u, _ := New(logger)
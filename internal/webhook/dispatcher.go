@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/logging"
+	"github.com/connorpalermo/url-shortener/internal/netaddr"
+	"go.uber.org/zap"
+)
+
+type (
+	// SubscriptionStore is implemented by Store.
+	SubscriptionStore interface {
+		Subscriptions(ctx context.Context) ([]Subscription, error)
+		RecordDelivery(ctx context.Context, d Delivery) error
+	}
+
+	// Dispatcher delivers events to their subscriptions in the background, retrying failed
+	// deliveries with exponential backoff and logging every attempt.
+	Dispatcher struct {
+		Store  SubscriptionStore
+		Logger *zap.Logger
+		// Client defaults to one with DefaultTimeout that does not follow redirects and refuses to
+		// connect to non-public addresses.
+		Client *http.Client
+		// MaxAttempts bounds how often a delivery is tried, DefaultMaxAttempts when zero.
+		MaxAttempts int
+		// Backoff is the wait before the first retry, doubled for every further one up to
+		// MaxBackoff. DefaultBackoff and DefaultMaxBackoff when zero.
+		Backoff    time.Duration
+		MaxBackoff time.Duration
+		// CacheTTL is how long subscriptions are reused before they are read again,
+		// DefaultCacheTTL when zero.
+		CacheTTL time.Duration
+		// Now stamps events and signatures, time.Now when nil.
+		Now func() time.Time
+
+		wg       sync.WaitGroup
+		once     sync.Once
+		stopOnce sync.Once
+		stopped  context.Context
+		stop     context.CancelFunc
+		client   *http.Client
+		mu       sync.Mutex
+		subs     []Subscription
+		loadedAt time.Time
+	}
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = time.Minute
+	DefaultCacheTTL    = time.Minute
+	DefaultTimeout     = 5 * time.Second
+	DefaultUserAgent   = "url-shortener-webhook/1.0"
+
+	// FlushTimeoutEnv bounds how long a Lambda invocation waits for webhook deliveries before it
+	// returns, DefaultFlushTimeout when unset. Deliveries still running carry on if the execution
+	// environment is reused.
+	FlushTimeoutEnv     = "WEBHOOK_FLUSH_TIMEOUT"
+	DefaultFlushTimeout = time.Second
+)
+
+// FlushTimeoutFromEnv reads WEBHOOK_FLUSH_TIMEOUT, a duration such as 500ms.
+func FlushTimeoutFromEnv() (time.Duration, error) {
+	v := os.Getenv(FlushTimeoutEnv)
+	if v == "" {
+		return DefaultFlushTimeout, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", FlushTimeoutEnv, err)
+	}
+	return timeout, nil
+}
+
+// Emit sends an event with data as its payload to every subscription that wants it. It returns
+// straight away; Flush waits for the deliveries to finish.
+func (d *Dispatcher) Emit(ctx context.Context, eventType EventType, data any) {
+	logger := logging.FromContext(ctx, d.Logger).With(zap.String(logkey.EventType, string(eventType)))
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("failed to encode webhook event", zap.Error(err))
+		return
+	}
+	event := Event{ID: "evt_" + randomHex(12), Type: eventType, CreatedAt: d.now().UTC(), Data: payload}
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("failed to encode webhook event", zap.Error(err))
+		return
+	}
+
+	// deliveries outlive the request that caused them, but not the dispatcher
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	unlink := context.AfterFunc(d.lifetime(), cancel)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer cancel()
+		defer unlink()
+		subs, err := d.subscriptions(ctx)
+		if err != nil {
+			logger.Error("failed to load webhook subscriptions", zap.Error(err))
+			return
+		}
+		var wg sync.WaitGroup
+		for _, sub := range subs {
+			if !sub.Wants(eventType) {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, logger, sub, event, body)
+			}()
+		}
+		wg.Wait()
+	}()
+}
+
+// Flush waits until every delivery started by Emit, including its retries, finished or ctx is
+// done.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops every delivery started by Emit, abandoning the attempts in flight and the retries
+// still waiting for their backoff. Events emitted afterwards are not delivered.
+func (d *Dispatcher) Close() {
+	d.lifetime()
+	d.stop()
+}
+
+func (d *Dispatcher) lifetime() context.Context {
+	d.stopOnce.Do(func() {
+		d.stopped, d.stop = context.WithCancel(context.Background())
+	})
+	return d.stopped
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, logger *zap.Logger, sub Subscription, event Event, body []byte) {
+	logger = logger.With(zap.String(logkey.WebhookID, sub.ID), zap.String(logkey.EventID, event.ID))
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	backoff, maxBackoff := d.Backoff, d.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		delivery, retry := d.attempt(ctx, sub, event, body, attempt)
+		if err := d.Store.RecordDelivery(ctx, delivery); err != nil {
+			logger.Warn("failed to record webhook delivery", zap.Error(err))
+		}
+		if delivery.Succeeded {
+			return
+		}
+		if !retry || attempt >= maxAttempts {
+			logger.Warn("webhook delivery failed", zap.Int(logkey.Attempt, attempt), zap.Int(logkey.Status, delivery.StatusCode), zap.String(logkey.Error, delivery.Error))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			logger.Warn("webhook delivery abandoned", zap.Int(logkey.Attempt, attempt), zap.Error(ctx.Err()))
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// attempt posts the event once. It reports whether a failed attempt is worth retrying, which is
+// not the case when the receiver rejected the request.
+func (d *Dispatcher) attempt(ctx context.Context, sub Subscription, event Event, body []byte, attempt int) (Delivery, bool) {
+	delivery := Delivery{
+		ID:        randomHex(12),
+		WebhookID: sub.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Attempt:   attempt,
+		CreatedAt: d.now().UTC(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, delivery.CreatedAt, body))
+
+	start := time.Now()
+	resp, err := d.httpClient().Do(req)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, true
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Succeeded = resp.StatusCode >= 200 && resp.StatusCode < 300
+	retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout
+	return delivery, retry
+}
+
+func (d *Dispatcher) subscriptions(ctx context.Context) ([]Subscription, error) {
+	ttl := d.CacheTTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.subs != nil && d.now().Sub(d.loadedAt) < ttl {
+		return d.subs, nil
+	}
+	subs, err := d.Store.Subscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if subs == nil {
+		subs = []Subscription{}
+	}
+	d.subs, d.loadedAt = subs, d.now()
+	return subs, nil
+}
+
+func (d *Dispatcher) httpClient() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	d.once.Do(func() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{Timeout: DefaultTimeout, Control: netaddr.DialControl}).DialContext
+		d.client = &http.Client{
+			Transport: transport,
+			Timeout:   DefaultTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	})
+	return d.client
+}
+
+func (d *Dispatcher) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
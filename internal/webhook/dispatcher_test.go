@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/connorpalermo/url-shortener/internal/netaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockSubscriptionStore struct {
+	mock.Mock
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+func (m *MockSubscriptionStore) Subscriptions(_ context.Context) ([]Subscription, error) {
+	args := m.Called()
+	subs, _ := args.Get(0).([]Subscription)
+	return subs, args.Error(1)
+}
+
+func (m *MockSubscriptionStore) RecordDelivery(_ context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func Test_Dispatcher_Emit(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		responses          []int
+		events             []EventType
+		expectedAttempts   int
+		expectedSucceeded  bool
+		expectedLastStatus int
+	}{
+		"Happy Path": {
+			responses:          []int{http.StatusNoContent},
+			expectedAttempts:   1,
+			expectedSucceeded:  true,
+			expectedLastStatus: http.StatusNoContent,
+		},
+		"Happy Path after retries": {
+			responses:          []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK},
+			expectedAttempts:   3,
+			expectedSucceeded:  true,
+			expectedLastStatus: http.StatusOK,
+		},
+		"Sad Path gives up": {
+			responses:          []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectedAttempts:   3,
+			expectedLastStatus: http.StatusBadGateway,
+		},
+		"Sad Path rejected": {
+			responses:          []int{http.StatusBadRequest, http.StatusOK},
+			expectedAttempts:   1,
+			expectedLastStatus: http.StatusBadRequest,
+		},
+		"Not subscribed": {
+			events: []EventType{LinkCreated},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				body, _ := io.ReadAll(r.Body)
+				assert.NoError(t, Verify("s3cret", r.Header.Get(SignatureHeader), body, 0, now))
+				assert.Equal(t, string(LinkClicked), r.Header.Get(EventHeader))
+				assert.NotEmpty(t, r.Header.Get(DeliveryHeader))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				var event Event
+				assert.NoError(t, json.Unmarshal(body, &event))
+				assert.Equal(t, LinkClicked, event.Type)
+				assert.JSONEq(t, `{"short_url":"b"}`, string(event.Data))
+				w.WriteHeader(tc.responses[n-1])
+			}))
+			defer srv.Close()
+
+			store := new(MockSubscriptionStore)
+			store.On("Subscriptions").Return([]Subscription{{ID: "1", URL: srv.URL, Secret: "s3cret", Events: tc.events}}, nil).Once()
+			d := &Dispatcher{
+				Store:       store,
+				Logger:      zaptest.NewLogger(t),
+				Client:      srv.Client(),
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+				Now:         func() time.Time { return now },
+			}
+
+			d.Emit(context.Background(), LinkClicked, map[string]string{"short_url": "b"})
+			assert.NoError(t, d.Flush(context.Background()))
+
+			assert.Equal(t, int32(tc.expectedAttempts), calls.Load())
+			assert.Len(t, store.deliveries, tc.expectedAttempts)
+			for i, delivery := range store.deliveries {
+				assert.Equal(t, i+1, delivery.Attempt)
+				assert.Equal(t, "1", delivery.WebhookID)
+				assert.Equal(t, LinkClicked, delivery.EventType)
+				assert.Equal(t, store.deliveries[0].EventID, delivery.EventID)
+			}
+			if tc.expectedAttempts > 0 {
+				last := store.deliveries[len(store.deliveries)-1]
+				assert.Equal(t, tc.expectedSucceeded, last.Succeeded)
+				assert.Equal(t, tc.expectedLastStatus, last.StatusCode)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Dispatcher_CachesSubscriptions(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := new(MockSubscriptionStore)
+	store.On("Subscriptions").Return(nil, nil).Twice()
+	d := &Dispatcher{Store: store, Logger: zaptest.NewLogger(t), Now: func() time.Time { return now }}
+
+	d.Emit(context.Background(), LinkCreated, nil)
+	assert.NoError(t, d.Flush(context.Background()))
+	d.Emit(context.Background(), LinkCreated, nil)
+	assert.NoError(t, d.Flush(context.Background()))
+
+	now = now.Add(DefaultCacheTTL)
+	d.Emit(context.Background(), LinkCreated, nil)
+	assert.NoError(t, d.Flush(context.Background()))
+	store.AssertExpectations(t)
+}
+
+func Test_Dispatcher_Flush(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	store := new(MockSubscriptionStore)
+	store.On("Subscriptions").Return([]Subscription{{ID: "1", URL: srv.URL}}, nil)
+	d := &Dispatcher{Store: store, Logger: zaptest.NewLogger(t), Client: srv.Client()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// canceling the emitting request does not cancel the delivery
+	d.Emit(ctx, LinkClicked, nil)
+	assert.ErrorIs(t, d.Flush(ctx), context.DeadlineExceeded)
+}
+
+func Test_Dispatcher_Close(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := new(MockSubscriptionStore)
+	store.On("Subscriptions").Return([]Subscription{{ID: "1", URL: srv.URL}}, nil)
+	d := &Dispatcher{Store: store, Logger: zaptest.NewLogger(t), Client: srv.Client(), Backoff: time.Hour}
+
+	d.Emit(context.Background(), LinkClicked, nil)
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.deliveries) == 1
+	}, time.Second, time.Millisecond)
+
+	// the retry waiting for its backoff is abandoned instead of holding up Flush
+	d.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, d.Flush(ctx))
+	assert.Len(t, store.deliveries, 1)
+}
+
+func Test_Dispatcher_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	store := new(MockSubscriptionStore)
+	store.On("Subscriptions").Return([]Subscription{{ID: "1", URL: srv.URL}}, nil)
+	d := &Dispatcher{Store: store, Logger: zaptest.NewLogger(t), MaxAttempts: 1}
+
+	d.Emit(context.Background(), LinkClicked, nil)
+	assert.NoError(t, d.Flush(context.Background()))
+	if assert.Len(t, store.deliveries, 1) {
+		assert.False(t, store.deliveries[0].Succeeded)
+		assert.Contains(t, store.deliveries[0].Error, netaddr.ErrNotPublic.Error())
+	}
+}
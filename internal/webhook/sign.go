@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	// DefaultTolerance is how old a signature Verify accepts when no tolerance is given.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the SignatureHeader value for body sent at timestamp:
+// t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with secret>. Signing the timestamp
+// lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + signature(secret, t, body)
+}
+
+// Verify checks a SignatureHeader value the way receivers should, rejecting signatures older than
+// tolerance. Several v1 values are accepted so secrets can be rotated.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	expected := signature(secret, t, body)
+	for _, s := range signatures {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
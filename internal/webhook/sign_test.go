@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sign(t *testing.T) {
+	now := time.Unix(1740819600, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	// echo -n '1740819600.{"id":"evt_1"}' | openssl dgst -sha256 -hmac s3cret
+	assert.Equal(t, "t=1740819600,v1=44aeb0ffce76d889868ed928fcaa06f090007371ae8c71d7899df5cfcd5138ab", Sign("s3cret", now, body))
+}
+
+func Test_Verify(t *testing.T) {
+	now := time.Unix(1740819600, 0)
+	body := []byte(`{"id":"evt_1"}`)
+	header := Sign("s3cret", now, body)
+
+	tests := map[string]struct {
+		secret        string
+		header        string
+		body          []byte
+		now           time.Time
+		expectedError bool
+	}{
+		"Happy Path": {
+			secret: "s3cret",
+			header: header,
+			body:   body,
+			now:    now.Add(time.Minute),
+		},
+		"Happy Path rotated secret": {
+			secret: "s3cret",
+			header: Sign("old", now, body) + "," + header[len("t=1740819600,"):],
+			body:   body,
+			now:    now,
+		},
+		"Sad Path wrong secret": {
+			secret:        "other",
+			header:        header,
+			body:          body,
+			now:           now,
+			expectedError: true,
+		},
+		"Sad Path tampered body": {
+			secret:        "s3cret",
+			header:        header,
+			body:          []byte(`{"id":"evt_2"}`),
+			now:           now,
+			expectedError: true,
+		},
+		"Sad Path replayed": {
+			secret:        "s3cret",
+			header:        header,
+			body:          body,
+			now:           now.Add(DefaultTolerance + time.Second),
+			expectedError: true,
+		},
+		"Sad Path malformed": {
+			secret:        "s3cret",
+			header:        "v1=abc",
+			body:          body,
+			now:           now,
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, 0, tc.now)
+
+			if tc.expectedError {
+				assert.ErrorIs(t, err, ErrInvalidSignature)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
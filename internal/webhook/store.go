@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+)
+
+type (
+	// DBProvider is implemented by persistence.UrlDB.
+	DBProvider interface {
+		PutEntity(ctx context.Context, key, entity string, attributes map[string]types.AttributeValue) error
+		QueryEntities(ctx context.Context, q urlDB.EntityQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error)
+		DeleteItem(ctx context.Context, key string) error
+	}
+
+	// Store keeps subscriptions and the delivery log in the url-mapping table next to the links.
+	Store struct {
+		DB DBProvider
+		// Retention is how long deliveries are kept, DefaultRetention when zero.
+		Retention time.Duration
+		// Now stamps new items, time.Now when nil.
+		Now func() time.Time
+	}
+)
+
+const (
+	DefaultRetention = 30 * 24 * time.Hour
+
+	// keys contain a character short codes cannot, so they never collide with links
+	subscriptionKeyPrefix = "webhook#"
+	deliveryKeyPrefix     = "delivery#"
+	webhookIDAttribute    = "webhook_id"
+)
+
+// SaveSubscription stores a new subscription.
+func (s *Store) SaveSubscription(ctx context.Context, sub Subscription) error {
+	sub.CreatedAt = s.now()
+	return s.put(ctx, subscriptionKeyPrefix+sub.ID, urlDB.EntityWebhook, sub, sub.CreatedAt)
+}
+
+// Subscriptions returns every subscription, oldest first.
+func (s *Store) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := s.query(ctx, urlDB.EntityQuery{Entity: urlDB.EntityWebhook, Ascending: true}, 0, func(item map[string]types.AttributeValue) error {
+		var sub Subscription
+		if err := attributevalue.UnmarshalMap(item, &sub); err != nil {
+			return err
+		}
+		subs = append(subs, sub)
+		return nil
+	})
+	return subs, err
+}
+
+// DeleteSubscription stops deliveries to a subscription. Its delivery log expires on its own.
+func (s *Store) DeleteSubscription(ctx context.Context, id string) error {
+	subs, err := s.Subscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if sub.ID == id {
+			return s.DB.DeleteItem(ctx, subscriptionKeyPrefix+id)
+		}
+	}
+	return ErrSubscriptionNotFound
+}
+
+// RecordDelivery adds an attempt to the delivery log, which DynamoDB expires after Retention.
+func (s *Store) RecordDelivery(ctx context.Context, d Delivery) error {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = s.now()
+	}
+	retention := s.Retention
+	if retention == 0 {
+		retention = DefaultRetention
+	}
+	return s.put(ctx, deliveryKeyPrefix+d.ID, urlDB.EntityDelivery, d, d.CreatedAt, func(item map[string]types.AttributeValue) {
+		item[urlDB.ExpiresAt] = &types.AttributeValueMemberN{Value: strconv.FormatInt(d.CreatedAt.Add(retention).Unix(), 10)}
+	})
+}
+
+// Deliveries returns up to limit of the latest delivery attempts of a subscription, newest first.
+func (s *Store) Deliveries(ctx context.Context, webhookID string, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	q := urlDB.EntityQuery{Entity: urlDB.EntityDelivery, Attribute: webhookIDAttribute, Value: webhookID, Limit: int32(limit)}
+	err := s.query(ctx, q, limit, func(item map[string]types.AttributeValue) error {
+		var d Delivery
+		if err := attributevalue.UnmarshalMap(item, &d); err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+		return nil
+	})
+	return deliveries, err
+}
+
+// put stores v with created_at in the fixed width format the creation time index sorts by.
+func (s *Store) put(ctx context.Context, key, entity string, v any, createdAt time.Time, extra ...func(map[string]types.AttributeValue)) error {
+	item, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		return err
+	}
+	item[urlDB.CreatedAt] = &types.AttributeValueMemberS{Value: urlDB.FormatCreatedAt(createdAt)}
+	for _, f := range extra {
+		f(item)
+	}
+	return s.DB.PutEntity(ctx, key, entity, item)
+}
+
+// query calls f for the items of q until limit items were seen, or all of them when limit is zero.
+// Filters apply after each page's Limit, so it keeps reading pages until it has enough.
+func (s *Store) query(ctx context.Context, q urlDB.EntityQuery, limit int, f func(map[string]types.AttributeValue) error) error {
+	seen := 0
+	for {
+		items, lastKey, err := s.DB.QueryEntities(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err = f(item); err != nil {
+				return err
+			}
+			if seen++; limit > 0 && seen == limit {
+				return nil
+			}
+		}
+		if len(lastKey) == 0 {
+			return nil
+		}
+		q.StartKey = lastKey
+	}
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
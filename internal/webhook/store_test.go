@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	urlDB "github.com/connorpalermo/url-shortener/internal/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDBProvider struct {
+	mock.Mock
+}
+
+func (m *MockDBProvider) PutEntity(_ context.Context, key, entity string, attributes map[string]types.AttributeValue) error {
+	args := m.Called(key, entity, attributes)
+	return args.Error(0)
+}
+
+func (m *MockDBProvider) QueryEntities(_ context.Context, q urlDB.EntityQuery) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	args := m.Called(q)
+	items, _ := args.Get(0).([]map[string]types.AttributeValue)
+	lastKey, _ := args.Get(1).(map[string]types.AttributeValue)
+	return items, lastKey, args.Error(2)
+}
+
+func (m *MockDBProvider) DeleteItem(_ context.Context, key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+var storeNow = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func subscriptionItem(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"webhook_id": &types.AttributeValueMemberS{Value: id},
+		"url":        &types.AttributeValueMemberS{Value: "https://hooks.example.com"},
+		"secret":     &types.AttributeValueMemberS{Value: "s3cret"},
+		"events": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "link.created"},
+		}},
+		"created_at": &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00.000Z"},
+	}
+}
+
+func Test_Store_SaveSubscription(t *testing.T) {
+	m := new(MockDBProvider)
+	m.On("PutEntity", "webhook#1", urlDB.EntityWebhook, subscriptionItem("1")).Return(nil)
+	s := &Store{DB: m, Now: func() time.Time { return storeNow }}
+
+	err := s.SaveSubscription(context.Background(), Subscription{
+		ID:     "1",
+		URL:    "https://hooks.example.com",
+		Secret: "s3cret",
+		Events: []EventType{LinkCreated},
+	})
+
+	assert.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func Test_Store_Subscriptions(t *testing.T) {
+	lastKey := map[string]types.AttributeValue{"short_url": &types.AttributeValueMemberS{Value: "webhook#1"}}
+	m := new(MockDBProvider)
+	m.On("QueryEntities", urlDB.EntityQuery{Entity: urlDB.EntityWebhook, Ascending: true}).
+		Return([]map[string]types.AttributeValue{subscriptionItem("1")}, lastKey, nil)
+	m.On("QueryEntities", urlDB.EntityQuery{Entity: urlDB.EntityWebhook, Ascending: true, StartKey: lastKey}).
+		Return([]map[string]types.AttributeValue{subscriptionItem("2")}, nil, nil)
+	s := &Store{DB: m}
+
+	subs, err := s.Subscriptions(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Subscription{
+		{ID: "1", URL: "https://hooks.example.com", Secret: "s3cret", Events: []EventType{LinkCreated}, CreatedAt: storeNow},
+		{ID: "2", URL: "https://hooks.example.com", Secret: "s3cret", Events: []EventType{LinkCreated}, CreatedAt: storeNow},
+	}, subs)
+	m.AssertExpectations(t)
+}
+
+func Test_Store_DeleteSubscription(t *testing.T) {
+	tests := map[string]struct {
+		id            string
+		queryError    error
+		expectDelete  bool
+		expectedError error
+	}{
+		"Happy Path": {
+			id:           "1",
+			expectDelete: true,
+		},
+		"Sad Path not found": {
+			id:            "2",
+			expectedError: ErrSubscriptionNotFound,
+		},
+		"Sad Path error": {
+			id:            "1",
+			queryError:    errors.New("error"),
+			expectedError: errors.New("error"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := new(MockDBProvider)
+			m.On("QueryEntities", mock.Anything).Return([]map[string]types.AttributeValue{subscriptionItem("1")}, nil, tc.queryError)
+			if tc.expectDelete {
+				m.On("DeleteItem", "webhook#"+tc.id).Return(nil)
+			}
+			s := &Store{DB: m}
+
+			err := s.DeleteSubscription(context.Background(), tc.id)
+
+			if tc.expectedError != nil {
+				assert.Error(t, err)
+				if errors.Is(tc.expectedError, ErrSubscriptionNotFound) {
+					assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+				}
+				m.AssertNotCalled(t, "DeleteItem", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Store_RecordDelivery(t *testing.T) {
+	m := new(MockDBProvider)
+	m.On("PutEntity", "delivery#d1", urlDB.EntityDelivery, map[string]types.AttributeValue{
+		"delivery_id": &types.AttributeValueMemberS{Value: "d1"},
+		"webhook_id":  &types.AttributeValueMemberS{Value: "1"},
+		"event_id":    &types.AttributeValueMemberS{Value: "evt_1"},
+		"event_type":  &types.AttributeValueMemberS{Value: "link.clicked"},
+		"attempt":     &types.AttributeValueMemberN{Value: "2"},
+		"succeeded":   &types.AttributeValueMemberBOOL{Value: false},
+		"status_code": &types.AttributeValueMemberN{Value: "502"},
+		"duration_ms": &types.AttributeValueMemberN{Value: "12"},
+		"created_at":  &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00.000Z"},
+		"expires_at":  &types.AttributeValueMemberN{Value: "1743411600"},
+	}).Return(nil)
+	s := &Store{DB: m, Now: func() time.Time { return storeNow }}
+
+	err := s.RecordDelivery(context.Background(), Delivery{
+		ID:         "d1",
+		WebhookID:  "1",
+		EventID:    "evt_1",
+		EventType:  LinkClicked,
+		Attempt:    2,
+		StatusCode: 502,
+		DurationMS: 12,
+	})
+
+	assert.NoError(t, err)
+	m.AssertExpectations(t)
+}
+
+func Test_Store_Deliveries(t *testing.T) {
+	delivery := func(id string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: id},
+			"webhook_id":  &types.AttributeValueMemberS{Value: "1"},
+			"succeeded":   &types.AttributeValueMemberBOOL{Value: true},
+			"created_at":  &types.AttributeValueMemberS{Value: "2025-03-01T09:00:00.000Z"},
+		}
+	}
+	lastKey := map[string]types.AttributeValue{"short_url": &types.AttributeValueMemberS{Value: "delivery#d1"}}
+	query := urlDB.EntityQuery{Entity: urlDB.EntityDelivery, Attribute: "webhook_id", Value: "1", Limit: 2}
+	m := new(MockDBProvider)
+	m.On("QueryEntities", query).Return([]map[string]types.AttributeValue{delivery("d1")}, lastKey, nil)
+	query.StartKey = lastKey
+	m.On("QueryEntities", query).Return([]map[string]types.AttributeValue{delivery("d2"), delivery("d3")}, lastKey, nil)
+	s := &Store{DB: m}
+
+	deliveries, err := s.Deliveries(context.Background(), "1", 2)
+
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, "d1", deliveries[0].ID)
+		assert.Equal(t, "d2", deliveries[1].ID)
+		assert.True(t, deliveries[1].Succeeded)
+	}
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,103 @@
+// Package webhook delivers link lifecycle and click events to the HTTP endpoints subscribed to
+// them, signing every delivery so receivers can tell it came from this service.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+type (
+	EventType string
+
+	// Event is the JSON body of a delivery. Retries of the same event share its ID, so receivers
+	// can drop duplicates.
+	Event struct {
+		ID        string          `json:"id"`
+		Type      EventType       `json:"type"`
+		CreatedAt time.Time       `json:"created_at"`
+		Data      json.RawMessage `json:"data"`
+	}
+
+	// Subscription sends the events listed in Events to URL, or every event when Events is empty.
+	// Secret signs the deliveries and is only shown when the subscription is created.
+	Subscription struct {
+		ID        string      `dynamodbav:"webhook_id" json:"id"`
+		URL       string      `dynamodbav:"url" json:"url"`
+		Secret    string      `dynamodbav:"secret" json:"secret,omitempty"`
+		Events    []EventType `dynamodbav:"events,omitempty" json:"events,omitempty"`
+		CreatedAt time.Time   `dynamodbav:"created_at" json:"created_at"`
+	}
+
+	// Delivery is one attempt to deliver an event, as kept in the delivery log.
+	Delivery struct {
+		ID         string    `dynamodbav:"delivery_id" json:"id"`
+		WebhookID  string    `dynamodbav:"webhook_id" json:"webhook_id"`
+		EventID    string    `dynamodbav:"event_id" json:"event_id"`
+		EventType  EventType `dynamodbav:"event_type" json:"event_type"`
+		Attempt    int       `dynamodbav:"attempt" json:"attempt"`
+		Succeeded  bool      `dynamodbav:"succeeded" json:"succeeded"`
+		StatusCode int       `dynamodbav:"status_code,omitempty" json:"status_code,omitempty"`
+		Error      string    `dynamodbav:"error,omitempty" json:"error,omitempty"`
+		DurationMS int64     `dynamodbav:"duration_ms" json:"duration_ms"`
+		CreatedAt  time.Time `dynamodbav:"created_at" json:"created_at"`
+	}
+)
+
+const (
+	LinkCreated EventType = "link.created"
+	// LinkUpdated is sent when the metadata of a link changes or it is disabled or enabled.
+	LinkUpdated EventType = "link.updated"
+	LinkDeleted EventType = "link.deleted"
+	LinkClicked EventType = "link.clicked"
+)
+
+var (
+	// EventTypes lists every event a subscription can ask for.
+	EventTypes = []EventType{LinkCreated, LinkUpdated, LinkDeleted, LinkClicked}
+
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+)
+
+// NewSubscription validates a subscription and assigns its ID, generating a secret when secret is
+// empty.
+func NewSubscription(rawURL string, events []EventType, secret string) (Subscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, fmt.Errorf("%w: %q is not an absolute http(s) URL", ErrInvalidSubscription, rawURL)
+	}
+	for _, event := range events {
+		if !slices.Contains(EventTypes, event) {
+			return Subscription{}, fmt.Errorf("%w: unknown event %q", ErrInvalidSubscription, event)
+		}
+	}
+	if secret == "" {
+		secret = "whsec_" + randomHex(24)
+	}
+	return Subscription{
+		ID:     randomHex(8),
+		URL:    rawURL,
+		Secret: secret,
+		Events: slices.Compact(slices.Sorted(slices.Values(events))),
+	}, nil
+}
+
+// Wants reports whether the subscription receives events of type t.
+func (s Subscription) Wants(t EventType) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, t)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSubscription(t *testing.T) {
+	tests := map[string]struct {
+		url            string
+		events         []EventType
+		secret         string
+		expectedEvents []EventType
+		expectedError  error
+	}{
+		"Happy Path": {
+			url:            "https://hooks.example.com/links",
+			events:         []EventType{LinkClicked, LinkCreated, LinkClicked},
+			secret:         "s3cret",
+			expectedEvents: []EventType{LinkClicked, LinkCreated},
+		},
+		"Happy Path every event": {
+			url: "http://hooks.example.com",
+		},
+		"Sad Path relative URL": {
+			url:           "/hooks",
+			expectedError: ErrInvalidSubscription,
+		},
+		"Sad Path unsupported scheme": {
+			url:           "ftp://hooks.example.com",
+			expectedError: ErrInvalidSubscription,
+		},
+		"Sad Path unknown event": {
+			url:           "https://hooks.example.com",
+			events:        []EventType{"link.exploded"},
+			expectedError: ErrInvalidSubscription,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sub, err := NewSubscription(tc.url, tc.events, tc.secret)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, sub.ID, 16)
+			assert.Equal(t, tc.url, sub.URL)
+			assert.Equal(t, tc.expectedEvents, sub.Events)
+			if tc.secret != "" {
+				assert.Equal(t, tc.secret, sub.Secret)
+			} else {
+				assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+			}
+		})
+	}
+}
+
+func Test_Wants(t *testing.T) {
+	assert.True(t, Subscription{}.Wants(LinkClicked))
+	assert.True(t, Subscription{Events: []EventType{LinkCreated, LinkClicked}}.Wants(LinkClicked))
+	assert.False(t, Subscription{Events: []EventType{LinkCreated}}.Wants(LinkClicked))
+}
@@ -2,17 +2,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	chiadapter "github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/connorpalermo/url-shortener/constant/logkey"
+	"github.com/connorpalermo/url-shortener/internal/analytics"
+	"github.com/connorpalermo/url-shortener/internal/botdetect"
+	"github.com/connorpalermo/url-shortener/internal/clickstream"
 	"github.com/connorpalermo/url-shortener/internal/endpoint"
+	"github.com/connorpalermo/url-shortener/internal/linkcheck"
 	"github.com/connorpalermo/url-shortener/internal/persistence"
 	"github.com/connorpalermo/url-shortener/internal/router"
 	"github.com/connorpalermo/url-shortener/internal/urlshortener"
+	"github.com/connorpalermo/url-shortener/internal/webhook"
 	"go.uber.org/zap"
 )
 
+// ScheduledEventType is the detail-type of events sent by EventBridge schedules.
+const ScheduledEventType = "Scheduled Event"
+
 func main() {
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -25,21 +37,114 @@ func main() {
 		return
 	}
 
+	check, err := urlshortener.ReputationCheckFromEnv()
+	if err != nil {
+		logger.Error("invalid reputation check configuration", zap.Error(err))
+		return
+	}
+
+	flushTimeout, err := webhook.FlushTimeoutFromEnv()
+	if err != nil {
+		logger.Error("invalid webhook configuration", zap.Error(err))
+		return
+	}
+	dispatcher := &webhook.Dispatcher{Store: &webhook.Store{DB: db}, Logger: logger}
+
 	u := &urlshortener.UrlShortener{
-		Logger:   logger,
-		DBClient: db,
+		Logger:     logger,
+		DBClient:   db,
+		Policy:     urlshortener.PolicyFromEnv(),
+		Reputation: check,
+		Events:     dispatcher,
 	}
 
-	mux := router.New(&endpoint.Handler{
-		Logger:               logger,
-		UrlShortenerProvider: u,
-	})
+	interstitials, err := endpoint.ParseInterstitialMode(os.Getenv(endpoint.InterstitialEnv))
+	if err != nil {
+		logger.Error("invalid interstitial configuration", zap.Error(err))
+		return
+	}
+
+	bots, err := botdetect.FromEnv()
+	if err != nil {
+		logger.Error("invalid crawler list", zap.Error(err))
+		return
+	}
+
+	sink, err := clickstream.SinkFromEnv(context.Background(), db)
+	if err != nil {
+		logger.Error("invalid click sink configuration", zap.Error(err))
+		return
+	}
+	var clicks *clickstream.Buffer
+	if sink != nil {
+		clicks = &clickstream.Buffer{Sink: sink, Logger: logger, Salt: os.Getenv(clickstream.SaltEnv)}
+	}
+
+	handler := &endpoint.Handler{
+		Logger:                logger,
+		UrlShortenerProvider:  u,
+		Dependencies:          []endpoint.DependencyChecker{db},
+		Interstitials:         interstitials,
+		InterstitialSkipToken: os.Getenv(endpoint.InterstitialSkipTokenEnv),
+		Events:                dispatcher,
+		Bots:                  bots,
+		Analytics:             &analytics.Reporter{DB: db},
+		AdminToken:            os.Getenv(endpoint.AdminTokenEnv),
+	}
+	if clicks != nil {
+		handler.Clicks = clicks
+	}
+	mux := router.New(logger, handler)
 
 	chiLambda := chiadapter.New(mux)
+	aggregator := &analytics.Aggregator{DB: db, Logger: logger}
+
+	// The function serves API Gateway requests, the scheduled link check and the table streams
+	// feeding the analytics rollups, told apart by the records of stream batches and the
+	// detail-type EventBridge puts on scheduled events.
+	lambda.Start(func(ctx context.Context, payload json.RawMessage) (any, error) {
+		// write out clicks and give webhook deliveries a moment to finish before the execution
+		// environment is frozen. Redirects do not wait for theirs, the link.clicked deliveries carry
+		// on when the environment is reused.
+		flushEvents := true
+		defer func() {
+			ctx := context.WithoutCancel(ctx)
+			if clicks != nil {
+				// failures are logged by Flush and retried on the next invocation
+				_ = clicks.Flush(ctx)
+			}
+			if !flushEvents {
+				return
+			}
+			ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+			defer cancel()
+			if err := dispatcher.Flush(ctx); err != nil {
+				logger.Warn("webhook deliveries still pending", zap.Error(err))
+			}
+		}()
+
+		var stream events.DynamoDBEvent
+		if err := json.Unmarshal(payload, &stream); err == nil && analytics.IsStreamEvent(stream) {
+			return aggregator.Handle(ctx, stream)
+		}
+
+		var event events.EventBridgeEvent
+		if err := json.Unmarshal(payload, &event); err == nil && event.DetailType == ScheduledEventType {
+			checker := &linkcheck.Checker{Client: linkcheck.NewClient(linkcheck.DefaultTimeout)}
+			return u.CheckLinks(ctx, checker)
+		}
 
-	// Start Lambda handler with ProxyWithContext
-	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-		logger.Info("Raw Request", zap.Any("request", request))
+		var request events.APIGatewayProxyRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		// redirects are the only GET and HEAD requests that emit events
+		flushEvents = request.HTTPMethod != http.MethodGet && request.HTTPMethod != http.MethodHead
+		// the body and headers are left out, they carry link passwords and tokens
+		logger.Info("Raw Request",
+			zap.String(logkey.RequestID, request.RequestContext.RequestID),
+			zap.String(logkey.Method, request.HTTPMethod),
+			zap.String(logkey.Path, request.Path))
 		return chiLambda.ProxyWithContext(ctx, request)
 	})
 }